@@ -1,10 +1,15 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"student-backend/models"
 	"time"
+	"unicode"
 
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
@@ -12,31 +17,99 @@ import (
 
 // Определяем тип JWTClaims здесь, чтобы избежать циклических импортов
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID uint        `json:"user_id"`
+	Email  string      `json:"email"`
+	Role   models.Role `json:"role"`
 	jwt.RegisteredClaims
 }
 
 type JWTService struct {
-	secretKey string
-	expiry    int
+	secretKey  string
+	expiry     int
+	roleExpiry map[string]int
 }
 
-func NewJWTService(secretKey string, expiry int) *JWTService {
+// NewJWTService создает сервис токенов. expiry - время жизни по умолчанию в часах,
+// roleExpiry переопределяет его для отдельных ролей
+func NewJWTService(secretKey string, expiry int, roleExpiry map[string]int) *JWTService {
 	return &JWTService{
-		secretKey: secretKey,
-		expiry:    expiry,
+		secretKey:  secretKey,
+		expiry:     expiry,
+		roleExpiry: roleExpiry,
 	}
 }
 
-// HashPassword хэширует пароль
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+// TTL возвращает время жизни токена для роли
+func (j *JWTService) TTL(role models.Role) time.Duration {
+	if hours, ok := j.roleExpiry[string(role)]; ok && hours > 0 {
+		return time.Hour * time.Duration(hours)
+	}
+	return time.Hour * time.Duration(j.expiry)
+}
+
+// GeneratePassword создает случайный пароль для учетных записей, заведенных администратором
+func GeneratePassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// GenerateVerificationToken создает случайный токен для ссылок из писем
+func GenerateVerificationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// APIKeyPrefix - префикс ключей API, по нему ключ легко узнать в конфигурации интеграции
+const APIKeyPrefix = "sbk_"
+
+// GenerateAPIKey создает новый ключ API
+func GenerateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return APIKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// HashToken возвращает SHA-256 токена из письма для хранения в базе
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Политика паролей: bcrypt учитывает только первые 72 байта
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// ValidatePassword проверяет пароль на соответствие политике
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
 	}
-	return string(hashedPassword), nil
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", MaxPasswordLength)
+	}
+	hasLetter, hasDigit := false, false
+	for _, c := range password {
+		switch {
+		case unicode.IsLetter(c):
+			hasLetter = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return fmt.Errorf("password must contain at least one letter and one digit")
+	}
+	return nil
 }
 
 // CheckPassword проверяет пароль
@@ -45,9 +118,9 @@ func CheckPassword(password, hashedPassword string) bool {
 	return err == nil
 }
 
-// GenerateToken создает JWT токен
+// GenerateToken создает JWT токен. Поле exp равно времени выдачи плюс TTL роли пользователя
 func (j *JWTService) GenerateToken(user *models.User) (string, error) {
-	expiryTime := time.Now().Add(time.Hour * time.Duration(j.expiry))
+	expiryTime := time.Now().Add(j.TTL(user.Role))
 
 	claims := JWTClaims{
 		UserID: user.ID,
@@ -91,5 +164,9 @@ func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
+	if !claims.Role.IsValid() {
+		return nil, fmt.Errorf("invalid token: unknown role %q", claims.Role)
+	}
+
 	return claims, nil
 }
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"strings"
+	"student-backend/models"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "test-secret"
+
+// signClaims подписывает произвольные claims тем же ключом, что и сервис
+func signClaims(t *testing.T, claims *JWTClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestValidateTokenChecksRole(t *testing.T) {
+	service := NewJWTService(testSecret, 1, nil)
+	expires := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		role    models.Role
+		wantErr bool
+	}{
+		{models.RoleAdmin, false},
+		{models.RoleTeacher, false},
+		{models.RoleStudent, false},
+		{"superuser", true},
+		{"Admin", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			token := signClaims(t, &JWTClaims{
+				UserID: 1, Email: "user@example.com", Role: tt.role,
+				RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expires},
+			})
+			claims, err := service.ValidateToken(token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateToken() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "unknown role") {
+				t.Fatalf("error = %v, want unknown role", err)
+			}
+			if err == nil && claims.Role != tt.role {
+				t.Fatalf("role = %q, want %q", claims.Role, tt.role)
+			}
+		})
+	}
+}
+
+func TestGenerateTokenRoundTrip(t *testing.T) {
+	service := NewJWTService(testSecret, 1, map[string]int{"admin": 2})
+	token, err := service.GenerateToken(&models.User{ID: 7, Email: "admin@example.com", Role: models.RoleAdmin})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	claims, err := service.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserID != 7 || claims.Role != models.RoleAdmin {
+		t.Fatalf("claims = %+v", claims)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl <= time.Hour || ttl > 2*time.Hour {
+		t.Fatalf("admin token lives %v, want the 2h role override", ttl)
+	}
+}
+
+func TestGenerateTokenPerRoleTTL(t *testing.T) {
+	service := NewJWTService(testSecret, 1, map[string]int{"admin": 2, "student": 8, "teacher": 0})
+
+	tests := []struct {
+		role models.Role
+		want time.Duration
+	}{
+		{models.RoleAdmin, 2 * time.Hour},
+		{models.RoleStudent, 8 * time.Hour},
+		// Нулевое переопределение не действует, используется срок по умолчанию
+		{models.RoleTeacher, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			if got := service.TTL(tt.role); got != tt.want {
+				t.Fatalf("TTL(%s) = %v, want %v", tt.role, got, tt.want)
+			}
+
+			token, err := service.GenerateToken(&models.User{ID: 7, Email: "user@example.com", Role: tt.role})
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			claims, err := service.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			// exp и iat округляются до секунд по отдельности
+			if lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time); lifetime < tt.want-time.Second || lifetime > tt.want {
+				t.Fatalf("%s token lives %v, want %v", tt.role, lifetime, tt.want)
+			}
+		})
+	}
+}
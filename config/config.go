@@ -1,20 +1,203 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	ServerPort string
-	DBHost     string
-	DBPort     int
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBSSLMode  string
-	JWTSecret  string
-	JWTExpiry  int // в часах
+
+	// HTTPS: при заданных TLSCertFile и TLSKeyFile сервер работает по TLS.
+	// HTTPRedirectPort - необязательный порт с перенаправлением HTTP на HTTPS
+	TLSCertFile      string
+	TLSKeyFile       string
+	HTTPRedirectPort string
+	// Вместо файлов сертификат можно получать у Let's Encrypt для доменов
+	// TLSAutocertDomains; сертификаты хранятся в TLSAutocertCacheDir
+	TLSAutocertDomains  []string
+	TLSAutocertCacheDir string
+	TLSAutocertEmail    string
+	DBHost              string
+	DBPort              int
+	DBUser              string
+	DBPassword          string
+	DBName              string
+	DBSSLMode           string
+	JWTSecret           string
+	JWTExpiry           int // в часах
+
+	// Время жизни токена по ролям в часах, например {"admin":2,"teacher":8,"student":24}.
+	// Для ролей без значения используется JWTExpiry
+	JWTRoleExpiry map[string]int
+
+	// Выдача нового токена в заголовке X-Refreshed-Token, когда до истечения текущего
+	// осталось не больше TokenRefreshWindowPercent процентов его срока жизни
+	TokenRefreshEnabled       bool
+	TokenRefreshWindowPercent int
+
+	// Максимальное время выполнения запросов к базе в рамках одного HTTP-запроса
+	DBQueryTimeout time.Duration
+	// Предельное время обработки HTTP-запроса и отдельный предел для выгрузок
+	RequestTimeout       time.Duration
+	ExportRequestTimeout time.Duration
+
+	// Подключение к базе при старте: число попыток и задержка перед второй попыткой,
+	// дальше задержка удваивается
+	DBConnectAttempts   int
+	DBConnectRetryDelay time.Duration
+	// Пул соединений с базой. DBMaxIdleConns не больше DBMaxOpenConns,
+	// DBMaxOpenConns 0 снимает ограничение (см. Validate)
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+	// DBReset удаляет все таблицы при старте и заполняет базу заново (для демо).
+	// Запрещен в production
+	DBReset bool
+
+	// Лимит попыток входа/регистрации в минуту на IP и на email (0 - без ограничения)
+	AuthRateLimitPerMinute int
+
+	// Общий лимит запросов с одного IP: скорость в секунду и размер всплеска (0 - без ограничения).
+	// TrustProxy разрешает брать IP клиента из X-Forwarded-For; без TrustedProxies
+	// доверенным прокси считается адрес соединения
+	RateLimitRPS   float64
+	RateLimitBurst int
+	TrustProxy     bool
+
+	// Сети, из которых доступны маршруты управления (преподаватели, учетные записи,
+	// ключи API, /api/admin). Пустой список отключает проверку. X-Forwarded-For
+	// учитывается для всех middleware только для соединений от TrustedProxies
+	AdminAllowedCIDRs []string
+	TrustedProxies    []string
+
+	// Журнал доступа: формат text или json и пути, которые не журналируются
+	AccessLogFormat       string
+	AccessLogExcludePaths []string
+	// LogRequestBodies включает отладочный лог тел запросов создания с маскированием
+	// персональных данных. Тела запросов аутентификации не логируются никогда
+	LogRequestBodies bool
+
+	// Трассировка OpenTelemetry: без OTLPEndpoint спаны не экспортируются
+	OTLPEndpoint    string
+	OTelServiceName string
+	TraceSampleRate float64
+
+	// Максимальный размер тела запроса в байтах
+	MaxBodyBytes int64
+
+	// Таймауты HTTP-сервера и лимит размера заголовков: защищают от медленных
+	// клиентов (slowloris), которые держат соединения открытыми
+	ServerReadHeaderTimeout time.Duration
+	ServerReadTimeout       time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+	ServerMaxHeaderBytes    int
+	// Время на запись одной порции потоковой выгрузки (CSV): выгрузка продлевает
+	// дедлайн записи на это время перед каждой порцией вместо общего WriteTimeout
+	ExportWriteTimeout time.Duration
+
+	// Плавная остановка: пауза после перевода /readyz в 503 и предельное время
+	// ожидания текущих запросов
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	// Формат ошибок: true - {"error": {"code", "message", ...}}, false - плоский
+	// {"error": "...", "code": "..."}, который разбирает текущий фронтенд
+	ErrorEnvelope bool
+
+	// Режим обслуживания: 503 для всех запросов, кроме проверок живости.
+	// Переключается через /api/admin/maintenance или SIGHUP
+	MaintenanceMode         bool
+	MaintenanceRetryAfter   time.Duration
+	MaintenanceBypassSecret string
+
+	// Переопределение заголовков безопасности (JSON-объект, пустое значение отключает заголовок)
+	SecurityHeaders map[string]string
+
+	// Размер страницы списков без параметра limit и наибольший допустимый limit
+	DefaultPageSize int
+	MaxPageSize     int
+
+	// Максимальное число записей в запросе массового создания
+	BulkMaxItems int
+
+	// Срок хранения ответов на запросы создания с заголовком Idempotency-Key
+	IdempotencyKeyTTL time.Duration
+
+	// Шаблон проверки телефона преподавателя (после удаления пробелов и дефисов)
+	PhonePattern string
+
+	// Шаблон кода группы (после обрезки пробелов и перевода в верхний регистр)
+	GroupCodePattern string
+
+	// Письма: адрес для ссылок, запрет входа без подтверждения email, срок жизни ссылки сброса пароля
+	AppBaseURL               string
+	RequireEmailVerification bool
+	PasswordResetTTL         time.Duration
+	// BcryptCost - стоимость bcrypt для хэшей паролей (4-31)
+	BcryptCost int
+
+	// Почта: без SMTPHost письма пишутся в лог
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string
+	SMTPFrom string
+	// NotifyOnCreate - письмо студенту или преподавателю при добавлении в систему
+	NotifyOnCreate bool
+
+	// Начальные данные
+	SeedAdminEmail    string
+	SeedAdminPassword string
+	// SeedFile - YAML или JSON с начальными данными вместо встроенных по умолчанию
+	SeedFile string
+	// Production включается PRODUCTION=true или APP_ENV=production
+	Production bool
+	// AppEnv - значение APP_ENV; если задано вместе с PRODUCTION, они должны совпадать
+	AppEnv string
+}
+
+// DefaultPhonePattern - номер в формате, близком к E.164
+const DefaultPhonePattern = `^\+?[0-9]{7,15}$`
+
+// DefaultGroupCodePattern - код группы вида INF-101
+const DefaultGroupCodePattern = `^[A-Z]{2,6}-\d{2,4}$`
+
+// DefaultSeedAdminPassword - пароль администратора для разработки, запрещен в продакшене
+const DefaultSeedAdminPassword = "admin123"
+
+// TLSEnabled сообщает, работает ли сервер по HTTPS: с сертификатом из файлов или autocert
+func (c *Config) TLSEnabled() bool {
+	return (c.TLSCertFile != "" && c.TLSKeyFile != "") || c.TLSAutocert()
+}
+
+// TLSAutocert сообщает, получается ли сертификат автоматически у Let's Encrypt
+func (c *Config) TLSAutocert() bool {
+	return len(c.TLSAutocertDomains) > 0
+}
+
+// Validate проверяет согласованность настроек, которую нельзя выразить значениями по умолчанию
+func (c *Config) Validate() error {
+	if c.DBMaxOpenConns < 0 || c.DBMaxIdleConns < 0 {
+		return fmt.Errorf("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative")
+	}
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		return fmt.Errorf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DBMaxIdleConns, c.DBMaxOpenConns)
+	}
+	if c.BcryptCost < 4 || c.BcryptCost > 31 {
+		return fmt.Errorf("BCRYPT_COST must be between 4 and 31, got %d", c.BcryptCost)
+	}
+	if c.AppEnv != "" && strings.EqualFold(c.AppEnv, "production") != c.Production {
+		return fmt.Errorf("PRODUCTION=%t conflicts with APP_ENV=%s; set one of them or make them agree", c.Production, c.AppEnv)
+	}
+	return nil
 }
 
 func Load() *Config {
@@ -26,8 +209,101 @@ func Load() *Config {
 		DBName:     getEnv("DB_NAME", "students_db"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
-		JWTSecret:  getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-		JWTExpiry:  getEnvAsInt("JWT_EXPIRY", 24),
+
+		TLSCertFile:      getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:       getEnv("TLS_KEY_FILE", ""),
+		HTTPRedirectPort: getEnv("HTTP_REDIRECT_PORT", ""),
+
+		TLSAutocertDomains:  getEnvAsList("TLS_AUTOCERT_DOMAINS", nil),
+		TLSAutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "autocert-cache"),
+		TLSAutocertEmail:    getEnv("TLS_AUTOCERT_EMAIL", ""),
+
+		JWTSecret: getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+		JWTExpiry: getEnvAsInt("JWT_EXPIRY", 24),
+
+		JWTRoleExpiry: getEnvAsIntMap("JWT_ROLE_EXPIRY"),
+
+		TokenRefreshEnabled:       getEnvAsBool("TOKEN_REFRESH_ENABLED", false),
+		TokenRefreshWindowPercent: getEnvAsInt("TOKEN_REFRESH_WINDOW_PERCENT", 20),
+
+		DBQueryTimeout:       getEnvAsDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+		RequestTimeout:       getEnvAsDuration("REQUEST_TIMEOUT", 15*time.Second),
+		ExportRequestTimeout: getEnvAsDuration("EXPORT_REQUEST_TIMEOUT", 10*time.Minute),
+
+		DBConnectAttempts:   getEnvAsInt("DB_CONNECT_ATTEMPTS", 10),
+		DBConnectRetryDelay: getEnvAsDuration("DB_CONNECT_RETRY_DELAY", time.Second),
+		DBReset:             getEnvAsBool("DB_RESET", false),
+
+		DBMaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		AuthRateLimitPerMinute: getEnvAsInt("AUTH_RATE_LIMIT_PER_MINUTE", 10),
+
+		RateLimitRPS:   getEnvAsFloat("RATE_LIMIT_RPS", 20),
+		RateLimitBurst: getEnvAsInt("RATE_LIMIT_BURST", 40),
+		TrustProxy:     getEnvAsBool("TRUST_PROXY", false),
+
+		AdminAllowedCIDRs: getEnvAsList("ADMIN_ALLOWED_CIDRS", nil),
+		TrustedProxies:    getEnvAsList("TRUSTED_PROXIES", nil),
+
+		AccessLogFormat:       getEnv("ACCESS_LOG_FORMAT", "text"),
+		AccessLogExcludePaths: getEnvAsList("ACCESS_LOG_EXCLUDE_PATHS", nil),
+		LogRequestBodies:      getEnvAsBool("LOG_REQUEST_BODIES", false),
+
+		OTLPEndpoint:    getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		OTelServiceName: getEnv("OTEL_SERVICE_NAME", "student-backend"),
+		TraceSampleRate: getEnvAsFloat("OTEL_TRACES_SAMPLE_RATE", 1.0),
+
+		MaxBodyBytes: int64(getEnvAsInt("MAX_BODY_BYTES", 1<<20)),
+
+		ServerReadHeaderTimeout: getEnvAsDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ServerReadTimeout:       getEnvAsDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerWriteTimeout:      getEnvAsDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		ServerIdleTimeout:       getEnvAsDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		ServerMaxHeaderBytes:    getEnvAsInt("SERVER_MAX_HEADER_BYTES", 1<<20),
+		ExportWriteTimeout:      getEnvAsDuration("EXPORT_WRITE_TIMEOUT", 30*time.Second),
+
+		ShutdownDelay:   getEnvAsDuration("SHUTDOWN_DELAY", 0),
+		ShutdownTimeout: getEnvAsDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+
+		ErrorEnvelope: getEnvAsBool("ERROR_ENVELOPE", false),
+
+		MaintenanceMode:         getEnvAsBool("MAINTENANCE_MODE", false),
+		MaintenanceRetryAfter:   getEnvAsDuration("MAINTENANCE_RETRY_AFTER", 2*time.Minute),
+		MaintenanceBypassSecret: getEnv("MAINTENANCE_BYPASS_SECRET", ""),
+
+		SecurityHeaders: getEnvAsStringMap("SECURITY_HEADERS"),
+
+		DefaultPageSize: getEnvAsInt("DEFAULT_PAGE_SIZE", 20),
+		MaxPageSize:     getEnvAsInt("MAX_PAGE_SIZE", 100),
+
+		BulkMaxItems: getEnvAsInt("BULK_MAX_ITEMS", 500),
+
+		IdempotencyKeyTTL: getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		PhonePattern:     getEnv("PHONE_PATTERN", DefaultPhonePattern),
+		GroupCodePattern: getEnv("GROUP_CODE_PATTERN", DefaultGroupCodePattern),
+
+		AppBaseURL:               getEnv("APP_BASE_URL", "http://localhost:8080"),
+		RequireEmailVerification: getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+		PasswordResetTTL:         getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+		BcryptCost:               getEnvAsInt("BCRYPT_COST", 10),
+
+		SMTPHost: getEnv("SMTP_HOST", ""),
+		SMTPPort: getEnvAsInt("SMTP_PORT", 587),
+		SMTPUser: getEnv("SMTP_USER", ""),
+		SMTPPass: getEnv("SMTP_PASS", ""),
+		SMTPFrom: getEnv("SMTP_FROM", ""),
+
+		NotifyOnCreate: getEnvAsBool("NOTIFY_ON_CREATE", false),
+
+		SeedAdminEmail:    getEnv("SEED_ADMIN_EMAIL", "admin@example.com"),
+		SeedAdminPassword: getEnv("SEED_ADMIN_PASSWORD", DefaultSeedAdminPassword),
+		SeedFile:          getEnv("SEED_FILE", ""),
+		Production:        getEnvAsBool("PRODUCTION", strings.EqualFold(getEnv("APP_ENV", ""), "production")),
+		AppEnv:            getEnv("APP_ENV", ""),
 	}
 }
 
@@ -38,6 +314,72 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsIntMap читает переменную в формате JSON-объекта {"key": число}.
+// Некорректное значение игнорируется с предупреждением
+// getEnvAsList читает список значений через запятую
+func getEnvAsList(key string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvAsStringMap(key string) map[string]string {
+	result := map[string]string{}
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			log.Printf("Warning: invalid %s value, ignoring: %v", key, err)
+			return map[string]string{}
+		}
+	}
+	return result
+}
+
+func getEnvAsIntMap(key string) map[string]int {
+	result := map[string]int{}
+	if value, exists := os.LookupEnv(key); exists && value != "" {
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			log.Printf("Warning: invalid %s value, ignoring: %v", key, err)
+			return map[string]int{}
+		}
+	}
+	return result
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsInt(key string, defaultValue int) int {
 	if value, exists := os.LookupEnv(key); exists {
 		if intValue, err := strconv.Atoi(value); err == nil {
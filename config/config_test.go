@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProductionFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name           string
+		production     string
+		appEnv         string
+		wantProduction bool
+		wantErr        bool
+	}{
+		{"neither set", "", "", false, false},
+		{"PRODUCTION only", "true", "", true, false},
+		{"APP_ENV only", "", "Production", true, false},
+		{"APP_ENV development", "", "development", false, false},
+		{"both agree", "true", "production", true, false},
+		{"both agree off", "false", "staging", false, false},
+		{"PRODUCTION=false overrides APP_ENV=production", "false", "production", false, true},
+		{"PRODUCTION=true with APP_ENV=staging", "true", "staging", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, "PRODUCTION", tt.production)
+			setEnv(t, "APP_ENV", tt.appEnv)
+
+			cfg := Load()
+			if cfg.Production != tt.wantProduction {
+				t.Fatalf("Production = %t, want %t", cfg.Production, tt.wantProduction)
+			}
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "APP_ENV") {
+				t.Fatalf("error %q does not name the conflicting variables", err)
+			}
+		})
+	}
+}
+
+// setEnv задает переменную на время теста; пустое значение - переменная не задана
+func setEnv(t *testing.T, key, value string) {
+	t.Helper()
+	t.Setenv(key, value)
+	if value == "" {
+		os.Unsetenv(key)
+	}
+}
@@ -3,35 +3,146 @@ package database
 import (
 	"fmt"
 	"log"
+	"strings"
 	"student-backend/config"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
+// InitDB подключается к базе по настройкам из cfg и применяет миграции всех моделей.
+// При DB_RESET сначала удаляет все таблицы; вне production это проверяет main
 func InitDB(cfg *config.Config) (*gorm.DB, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.DBReset {
+		log.Printf("⚠️ DB_RESET is set: dropping all tables in %s", cfg.DBName)
+		if err := Reset(db); err != nil {
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+			return nil, err
+		}
+	}
+
+	if err := Migrate(db, cfg); err != nil {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+	return db, nil
+}
+
+// Connect подключается к базе по настройкам из cfg без применения миграций
+func Connect(cfg *config.Config) (*gorm.DB, error) {
 	dsn := buildDSN(cfg)
 
 	log.Printf("Connecting to database...")
 	// Не логируем полный DSN из соображений безопасности
 	log.Printf("Database: %s@%s:%d/%s", cfg.DBUser, cfg.DBHost, cfg.DBPort, cfg.DBName)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := connectWithRetry(openPostgres(dsn), cfg.DBConnectAttempts, cfg.DBConnectRetryDelay, time.Sleep)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
+	}
+
+	// Запросы к базе попадают в трассу запроса дочерними спанами.
+	// Значения параметров не записываются, чтобы в трассы не попадали пароли и email
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		return nil, fmt.Errorf("failed to enable database tracing: %w", err)
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	log.Printf("Database pool: max open %d, max idle %d, max lifetime %v, max idle time %v",
+		cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBConnMaxLifetime, cfg.DBConnMaxIdleTime)
+
 	log.Println("Successfully connected to PostgreSQL with GORM!")
 	return db, nil
 }
 
+// maxConnectRetryDelay ограничивает рост задержки между попытками подключения
+const maxConnectRetryDelay = 30 * time.Second
+
+// opener открывает соединение и проверяет его; подменяется при проверке логики повторов
+type opener func() (*gorm.DB, error)
+
+func openPostgres(dsn string) opener {
+	return func() (*gorm.DB, error) {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		if err != nil {
+			return nil, err
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		if err := sqlDB.Ping(); err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+		return db, nil
+	}
+}
+
+// connectWithRetry повторяет подключение с экспоненциальной задержкой: база в
+// docker-compose часто поднимается позже бэкенда
+func connectWithRetry(open opener, attempts int, baseDelay time.Duration, sleep func(time.Duration)) (*gorm.DB, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := baseDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var db *gorm.DB
+		db, err = open()
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+		log.Printf("Database connection attempt %d/%d failed: %v, retrying in %v", attempt, attempts, err, delay)
+		sleep(delay)
+
+		delay *= 2
+		if delay > maxConnectRetryDelay {
+			delay = maxConnectRetryDelay
+		}
+	}
+
+	return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", attempts, err)
+}
+
+// buildDSN собирает строку подключения формата key=value из настроек.
+// Значения берутся в кавычки, поэтому пароль может содержать пробелы, кавычки и '='
 func buildDSN(cfg *config.Config) string {
 	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=UTC",
-		cfg.DBHost,
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBName,
+		dsnValue(cfg.DBHost),
+		dsnValue(cfg.DBUser),
+		dsnValue(cfg.DBPassword),
+		dsnValue(cfg.DBName),
 		cfg.DBPort,
-		cfg.DBSSLMode,
+		dsnValue(cfg.DBSSLMode),
 	)
 }
+
+// dsnValue экранирует обратную косую черту и одинарную кавычку и берет значение в кавычки
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
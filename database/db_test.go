@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"student-backend/config"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+func TestConnectWithRetry(t *testing.T) {
+	db := openTestDB(t)
+	errRefused := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		failures   int
+		attempts   int
+		wantErr    bool
+		wantCalls  int
+		wantDelays []time.Duration
+	}{
+		{"fails twice then succeeds", 2, 5, false, 3, []time.Duration{time.Second, 2 * time.Second}},
+		{"first attempt", 0, 5, false, 1, nil},
+		{"gives up", 10, 3, true, 3, []time.Duration{time.Second, 2 * time.Second}},
+		{"attempts below one", 10, 0, true, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			open := func() (*gorm.DB, error) {
+				calls++
+				if calls <= tt.failures {
+					return nil, errRefused
+				}
+				return db, nil
+			}
+			var delays []time.Duration
+			sleep := func(d time.Duration) { delays = append(delays, d) }
+
+			got, err := connectWithRetry(open, tt.attempts, time.Second, sleep)
+			if tt.wantErr {
+				if !errors.Is(err, errRefused) {
+					t.Fatalf("err = %v, want wrapped connection error", err)
+				}
+			} else if err != nil || got != db {
+				t.Fatalf("connectWithRetry = %v, %v; want the opened db", got, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("open called %d times, want %d", calls, tt.wantCalls)
+			}
+			if len(delays) != len(tt.wantDelays) {
+				t.Fatalf("delays = %v, want %v", delays, tt.wantDelays)
+			}
+			for i := range delays {
+				if delays[i] != tt.wantDelays[i] {
+					t.Fatalf("delays = %v, want %v", delays, tt.wantDelays)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectWithRetryCapsDelay(t *testing.T) {
+	var delays []time.Duration
+	_, err := connectWithRetry(func() (*gorm.DB, error) { return nil, errors.New("down") },
+		4, 20*time.Second, func(d time.Duration) { delays = append(delays, d) })
+	if err == nil {
+		t.Fatal("expected an error after all attempts failed")
+	}
+
+	want := []time.Duration{20 * time.Second, maxConnectRetryDelay, maxConnectRetryDelay}
+	if len(delays) != len(want) {
+		t.Fatalf("delays = %v, want %v", delays, want)
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("delays = %v, want %v", delays, want)
+		}
+	}
+}
+
+func TestBuildDSNSpecialCharacters(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"plain", "secret"},
+		{"spaces", "pass word with spaces"},
+		{"single quote", "it's"},
+		{"backslash", `back\slash`},
+		{"equals and quotes", `a=b 'c' "d"`},
+		{"trailing backslash", `end\`},
+		{"unicode", "пароль"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				DBHost: "db.internal", DBUser: "o'neil", DBPassword: tt.password,
+				DBName: "student db", DBPort: 6543, DBSSLMode: "disable",
+			}
+			parsed, err := pgconn.ParseConfig(buildDSN(cfg))
+			if err != nil {
+				t.Fatalf("DSN does not parse: %v", err)
+			}
+			if parsed.Password != tt.password {
+				t.Fatalf("password = %q, want %q", parsed.Password, tt.password)
+			}
+			if parsed.User != cfg.DBUser || parsed.Database != cfg.DBName || parsed.Host != cfg.DBHost || parsed.Port != 6543 {
+				t.Fatalf("parsed = user %q db %q host %q port %d", parsed.User, parsed.Database, parsed.Host, parsed.Port)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package database
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"student-backend/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFixture - начальные данные, если SEED_FILE не задан
+//
+//go:embed seed_default.yaml
+var defaultFixture []byte
+
+// minFixturePasswordLength совпадает с минимальной длиной пароля при регистрации
+const minFixturePasswordLength = 8
+
+// Fixture - начальные данные из YAML или JSON. Связи задаются кодом группы
+// и email студента или преподавателя
+type Fixture struct {
+	Groups   []FixtureGroup   `yaml:"groups"`
+	Teachers []FixtureTeacher `yaml:"teachers"`
+	Students []FixtureStudent `yaml:"students"`
+	Users    []FixtureUser    `yaml:"users"`
+}
+
+type FixtureGroup struct {
+	Name     string `yaml:"name"`
+	Code     string `yaml:"code"`
+	Year     int    `yaml:"year"`
+	Semester int    `yaml:"semester"`
+	Line     int    `yaml:"-"`
+}
+
+type FixtureTeacher struct {
+	Name    string `yaml:"name"`
+	Surname string `yaml:"surname"`
+	Email   string `yaml:"email"`
+	Phone   string `yaml:"phone"`
+	Title   string `yaml:"title"`
+	// Groups - коды групп преподавателя
+	Groups []string `yaml:"groups"`
+	Line   int      `yaml:"-"`
+}
+
+type FixtureStudent struct {
+	Name    string `yaml:"name"`
+	Surname string `yaml:"surname"`
+	Email   string `yaml:"email"`
+	// Group - код группы студента
+	Group string `yaml:"group"`
+	Line  int    `yaml:"-"`
+}
+
+type FixtureUser struct {
+	Email string `yaml:"email"`
+	// Password - пароль открытым текстом, хэшируется при сохранении
+	Password string      `yaml:"password"`
+	Role     models.Role `yaml:"role"`
+	// Student и Teacher - email связанной записи
+	Student string `yaml:"student"`
+	Teacher string `yaml:"teacher"`
+	Line    int    `yaml:"-"`
+}
+
+// UnmarshalYAML запоминают строку элемента для сообщений об ошибках
+func (g *FixtureGroup) UnmarshalYAML(node *yaml.Node) error {
+	type plain FixtureGroup
+	if err := node.Decode((*plain)(g)); err != nil {
+		return err
+	}
+	g.Line = node.Line
+	return nil
+}
+
+func (t *FixtureTeacher) UnmarshalYAML(node *yaml.Node) error {
+	type plain FixtureTeacher
+	if err := node.Decode((*plain)(t)); err != nil {
+		return err
+	}
+	t.Line = node.Line
+	return nil
+}
+
+func (s *FixtureStudent) UnmarshalYAML(node *yaml.Node) error {
+	type plain FixtureStudent
+	if err := node.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	s.Line = node.Line
+	return nil
+}
+
+func (u *FixtureUser) UnmarshalYAML(node *yaml.Node) error {
+	type plain FixtureUser
+	if err := node.Decode((*plain)(u)); err != nil {
+		return err
+	}
+	u.Line = node.Line
+	return nil
+}
+
+// FixtureError - все найденные в файле ошибки с номерами строк
+type FixtureError struct {
+	Source   string
+	Problems []string
+}
+
+func (e *FixtureError) Error() string {
+	return fmt.Sprintf("invalid seed fixture %s:\n  %s", e.Source, strings.Join(e.Problems, "\n  "))
+}
+
+func (e *FixtureError) add(line int, format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, args...)))
+}
+
+// LoadFixture читает файл path или, если путь пуст, встроенные данные по умолчанию
+func LoadFixture(path string) (*Fixture, error) {
+	if path == "" {
+		return ParseFixture(defaultFixture, "(embedded defaults)")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed fixture: %w", err)
+	}
+	return ParseFixture(data, path)
+}
+
+// ParseFixture разбирает YAML или JSON и проверяет данные до обращения к базе.
+// Ошибка проверки возвращается как *FixtureError со всеми найденными проблемами
+func ParseFixture(data []byte, source string) (*Fixture, error) {
+	var fixture Fixture
+	if err := yaml.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse seed fixture %s: %w", source, err)
+	}
+
+	if err := fixture.validate(source); err != nil {
+		return nil, err
+	}
+	return &fixture, nil
+}
+
+// validate проверяет обязательные поля, дубли и ссылки между разделами
+func (f *Fixture) validate(source string) error {
+	problems := &FixtureError{Source: source}
+
+	groupCodes := make(map[string]bool, len(f.Groups))
+	for _, group := range f.Groups {
+		if strings.TrimSpace(group.Name) == "" {
+			problems.add(group.Line, "group name is required")
+		}
+		if strings.TrimSpace(group.Code) == "" {
+			problems.add(group.Line, "group code is required")
+			continue
+		}
+		if groupCodes[group.Code] {
+			problems.add(group.Line, "duplicate group code %s", group.Code)
+		}
+		groupCodes[group.Code] = true
+	}
+
+	teacherEmails := make(map[string]bool, len(f.Teachers))
+	for _, teacher := range f.Teachers {
+		if strings.TrimSpace(teacher.Name) == "" || strings.TrimSpace(teacher.Surname) == "" {
+			problems.add(teacher.Line, "teacher name and surname are required")
+		}
+		email := models.NormalizeEmail(teacher.Email)
+		if email == "" {
+			problems.add(teacher.Line, "teacher email is required")
+		} else if teacherEmails[email] {
+			problems.add(teacher.Line, "duplicate teacher email %s", email)
+		}
+		teacherEmails[email] = true
+		for _, code := range teacher.Groups {
+			if !groupCodes[code] {
+				problems.add(teacher.Line, "unknown group code %s", code)
+			}
+		}
+	}
+
+	studentEmails := make(map[string]bool, len(f.Students))
+	for _, student := range f.Students {
+		if strings.TrimSpace(student.Name) == "" || strings.TrimSpace(student.Surname) == "" {
+			problems.add(student.Line, "student name and surname are required")
+		}
+		// Email - ключ студента при повторном запуске и ссылка из users
+		email := models.NormalizeEmail(student.Email)
+		if email == "" {
+			problems.add(student.Line, "student email is required")
+		} else if studentEmails[email] {
+			problems.add(student.Line, "duplicate student email %s", email)
+		}
+		studentEmails[email] = true
+		if student.Group != "" && !groupCodes[student.Group] {
+			problems.add(student.Line, "unknown group code %s", student.Group)
+		}
+	}
+
+	userEmails := make(map[string]bool, len(f.Users))
+	for _, user := range f.Users {
+		email := models.NormalizeEmail(user.Email)
+		if email == "" {
+			problems.add(user.Line, "user email is required")
+		} else if userEmails[email] {
+			problems.add(user.Line, "duplicate user email %s", email)
+		}
+		userEmails[email] = true
+
+		if !user.Role.IsValid() {
+			problems.add(user.Line, "unknown role %q", user.Role)
+		}
+		if len(user.Password) < minFixturePasswordLength {
+			problems.add(user.Line, "password must be at least %d characters", minFixturePasswordLength)
+		}
+		if user.Student != "" {
+			if user.Role != models.RoleStudent {
+				problems.add(user.Line, "only student accounts can be linked to a student")
+			}
+			if !studentEmails[models.NormalizeEmail(user.Student)] {
+				problems.add(user.Line, "unknown student %s", user.Student)
+			}
+		}
+		if user.Teacher != "" {
+			if user.Role != models.RoleTeacher {
+				problems.add(user.Line, "only teacher accounts can be linked to a teacher")
+			}
+			if !teacherEmails[models.NormalizeEmail(user.Teacher)] {
+				problems.add(user.Line, "unknown teacher %s", user.Teacher)
+			}
+		}
+	}
+
+	if len(problems.Problems) > 0 {
+		return problems
+	}
+	return nil
+}
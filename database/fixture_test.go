@@ -0,0 +1,31 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFixtureRejectsUnknownRole(t *testing.T) {
+	data := []byte(`users:
+  - email: admin@example.com
+    password: password123
+    role: admin
+  - email: root@example.com
+    password: password123
+    role: superuser
+`)
+
+	_, err := ParseFixture(data, "seed.yaml")
+	if err == nil {
+		t.Fatal("fixture with an unknown role was accepted")
+	}
+	if !strings.Contains(err.Error(), `unknown role "superuser"`) || !strings.Contains(err.Error(), "5") {
+		t.Fatalf("error = %q, want the unknown role and its line", err)
+	}
+}
+
+func TestParseFixtureDefault(t *testing.T) {
+	if _, err := ParseFixture(defaultFixture, "seed_default.yaml"); err != nil {
+		t.Fatalf("default fixture: %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"path/filepath"
+	"student-backend/config"
+	"student-backend/models"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB открывает пустую базу SQLite во временном каталоге.
+// testutil.NewDB здесь недоступен: он сам импортирует database
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sqlite handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// migratedTestDB открывает тестовую базу и применяет миграции
+func migratedTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openTestDB(t)
+	if err := Migrate(db, &config.Config{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	models.SetPasswordCost(4)
+	return db
+}
+
+// countRows возвращает число строк модели, включая мягко удаленные
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Unscoped().Model(model).Count(&count).Error; err != nil {
+		t.Fatalf("count %T: %v", model, err)
+	}
+	return count
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migration - шаг изменения схемы. Версии только растут, примененный шаг
+// не редактируется: изменения схемы добавляются новым шагом в конец списка
+type migration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+// migrations - шаги в порядке применения
+var migrations = []migration{
+	{version: 1, name: "initial schema", up: initialSchema},
+	{version: 2, name: "hash verification tokens", up: hashVerificationTokens},
+}
+
+// schemaModels - модели всех таблиц текущей схемы
+func schemaModels() []interface{} {
+	return []interface{}{
+		&models.Group{},
+		&models.Student{},
+		&models.Teacher{},
+		&models.User{},
+		&models.AuditLog{},
+		&models.AuditEntry{},
+		&models.StudentGroupHistory{},
+		&models.PasswordResetToken{},
+		&models.APIKey{},
+		&models.FeatureFlag{},
+		&models.IdempotencyKey{},
+		&models.Setting{},
+	}
+}
+
+// initialSchema создает таблицы по снимку моделей v1Models. В базах, созданных до
+// появления schema_migrations, таблицы уже есть, и AutoMigrate лишь досоздает недостающее
+func initialSchema(tx *gorm.DB) error {
+	// Учетные записи, созданные до появления подтверждения email, считаются подтвержденными
+	backfillEmailVerified := tx.Migrator().HasTable(&v1User{}) &&
+		!tx.Migrator().HasColumn(&v1User{}, "EmailVerified")
+
+	if err := tx.AutoMigrate(v1Models()...); err != nil {
+		return err
+	}
+
+	if backfillEmailVerified {
+		if err := tx.Model(&v1User{}).Where("1 = 1").Update("email_verified", true).Error; err != nil {
+			return fmt.Errorf("failed to mark existing users as verified: %w", err)
+		}
+	}
+	return nil
+}
+
+// hashVerificationTokens заменяет токены подтверждения email, сохраненные
+// открытым текстом, их хэшами: ссылки из уже отправленных писем остаются рабочими
+func hashVerificationTokens(tx *gorm.DB) error {
+	var users []models.User
+	if err := tx.Select("id", "verification_token").
+		Where("verification_token <> ''").Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to load verification tokens: %w", err)
+	}
+
+	for _, user := range users {
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+			UpdateColumn("verification_token", auth.HashToken(user.VerificationToken)).Error; err != nil {
+			return fmt.Errorf("failed to hash verification token of user %d: %w", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// MigrationStatus - состояние одного шага миграции
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// migrationLockKey - ключ advisory lock PostgreSQL, которым сериализуются миграции
+const migrationLockKey = 7_415_126_031
+
+// Migrate применяет еще не примененные шаги по порядку, каждый в своей транзакции.
+// Повторный запуск ничего не меняет. Начальные данные заполняются отдельно через Seed
+func Migrate(db *gorm.DB, cfg *config.Config) error {
+	log.Println("Running database migrations...")
+	return withMigrationLock(db, migrate)
+}
+
+// withMigrationLock выполняет fn под advisory lock, чтобы реплики, стартующие
+// одновременно, не применяли одни и те же шаги параллельно. Блокировка сессионная,
+// поэтому fn получает одно закрепленное соединение. SQLite не поддерживает
+// advisory lock и сам сериализует запись, для него fn вызывается напрямую
+func withMigrationLock(db *gorm.DB, fn func(db *gorm.DB) error) error {
+	if db.Dialector.Name() != "postgres" {
+		return fn(db)
+	}
+
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer func() {
+			if err := conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error; err != nil {
+				log.Printf("Failed to release migration lock: %v", err)
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// migrate применяет недостающие шаги; примененные версии читаются уже под блокировкой
+func migrate(db *gorm.DB) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, step := range migrations {
+		if _, ok := applied[step.version]; ok {
+			continue
+		}
+
+		log.Printf("Applying migration %d: %s", step.version, step.name)
+		err := WithTx(db, func(tx *gorm.DB) error {
+			if err := step.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&models.SchemaMigration{
+				Version:   step.version,
+				Name:      step.name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %w", step.version, step.name, err)
+		}
+		count++
+	}
+
+	log.Printf("Database migrations completed: %d applied, schema version %d", count, migrations[len(migrations)-1].version)
+	return nil
+}
+
+// Status возвращает все известные шаги с временем применения; AppliedAt == nil -
+// шаг еще не применен
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, step := range migrations {
+		status := MigrationStatus{Version: step.version, Name: step.name}
+		if record, ok := applied[step.version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Reset удаляет таблицы всех моделей вместе с историей миграций.
+// Данные теряются безвозвратно; проверки перед вызовом выполняет вызывающий
+func Reset(db *gorm.DB) error {
+	tables := append([]interface{}{"teacher_groups"}, schemaModels()...)
+	tables = append(tables, &models.SchemaMigration{})
+	for _, table := range tables {
+		name := tableName(db, table)
+		if !db.Migrator().HasTable(table) {
+			continue
+		}
+		if err := db.Migrator().DropTable(table); err != nil {
+			return fmt.Errorf("failed to drop table %s: %w", name, err)
+		}
+		log.Printf("⚠️ Dropped table %s", name)
+	}
+	return nil
+}
+
+// tableName возвращает имя таблицы модели или само имя, если передана строка
+func tableName(db *gorm.DB, table interface{}) string {
+	if name, ok := table.(string); ok {
+		return name
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(table); err != nil {
+		return fmt.Sprintf("%T", table)
+	}
+	return stmt.Schema.Table
+}
+
+// appliedMigrations создает schema_migrations при необходимости и возвращает
+// примененные версии
+func appliedMigrations(db *gorm.DB) (map[int]models.SchemaMigration, error) {
+	if err := db.AutoMigrate(&models.SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var records []models.SchemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]models.SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
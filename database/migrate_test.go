@@ -0,0 +1,247 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMigrateHashesPlaintextVerificationTokens(t *testing.T) {
+	db := migratedTestDB(t)
+
+	// Токен, сохраненный до появления шага 2 открытым текстом
+	user := models.User{Email: "pending@example.com", Password: "password123", Role: models.RoleStudent}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	db.Model(&user).UpdateColumn("verification_token", "plain-token")
+	verified := models.User{Email: "verified@example.com", Password: "password123", Role: models.RoleStudent, EmailVerified: true}
+	if err := db.Create(&verified).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	db.Where("version = ?", 2).Delete(&models.SchemaMigration{})
+
+	if err := Migrate(db, &config.Config{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var got models.User
+	db.First(&got, user.ID)
+	if got.VerificationToken != auth.HashToken("plain-token") {
+		t.Fatalf("verification token = %q, want its hash", got.VerificationToken)
+	}
+	var untouched models.User
+	db.First(&untouched, verified.ID)
+	if untouched.VerificationToken != "" {
+		t.Fatalf("empty verification token became %q", untouched.VerificationToken)
+	}
+}
+
+func TestMigratedSchemaMatchesModels(t *testing.T) {
+	migrated := migratedTestDB(t)
+	current := openTestDB(t)
+	if err := current.AutoMigrate(schemaModels()...); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+
+	want, got := sqliteSchema(t, current), sqliteSchema(t, migrated)
+	for name, ddl := range want {
+		if got[name] != ddl {
+			t.Errorf("%s differs from the models, add a migration:\n got: %s\nwant: %s", name, got[name], ddl)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("migrations create %s, which no model declares", name)
+		}
+	}
+}
+
+// sqliteSchema возвращает DDL таблиц и индексов базы, кроме служебных
+func sqliteSchema(t *testing.T, db *gorm.DB) map[string]string {
+	t.Helper()
+	var rows []struct {
+		Name string
+		SQL  string
+	}
+	if err := db.Raw(`SELECT name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT IN ('sqlite_sequence', 'schema_migrations')`).Scan(&rows).Error; err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	schema := make(map[string]string, len(rows))
+	for _, row := range rows {
+		schema[row.Name] = sortConstraints(row.SQL)
+	}
+	return schema
+}
+
+// sortConstraints упорядочивает ограничения в CREATE TABLE: GORM перечисляет
+// внешние ключи в порядке обхода map
+func sortConstraints(ddl string) string {
+	body := strings.TrimSuffix(ddl, ")")
+	parts := strings.Split(body, ",CONSTRAINT ")
+	sort.Strings(parts[1:])
+	return strings.Join(parts, ",CONSTRAINT ") + ")"
+}
+
+func TestMigrateTwiceAppliesNothing(t *testing.T) {
+	db := migratedTestDB(t)
+	if err := Migrate(db, &config.Config{}); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+
+	statuses, err := Status(db)
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	var applied int64
+	db.Model(&models.SchemaMigration{}).Count(&applied)
+	if int(applied) != len(migrations) || len(statuses) != len(migrations) {
+		t.Fatalf("applied %d of %d migrations", applied, len(migrations))
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Fatalf("migration %d is not applied", status.Version)
+		}
+	}
+}
+
+func TestMigrationLockSkippedForSQLite(t *testing.T) {
+	db := openTestDB(t)
+	var got *gorm.DB
+	if err := withMigrationLock(db, func(conn *gorm.DB) error { got = conn; return nil }); err != nil {
+		t.Fatalf("withMigrationLock: %v", err)
+	}
+	if got != db {
+		t.Fatal("sqlite migrations should run on the original handle without a lock")
+	}
+}
+
+// advisoryLocks имитирует pg_advisory_lock: блокирует вызывающего, пока ключ занят
+type advisoryLocks struct {
+	mu    sync.Mutex
+	held  map[int64]chan struct{}
+	calls []string
+}
+
+func (l *advisoryLocks) lock(key int64) int64 {
+	for {
+		l.mu.Lock()
+		wait, busy := l.held[key]
+		if !busy {
+			l.held[key] = make(chan struct{})
+			l.calls = append(l.calls, "lock")
+			l.mu.Unlock()
+			return 1
+		}
+		l.mu.Unlock()
+		<-wait
+	}
+}
+
+func (l *advisoryLocks) unlock(key int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, "unlock")
+	if wait, ok := l.held[key]; ok {
+		delete(l.held, key)
+		close(wait)
+		return 1
+	}
+	return 0
+}
+
+// openAdvisoryLockDB открывает SQLite с функциями pg_advisory_lock/unlock под
+// диалектом postgres, чтобы проверить путь блокировки без сервера PostgreSQL
+func openAdvisoryLockDB(t *testing.T, locks *advisoryLocks) *gorm.DB {
+	t.Helper()
+	driverName := "sqlite3_advisory_" + t.Name()
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("pg_advisory_lock", locks.lock, false); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("pg_advisory_unlock", locks.unlock, false)
+		},
+	})
+	sqlDB, err := sql.Open(driverName, filepath.Join(t.TempDir(), "lock.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Silent),
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open postgres dialector: %v", err)
+	}
+	return db
+}
+
+func TestMigrationLockSerializesConcurrentRuns(t *testing.T) {
+	locks := &advisoryLocks{held: map[int64]chan struct{}{}}
+	db := openAdvisoryLockDB(t, locks)
+
+	var running, overlaps int32
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- withMigrationLock(db, func(conn *gorm.DB) error {
+				if atomic.AddInt32(&running, 1) > 1 {
+					atomic.AddInt32(&overlaps, 1)
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("withMigrationLock: %v", err)
+		}
+	}
+	if overlaps != 0 {
+		t.Fatalf("%d migration runs overlapped", overlaps)
+	}
+	want := []string{"lock", "unlock", "lock", "unlock", "lock", "unlock"}
+	if fmt.Sprint(locks.calls) != fmt.Sprint(want) {
+		t.Fatalf("lock calls = %v, want %v", locks.calls, want)
+	}
+}
+
+func TestMigrationLockReleasedOnError(t *testing.T) {
+	locks := &advisoryLocks{held: map[int64]chan struct{}{}}
+	db := openAdvisoryLockDB(t, locks)
+	errFailed := errors.New("step failed")
+
+	if err := withMigrationLock(db, func(*gorm.DB) error { return errFailed }); !errors.Is(err, errFailed) {
+		t.Fatalf("err = %v, want %v", err, errFailed)
+	}
+	if len(locks.held) != 0 || fmt.Sprint(locks.calls) != "[lock unlock]" {
+		t.Fatalf("lock not released: held %v, calls %v", locks.held, locks.calls)
+	}
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Снимок моделей на момент миграции 1. Шаг создает таблицы по этим структурам,
+// а не по пакету models: последующие изменения моделей не должны менять уже
+// примененный шаг. Новые поля и таблицы добавляются новой миграцией
+
+type v1Group struct {
+	ID        uint        `gorm:"primaryKey;autoIncrement"`
+	Name      string      `gorm:"not null;size:100"`
+	Code      string      `gorm:"not null;size:20;uniqueIndex:idx_groups_code_year_semester"`
+	Year      int         `gorm:"not null;default:0;uniqueIndex:idx_groups_code_year_semester"`
+	Semester  int         `gorm:"not null;default:0;uniqueIndex:idx_groups_code_year_semester"`
+	Archived  bool        `gorm:"not null;default:false;index"`
+	CuratorID *uint       `gorm:"index"`
+	Curator   *v1Teacher  `gorm:"foreignKey:CuratorID"`
+	Students  []v1Student `gorm:"foreignKey:GroupID"`
+	Version   int         `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Group) TableName() string { return "groups" }
+
+type v1Student struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Name      string `gorm:"size:100;not null"`
+	Surname   string `gorm:"size:100;not null"`
+	Email     string `gorm:"size:255"`
+	GroupID   *uint
+	Group     *v1Group `gorm:"foreignKey:GroupID"`
+	UserID    *uint    `gorm:"unique"`
+	Version   int      `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Student) TableName() string { return "students" }
+
+type v1Teacher struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	Name         string `gorm:"not null;size:100"`
+	Surname      string `gorm:"not null;size:100"`
+	Email        string `gorm:"unique;size:255"`
+	Phone        string `gorm:"size:20"`
+	Title        string `gorm:"size:50"`
+	DepartmentID *uint  `gorm:"index"`
+	UserID       *uint  `gorm:"unique"`
+	Version      int    `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1Teacher) TableName() string { return "teachers" }
+
+// v1TeacherGroup - таблица связи many2many преподавателей и групп. Объявлена явно:
+// имена ограничений, которые GORM выводит для связи, зависят от имен типов
+type v1TeacherGroup struct {
+	TeacherID uint       `gorm:"primaryKey;autoIncrement:false"`
+	GroupID   uint       `gorm:"primaryKey;autoIncrement:false"`
+	Teacher   *v1Teacher `gorm:"foreignKey:TeacherID"`
+	Group     *v1Group   `gorm:"foreignKey:GroupID"`
+}
+
+func (v1TeacherGroup) TableName() string { return "teacher_groups" }
+
+type v1User struct {
+	ID                uint       `gorm:"primaryKey;autoIncrement"`
+	Email             string     `gorm:"unique;not null;size:255"`
+	Password          string     `gorm:"not null;size:255"`
+	Role              string     `gorm:"not null;size:50"`
+	EmailVerified     bool       `gorm:"not null;default:false"`
+	VerificationToken string     `gorm:"size:64;index"`
+	TwoFactorSecret   string     `gorm:"size:64"`
+	TwoFactorEnabled  bool       `gorm:"not null;default:false"`
+	StudentID         *uint      `gorm:"unique"`
+	TeacherID         *uint      `gorm:"unique"`
+	Student           *v1Student `gorm:"foreignKey:StudentID"`
+	Teacher           *v1Teacher `gorm:"foreignKey:TeacherID"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	DeletedAt         gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1User) TableName() string { return "users" }
+
+type v1AuditLog struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"index"`
+	Action    string `gorm:"not null;size:20;index"`
+	Entity    string `gorm:"not null;size:50;index"`
+	EntityID  uint
+	Detail    string `gorm:"size:500"`
+	CreatedAt time.Time
+}
+
+func (v1AuditLog) TableName() string { return "audit_logs" }
+
+type v1AuditEntry struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	UserID     uint   `gorm:"index"`
+	Email      string `gorm:"size:255;index"`
+	Method     string `gorm:"not null;size:10"`
+	Route      string `gorm:"not null;size:255"`
+	Entity     string `gorm:"size:50;index"`
+	EntityID   string `gorm:"size:64;index"`
+	StatusCode int
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (v1AuditEntry) TableName() string { return "audit_entries" }
+
+type v1StudentGroupHistory struct {
+	ID          uint `gorm:"primaryKey;autoIncrement"`
+	StudentID   uint `gorm:"not null;index"`
+	FromGroupID *uint
+	ToGroupID   *uint
+	ChangedBy   uint      `gorm:"not null"`
+	ChangedAt   time.Time `gorm:"not null;index"`
+}
+
+func (v1StudentGroupHistory) TableName() string { return "student_group_history" }
+
+type v1PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	UserID    uint      `gorm:"not null;index"`
+	Token     string    `gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+func (v1PasswordResetToken) TableName() string { return "password_reset_tokens" }
+
+type v1APIKey struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	Key        string `gorm:"not null;size:64;uniqueIndex"`
+	Prefix     string `gorm:"not null;size:16"`
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"not null;size:100"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (v1APIKey) TableName() string { return "api_keys" }
+
+type v1FeatureFlag struct {
+	Name      string `gorm:"primaryKey;size:64"`
+	Enabled   bool   `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+func (v1FeatureFlag) TableName() string { return "feature_flags" }
+
+type v1IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `gorm:"not null;size:255;uniqueIndex:idx_idempotency_user_key"`
+	RequestHash  string    `gorm:"not null;size:64"`
+	StatusCode   int       `gorm:"not null;default:0"`
+	ResponseBody string    `gorm:"type:text"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+func (v1IdempotencyKey) TableName() string { return "idempotency_keys" }
+
+type v1Setting struct {
+	Key       string `gorm:"primaryKey;size:64"`
+	Value     string `gorm:"type:text;not null"`
+	UpdatedAt time.Time
+}
+
+func (v1Setting) TableName() string { return "settings" }
+
+// v1Models - таблицы миграции 1 в порядке создания
+func v1Models() []interface{} {
+	return []interface{}{
+		&v1Group{},
+		&v1Student{},
+		&v1Teacher{},
+		&v1TeacherGroup{},
+		&v1User{},
+		&v1AuditLog{},
+		&v1AuditEntry{},
+		&v1StudentGroupHistory{},
+		&v1PasswordResetToken{},
+		&v1APIKey{},
+		&v1FeatureFlag{},
+		&v1IdempotencyKey{},
+		&v1Setting{},
+	}
+}
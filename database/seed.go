@@ -0,0 +1,171 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"student-backend/config"
+	"student-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Seed заполняет базу начальными данными: администратором из SEED_ADMIN_EMAIL
+// и SEED_ADMIN_PASSWORD и данными из файла SEED_FILE (YAML или JSON) или, если он
+// не задан, встроенными seed_default.yaml. Файл проверяется целиком до обращения
+// к базе, а все записи создаются в одной транзакции.
+// Каждая запись создается через FirstOrCreate по уникальному ключу (email или код группы),
+// поэтому повторный запуск не создает дублей, не трогает существующие данные
+// и восстанавливает только недостающие записи. Таблицы должны быть созданы Migrate
+func Seed(db *gorm.DB, cfg *config.Config) error {
+	fixture, err := LoadFixture(cfg.SeedFile)
+	if err != nil {
+		return err
+	}
+
+	// Тестовые учетные записи по умолчанию нужны только для разработки,
+	// явно заданный файл применяется полностью
+	if cfg.Production && cfg.SeedFile == "" {
+		fixture.Teachers, fixture.Students, fixture.Users = nil, nil, nil
+	}
+
+	if cfg.SeedFile != "" {
+		log.Printf("Seeding initial data from %s...", cfg.SeedFile)
+	} else {
+		log.Println("Seeding initial data...")
+	}
+
+	err = WithTx(db, func(tx *gorm.DB) error {
+		if err := seedAdmin(tx, cfg); err != nil {
+			return err
+		}
+		return seedFixture(tx, fixture)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Println("Initial data seeded")
+	return nil
+}
+
+func seedAdmin(db *gorm.DB, cfg *config.Config) error {
+	var existing models.User
+	err := db.Where("email = ?", models.NormalizeEmail(cfg.SeedAdminEmail)).First(&existing).Error
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check admin: %w", err)
+	}
+
+	// Пароль администратора по умолчанию недопустим в продакшене
+	if cfg.Production && cfg.SeedAdminPassword == config.DefaultSeedAdminPassword {
+		return errors.New("refusing to seed default admin password in production: set SEED_ADMIN_PASSWORD")
+	}
+
+	if _, err := seedUser(db, cfg.SeedAdminEmail, cfg.SeedAdminPassword, models.RoleAdmin, nil, nil); err != nil {
+		return err
+	}
+
+	log.Printf("Admin user created: %s", cfg.SeedAdminEmail)
+	return nil
+}
+
+// seedFixture создает группы, преподавателей, студентов и пользователей из
+// проверенного fixture, связывая их по коду группы и email
+func seedFixture(db *gorm.DB, fixture *Fixture) error {
+	groups := make(map[string]*models.Group, len(fixture.Groups))
+	for _, item := range fixture.Groups {
+		group := models.Group{Name: item.Name, Code: item.Code, Year: item.Year, Semester: item.Semester}
+		if err := db.Where("code = ?", group.Code).Attrs(group).FirstOrCreate(&group).Error; err != nil {
+			return fmt.Errorf("failed to seed group %s: %w", item.Code, err)
+		}
+		groups[item.Code] = &group
+	}
+
+	teachers := make(map[string]*models.Teacher, len(fixture.Teachers))
+	for _, item := range fixture.Teachers {
+		teacher := models.Teacher{
+			Name:    item.Name,
+			Surname: item.Surname,
+			Email:   models.NormalizeEmail(item.Email),
+			Phone:   item.Phone,
+			Title:   item.Title,
+		}
+		if err := db.Where("email = ?", teacher.Email).Attrs(teacher).FirstOrCreate(&teacher).Error; err != nil {
+			return fmt.Errorf("failed to seed teacher %s: %w", teacher.Email, err)
+		}
+		if len(item.Groups) > 0 {
+			teacherGroups := make([]models.Group, 0, len(item.Groups))
+			for _, code := range item.Groups {
+				teacherGroups = append(teacherGroups, *groups[code])
+			}
+			if err := db.Model(&teacher).Association("Groups").Append(teacherGroups); err != nil {
+				return fmt.Errorf("failed to assign groups to teacher %s: %w", teacher.Email, err)
+			}
+		}
+		teachers[teacher.Email] = &teacher
+	}
+
+	students := make(map[string]*models.Student, len(fixture.Students))
+	for _, item := range fixture.Students {
+		student := models.Student{Name: item.Name, Surname: item.Surname, Email: models.NormalizeEmail(item.Email)}
+		if item.Group != "" {
+			student.GroupID = &groups[item.Group].ID
+		}
+		if err := db.Where("email = ?", student.Email).Attrs(student).FirstOrCreate(&student).Error; err != nil {
+			return fmt.Errorf("failed to seed student %s: %w", student.Email, err)
+		}
+		students[student.Email] = &student
+	}
+
+	for _, item := range fixture.Users {
+		var studentID, teacherID *uint
+		student := students[models.NormalizeEmail(item.Student)]
+		if student != nil {
+			studentID = &student.ID
+		}
+		teacher := teachers[models.NormalizeEmail(item.Teacher)]
+		if teacher != nil {
+			teacherID = &teacher.ID
+		}
+
+		user, err := seedUser(db, item.Email, item.Password, item.Role, studentID, teacherID)
+		if err != nil {
+			return err
+		}
+
+		if student != nil && student.UserID == nil {
+			if err := db.Model(student).Update("user_id", user.ID).Error; err != nil {
+				return fmt.Errorf("failed to link seeded student: %w", err)
+			}
+		}
+		if teacher != nil && teacher.UserID == nil {
+			if err := db.Model(teacher).Update("user_id", user.ID).Error; err != nil {
+				return fmt.Errorf("failed to link seeded teacher: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// seedUser создает пользователя с указанным email, если его еще нет
+func seedUser(db *gorm.DB, email, password string, role models.Role, studentID, teacherID *uint) (*models.User, error) {
+	// Пароль хэшируется в User.BeforeSave
+	email = models.NormalizeEmail(email)
+	user := models.User{
+		Email:         email,
+		Password:      password,
+		Role:          role,
+		StudentID:     studentID,
+		TeacherID:     teacherID,
+		EmailVerified: true,
+	}
+	if err := db.Where("email = ?", email).Attrs(user).FirstOrCreate(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to seed user %s: %w", email, err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/models"
+	"testing"
+)
+
+func seedConfig() *config.Config {
+	return &config.Config{
+		SeedAdminEmail:    "admin@example.com",
+		SeedAdminPassword: config.DefaultSeedAdminPassword,
+	}
+}
+
+func TestSeedCreatesConfiguredAdmin(t *testing.T) {
+	db := migratedTestDB(t)
+	cfg := seedConfig()
+	cfg.SeedAdminEmail = "Root@School.test"
+	cfg.SeedAdminPassword = "s3cret-pass"
+
+	if err := Seed(db, cfg); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	var admin models.User
+	if err := db.Where("email = ?", "root@school.test").First(&admin).Error; err != nil {
+		t.Fatalf("admin not found: %v", err)
+	}
+	if admin.Role != models.RoleAdmin {
+		t.Errorf("role = %s, want admin", admin.Role)
+	}
+	if !auth.CheckPassword("s3cret-pass", admin.Password) {
+		t.Error("admin password does not match SEED_ADMIN_PASSWORD")
+	}
+}
+
+func TestSeedRefusesDefaultPasswordInProduction(t *testing.T) {
+	db := migratedTestDB(t)
+	cfg := seedConfig()
+	cfg.Production = true
+
+	if err := Seed(db, cfg); err == nil {
+		t.Fatal("seed with the default admin password in production succeeded")
+	}
+	if n := countRows(t, db, &models.User{}); n != 0 {
+		t.Errorf("users = %d after refused seed, want 0", n)
+	}
+
+	cfg.SeedAdminPassword = "s3cret-pass"
+	if err := Seed(db, cfg); err != nil {
+		t.Fatalf("seed with an overridden password: %v", err)
+	}
+}
+
+func TestSeedTwiceCreatesNoDuplicates(t *testing.T) {
+	db := migratedTestDB(t)
+	cfg := seedConfig()
+	tables := []interface{}{&models.User{}, &models.Group{}, &models.Teacher{}, &models.Student{}}
+
+	if err := Seed(db, cfg); err != nil {
+		t.Fatalf("first seed: %v", err)
+	}
+	first := make([]int64, len(tables))
+	for i, model := range tables {
+		first[i] = countRows(t, db, model)
+		if first[i] == 0 {
+			t.Fatalf("first seed created no %T rows", model)
+		}
+	}
+
+	if err := Seed(db, cfg); err != nil {
+		t.Fatalf("second seed: %v", err)
+	}
+	for i, model := range tables {
+		if n := countRows(t, db, model); n != first[i] {
+			t.Errorf("%T rows = %d after second seed, want %d", model, n, first[i])
+		}
+	}
+}
+
+func TestSeedRestoresMissingGroups(t *testing.T) {
+	db := migratedTestDB(t)
+	cfg := seedConfig()
+	if err := Seed(db, cfg); err != nil {
+		t.Fatalf("first seed: %v", err)
+	}
+	groups := countRows(t, db, &models.Group{})
+
+	var group models.Group
+	db.First(&group)
+	db.Model(&models.Student{}).Where("group_id = ?", group.ID).Update("group_id", nil)
+	db.Exec("DELETE FROM teacher_groups WHERE group_id = ?", group.ID)
+	if err := db.Unscoped().Delete(&group).Error; err != nil {
+		t.Fatalf("delete group: %v", err)
+	}
+
+	if err := Seed(db, cfg); err != nil {
+		t.Fatalf("second seed: %v", err)
+	}
+	if n := countRows(t, db, &models.Group{}); n != groups {
+		t.Errorf("groups = %d after reseed, want %d", n, groups)
+	}
+}
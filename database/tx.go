@@ -0,0 +1,9 @@
+package database
+
+import "gorm.io/gorm"
+
+// WithTx выполняет fn в транзакции. Первая ошибка из fn откатывает транзакцию
+// и возвращается без изменений, иначе транзакция фиксируется
+func WithTx(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return db.Transaction(fn)
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"errors"
+	"student-backend/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	db := migratedTestDB(t)
+	errStop := errors.New("stop")
+
+	err := WithTx(db, func(tx *gorm.DB) error {
+		if err := tx.Create(&models.Group{Name: "Group A", Code: "A-1", Year: 2024, Semester: 1}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.Teacher{Name: "Ivan", Surname: "Petrov", Email: "ivan@example.com"}).Error; err != nil {
+			return err
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("err = %v, want the error returned by fn", err)
+	}
+
+	if n := countRows(t, db, &models.Group{}); n != 0 {
+		t.Errorf("groups = %d after rollback, want 0", n)
+	}
+	if n := countRows(t, db, &models.Teacher{}); n != 0 {
+		t.Errorf("teachers = %d after rollback, want 0", n)
+	}
+}
+
+func TestWithTxCommits(t *testing.T) {
+	db := migratedTestDB(t)
+
+	err := WithTx(db, func(tx *gorm.DB) error {
+		return tx.Create(&models.Group{Name: "Group A", Code: "A-1", Year: 2024, Semester: 1}).Error
+	})
+	if err != nil {
+		t.Fatalf("WithTx: %v", err)
+	}
+	if n := countRows(t, db, &models.Group{}); n != 1 {
+		t.Errorf("groups = %d, want 1", n)
+	}
+}
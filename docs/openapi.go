@@ -0,0 +1,452 @@
+package docs
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"student-backend/models"
+	"sync"
+	"time"
+)
+
+// Схемы строятся из Go-структур моделей, поэтому спецификация не расходится с JSON-ответами
+var componentTypes = map[string]reflect.Type{
+	"Student":         reflect.TypeOf(models.Student{}),
+	"Teacher":         reflect.TypeOf(models.Teacher{}),
+	"Group":           reflect.TypeOf(models.Group{}),
+	"User":            reflect.TypeOf(models.User{}),
+	"AuditLog":        reflect.TypeOf(models.AuditLog{}),
+	"APIKey":          reflect.TypeOf(models.APIKey{}),
+	"GroupHistory":    reflect.TypeOf(models.StudentGroupHistory{}),
+	"Meta":            reflect.TypeOf(models.Meta{}),
+	"LoginRequest":    reflect.TypeOf(models.LoginRequest{}),
+	"LoginResponse":   reflect.TypeOf(models.LoginResponse{}),
+	"RegisterRequest": reflect.TypeOf(models.RegisterRequest{}),
+}
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+)
+
+// Spec возвращает документ OpenAPI 3 в виде JSON
+func Spec() []byte {
+	specOnce.Do(func() {
+		var err error
+		specJSON, err = json.MarshalIndent(buildSpec(), "", "  ")
+		if err != nil {
+			log.Printf("❌ Error building OpenAPI spec: %v", err)
+			specJSON = []byte(`{}`)
+		}
+	})
+	return specJSON
+}
+
+// SpecHandler отдает спецификацию по /openapi.json
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(Spec())
+}
+
+// UIHandler отдает страницу Swagger UI по /docs
+func UIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+    <title>Student Backend API - Docs</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        window.onload = function () {
+            SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+        };
+    </script>
+</body>
+</html>`
+
+func buildSpec() map[string]interface{} {
+	schemas := map[string]interface{}{
+		"Error": object(map[string]interface{}{"error": str()}),
+		"PaginatedResponse": object(map[string]interface{}{
+			"meta":  ref("Meta"),
+			"items": map[string]interface{}{"type": "array", "items": map[string]interface{}{}},
+		}),
+	}
+	for name, t := range componentTypes {
+		schemas[name] = structSchema(t)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Student Backend API",
+			"version": "1.0.0",
+		},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				"apiKeyAuth": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "X-API-Key",
+				},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKeyAuth": []string{}},
+		},
+		"paths": buildPaths(),
+	}
+}
+
+func buildPaths() map[string]interface{} {
+	idParam := map[string]interface{}{
+		"name": "id", "in": "path", "required": true,
+		"schema": map[string]interface{}{"type": "integer"},
+	}
+	listParams := []interface{}{
+		queryParam("page", "integer"),
+		queryParam("limit", "integer"),
+		queryParam("sortBy", "string"),
+		queryParam("q", "string"),
+	}
+
+	return map[string]interface{}{
+		"/api/auth/login": map[string]interface{}{
+			"post": public(operation("Login", ref("LoginRequest"), ref("LoginResponse"), nil)),
+		},
+		"/api/auth/register": map[string]interface{}{
+			"post": public(operation("Register", ref("RegisterRequest"), ref("LoginResponse"), nil)),
+		},
+		"/api/auth/verify": map[string]interface{}{
+			"get": public(operation("Confirm email by token from the verification email", nil, nil, []interface{}{queryParam("token", "string")})),
+		},
+		"/api/auth/forgot-password": map[string]interface{}{
+			"post": public(operation("Request a password reset link (always 200)",
+				object(map[string]interface{}{
+					"email": map[string]interface{}{"type": "string"},
+				}), nil, nil)),
+		},
+		"/api/auth/reset-password": map[string]interface{}{
+			"post": public(operation("Set a new password using the reset token",
+				object(map[string]interface{}{
+					"token":        map[string]interface{}{"type": "string"},
+					"new_password": map[string]interface{}{"type": "string"},
+				}), nil, nil)),
+		},
+		"/api/auth/2fa/setup": map[string]interface{}{
+			"post": operation("Generate a TOTP secret, otpauth URI and QR code", nil,
+				object(map[string]interface{}{
+					"secret":      map[string]interface{}{"type": "string"},
+					"otpauth_uri": map[string]interface{}{"type": "string"},
+					"qr_code":     map[string]interface{}{"type": "string"},
+				}), nil),
+		},
+		"/api/auth/2fa/enable": map[string]interface{}{
+			"post": operation("Enable two-factor authentication by verifying the first code",
+				object(map[string]interface{}{
+					"code": map[string]interface{}{"type": "string"},
+				}), nil, nil),
+		},
+		"/api/auth/me": map[string]interface{}{
+			"get": operation("Current user", nil, ref("User"), nil),
+			"patch": operation("Change own email, requires re-verification and returns a new token",
+				object(map[string]interface{}{"email": str()}), ref("LoginResponse"), nil),
+		},
+		"/api/students": map[string]interface{}{
+			"get": operation("List students", nil, ref("PaginatedResponse"),
+				append(append([]interface{}{}, listParams...), queryParam("group_id", "integer"), queryParam("group_code", "string"), queryParam("after", "integer"),
+					queryParam("ungrouped", "boolean"))),
+			"post": operation("Create student (admin)", ref("Student"), ref("Student"), nil),
+		},
+		"/api/students/bulk": map[string]interface{}{
+			"post": operation("Bulk create students, returns created IDs and per-item errors (admin)",
+				object(map[string]interface{}{
+					"items": map[string]interface{}{"type": "array", "items": ref("Student")},
+				}),
+				object(map[string]interface{}{
+					"created": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+					"errors":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+				}), nil),
+		},
+		"/api/students/{id}": map[string]interface{}{
+			"put":    operation("Replace student", ref("Student"), ref("Student"), []interface{}{idParam}),
+			"patch":  operation("Partially update student", ref("Student"), ref("Student"), []interface{}{idParam}),
+			"delete": operation("Delete student (admin)", nil, nil, []interface{}{idParam, queryParam("delete_user", "boolean")}),
+		},
+		"/api/students/{id}/group-history": map[string]interface{}{
+			"get": operation("Student group change history", nil, map[string]interface{}{"type": "array", "items": ref("GroupHistory")}, []interface{}{idParam}),
+		},
+		"/api/teachers": map[string]interface{}{
+			"get":    operation("List teachers (admin)", nil, ref("PaginatedResponse"), listParams),
+			"post":   operation("Create teacher (admin)", ref("Teacher"), ref("Teacher"), nil),
+			"delete": operation("Batch delete teachers (admin)", nil, nil, []interface{}{queryParam("force", "boolean")}),
+		},
+		"/api/teachers/export": map[string]interface{}{
+			"get": operation("Export teachers to CSV (admin)", nil, nil, []interface{}{queryParam("format", "string"), queryParam("bom", "boolean")}),
+		},
+		"/api/teachers/{id}": map[string]interface{}{
+			"put":    operation("Replace teacher (admin)", ref("Teacher"), ref("Teacher"), []interface{}{idParam}),
+			"patch":  operation("Partially update teacher (admin)", ref("Teacher"), ref("Teacher"), []interface{}{idParam}),
+			"delete": operation("Delete teacher (admin)", nil, nil, []interface{}{idParam, queryParam("delete_user", "boolean")}),
+		},
+		"/api/teachers/{id}/restore": map[string]interface{}{
+			"post": operation("Restore deleted teacher (admin)", nil, ref("Teacher"), []interface{}{idParam}),
+		},
+		"/api/groups": map[string]interface{}{
+			"get": operation("List groups with student_count and curator (teachers default to mine=true, students see own group)", nil, ref("PaginatedResponse"),
+				append(append([]interface{}{}, listParams...), queryParam("min_students", "integer"), queryParam("max_students", "integer"),
+					queryParam("curator_id", "integer"), queryParam("mine", "boolean"),
+					queryParam("year", "integer"), queryParam("semester", "integer"), queryParam("archived", "string"))),
+			"post": operation("Create group (admin)", ref("Group"), ref("Group"), nil),
+		},
+		"/api/groups/all": map[string]interface{}{
+			"get": operation("All groups", nil, map[string]interface{}{"type": "array", "items": ref("Group")}, nil),
+		},
+		"/api/groups/stats": map[string]interface{}{
+			"get": operation("Student count per group, including empty groups (admin)", nil,
+				map[string]interface{}{"type": "array", "items": object(map[string]interface{}{
+					"id":            map[string]interface{}{"type": "integer"},
+					"name":          map[string]interface{}{"type": "string"},
+					"code":          map[string]interface{}{"type": "string"},
+					"student_count": map[string]interface{}{"type": "integer"},
+				})},
+				[]interface{}{queryParam("sortBy", "string"), queryParam("archived", "string")}),
+		},
+		"/api/groups/{id}": map[string]interface{}{
+			"get":    operation("Get group with students", nil, ref("Group"), []interface{}{idParam}),
+			"put":    operation("Replace group (admin)", ref("Group"), ref("Group"), []interface{}{idParam}),
+			"patch":  operation("Partially update group (admin)", ref("Group"), ref("Group"), []interface{}{idParam}),
+			"delete": operation("Delete group (admin)", nil, nil, []interface{}{idParam}),
+		},
+		"/api/groups/{id}/students": map[string]interface{}{
+			"get": operation("List group students", nil, ref("PaginatedResponse"), append([]interface{}{idParam}, listParams...)),
+			"post": operation("Move listed students or a whole group into this group (admin)",
+				object(map[string]interface{}{
+					"from_group_id": map[string]interface{}{"type": "integer"},
+					"student_ids":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+				}),
+				nil, []interface{}{idParam}),
+		},
+		"/api/groups/{id}/transfer": map[string]interface{}{
+			"post": operation("Move all students of this group to another group (admin)",
+				object(map[string]interface{}{
+					"target_group_id": map[string]interface{}{"type": "integer"},
+				}),
+				nil, []interface{}{idParam}),
+		},
+		"/api/groups/{id}/archive": map[string]interface{}{
+			"post": operation("Archive group (admin)", nil, ref("Group"), []interface{}{idParam, queryParam("confirm", "boolean")}),
+		},
+		"/api/groups/{id}/unarchive": map[string]interface{}{
+			"post": operation("Unarchive group (admin)", nil, ref("Group"), []interface{}{idParam}),
+		},
+		"/api/users/{id}/link": map[string]interface{}{
+			"patch": operation("Relink account to a student or teacher record (admin)",
+				object(map[string]interface{}{
+					"student_id": map[string]interface{}{"type": "integer"},
+					"teacher_id": map[string]interface{}{"type": "integer"},
+				}),
+				ref("User"), []interface{}{idParam}),
+		},
+		"/api/api-keys": map[string]interface{}{
+			"get": operation("List API keys (admin)", nil, map[string]interface{}{"type": "array", "items": ref("APIKey")},
+				[]interface{}{queryParam("user_id", "integer")}),
+			"post": operation("Create API key, the plaintext key is returned only once (admin)",
+				object(map[string]interface{}{
+					"name":    map[string]interface{}{"type": "string"},
+					"user_id": map[string]interface{}{"type": "integer"},
+				}), ref("APIKey"), nil),
+		},
+		"/api/api-keys/{id}": map[string]interface{}{
+			"delete": operation("Revoke API key (admin)", nil, nil, []interface{}{idParam}),
+		},
+		"/api/admin/maintenance": map[string]interface{}{
+			"get": operation("Maintenance mode state (admin)", nil, maintenanceState(), nil),
+			"post": operation("Enable or disable maintenance mode with an optional message and ETA, persisted across restarts (admin)",
+				maintenanceState(), maintenanceState(), nil),
+		},
+		"/api/admin/flags": map[string]interface{}{
+			"get":   operation("Feature flags (admin)", nil, featureFlags(), nil),
+			"patch": operation("Update feature flags, values are persisted (admin)", featureFlags(), featureFlags(), nil),
+		},
+		"/api/audit": map[string]interface{}{
+			"get": operation("Audit log (admin)", nil, ref("PaginatedResponse"), []interface{}{
+				queryParam("page", "integer"), queryParam("limit", "integer"),
+				queryParam("entity", "string"), queryParam("action", "string"), queryParam("user_id", "integer"),
+			}),
+		},
+		"/api/audit/entries": map[string]interface{}{
+			"get": operation("Trail of successful POST/PUT/PATCH/DELETE requests (admin)", nil, ref("PaginatedResponse"), []interface{}{
+				queryParam("page", "integer"), queryParam("limit", "integer"), queryParam("sortBy", "string"),
+				queryParam("entity", "string"), queryParam("entity_id", "string"), queryParam("actor", "string"),
+				queryParam("from", "string"), queryParam("to", "string"),
+			}),
+		},
+		"/api/events": map[string]interface{}{
+			"get": operation("Audit trail entries as a text/event-stream of audit events (admin)", nil, nil, nil),
+		},
+	}
+}
+
+// maintenanceState - состояние режима обслуживания с сообщением и временем окончания
+func maintenanceState() map[string]interface{} {
+	return object(map[string]interface{}{
+		"enabled": map[string]interface{}{"type": "boolean"},
+		"message": map[string]interface{}{"type": "string"},
+		"eta":     map[string]interface{}{"type": "string", "format": "date-time"},
+	})
+}
+
+// featureFlags - объект флагов функциональности: имя флага -> значение
+func featureFlags() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "boolean"},
+		"example":              map[string]interface{}{"registration_open": true, "maintenance_mode": false},
+	}
+}
+
+func operation(summary string, requestSchema, responseSchema interface{}, params []interface{}) map[string]interface{} {
+	op := map[string]interface{}{
+		"summary": summary,
+		"responses": map[string]interface{}{
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     jsonContent(ref("Error")),
+			},
+		},
+	}
+
+	success := map[string]interface{}{"description": "Success"}
+	if responseSchema != nil {
+		success["content"] = jsonContent(responseSchema)
+	}
+	op["responses"].(map[string]interface{})["200"] = success
+
+	if requestSchema != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(requestSchema),
+		}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	return op
+}
+
+// public отключает требование авторизации для операции
+func public(op map[string]interface{}) map[string]interface{} {
+	op["security"] = []interface{}{}
+	return op
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+func queryParam(name, typ string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "query", "schema": map[string]interface{}{"type": typ}}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func str() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func object(properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// structSchema строит схему объекта по json-тегам полей структуры
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	collectProperties(t, properties)
+	return object(properties)
+}
+
+func collectProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			if field.Type.Kind() == reflect.Struct {
+				collectProperties(field.Type, properties)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type)
+	}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	timestampType = reflect.TypeOf(models.Timestamp{})
+)
+
+// typeSchema возвращает схему для типа поля; известные модели подставляются ссылкой
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType || t == timestampType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	for name, component := range componentTypes {
+		if component == t {
+			return ref(name)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return str()
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	return map[string]interface{}{}
+}
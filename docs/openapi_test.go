@@ -0,0 +1,74 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSpecHandlerServesOpenAPIDocument(t *testing.T) {
+	w := httptest.NewRecorder()
+	SpecHandler(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var spec struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("/openapi.json is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+
+	expected := map[string][]string{
+		"/api/auth/login":           {"post"},
+		"/api/auth/register":        {"post"},
+		"/api/students":             {"get", "post"},
+		"/api/students/{id}":        {"put", "patch", "delete"},
+		"/api/teachers":             {"get", "post"},
+		"/api/teachers/{id}":        {"put", "patch", "delete"},
+		"/api/groups":               {"get", "post"},
+		"/api/groups/{id}":          {"get", "put", "patch", "delete"},
+		"/api/groups/{id}/students": {"get", "post"},
+		"/api/groups/{id}/transfer": {"post"},
+	}
+	for path, methods := range expected {
+		operations, ok := spec.Paths[path]
+		if !ok {
+			t.Errorf("path %s is missing", path)
+			continue
+		}
+		for _, method := range methods {
+			if _, ok := operations[method]; !ok {
+				t.Errorf("%s %s is missing", strings.ToUpper(method), path)
+			}
+		}
+	}
+
+	// Все ссылки на схемы должны вести на описанные компоненты
+	for _, name := range refNames(w.Body.String()) {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is referenced but not defined", name)
+		}
+	}
+}
+
+// refNames возвращает имена схем из всех "$ref" документа
+func refNames(document string) []string {
+	const prefix = `"$ref": "#/components/schemas/`
+	var names []string
+	for _, part := range strings.Split(document, prefix)[1:] {
+		names = append(names, part[:strings.Index(part, `"`)])
+	}
+	return names
+}
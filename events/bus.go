@@ -0,0 +1,122 @@
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// Типы доменных событий
+const (
+	StudentCreated  = "student.created"
+	StudentUpdated  = "student.updated"
+	StudentDeleted  = "student.deleted"
+	TeacherCreated  = "teacher.created"
+	TeacherUpdated  = "teacher.updated"
+	TeacherDeleted  = "teacher.deleted"
+	TeacherRestored = "teacher.restored"
+)
+
+// AnyType - подписка на события всех типов
+const AnyType = "*"
+
+// subscriberBuffer - очередь подписчика; при переполнении события для него отбрасываются
+const subscriberBuffer = 256
+
+// Event - доменное событие, публикуемое обработчиками после фиксации изменений
+type Event struct {
+	Type     string
+	EntityID uint
+	// ActorID - пользователь, выполнивший действие, 0 если неизвестен
+	ActorID uint
+	// Detail - краткое описание для журнала аудита
+	Detail string
+	// Data - состояние сущности после изменения, nil для удаления
+	Data interface{}
+}
+
+// Handler обрабатывает событие в горутине своего подписчика
+type Handler func(Event)
+
+// subscriber - подписчик со своей очередью: медленный подписчик не задерживает остальных
+type subscriber struct {
+	eventType string
+	handler   Handler
+	queue     chan Event
+}
+
+// Bus - внутренняя шина событий. Публикация не блокирует обработчик запроса,
+// подписчики выполняются в отдельных горутинах
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []*subscriber
+	closed      bool
+	wg          sync.WaitGroup
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe регистрирует обработчик событий типа eventType (AnyType - всех типов).
+// Подписчики регистрируются при запуске, до публикации событий
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	sub := &subscriber{eventType: eventType, handler: handler, queue: make(chan Event, subscriberBuffer)}
+	b.subscribers = append(b.subscribers, sub)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for event := range sub.queue {
+			sub.handle(event)
+		}
+	}()
+}
+
+// Publish ставит событие в очереди подписчиков этого типа и сразу возвращается
+func (b *Bus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	for _, sub := range b.subscribers {
+		if sub.eventType != AnyType && sub.eventType != event.Type {
+			continue
+		}
+		select {
+		case sub.queue <- event:
+		default:
+			log.Printf("❌ Subscriber queue for %s is full, dropping %s event for %d", sub.eventType, event.Type, event.EntityID)
+		}
+	}
+}
+
+// Close прекращает прием событий и дожидается, пока подписчики разберут очереди
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for _, sub := range b.subscribers {
+			close(sub.queue)
+		}
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+// handle вызывает обработчик, не давая его панике остановить подписчика
+func (s *subscriber) handle(event Event) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("❌ Subscriber for %s panicked on %s event: %v", s.eventType, event.Type, p)
+		}
+	}()
+	s.handler(event)
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"sync"
+	"testing"
+)
+
+// recorder собирает события, полученные подписчиком
+type recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *recorder) handle(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+func TestBusDeliversByType(t *testing.T) {
+	bus := NewBus()
+	var created, all recorder
+	bus.Subscribe(StudentCreated, created.handle)
+	bus.Subscribe(AnyType, all.handle)
+
+	bus.Publish(Event{Type: StudentCreated, EntityID: 7, ActorID: 1, Data: "Anna"})
+	bus.Publish(Event{Type: TeacherDeleted, EntityID: 3})
+	bus.Close()
+
+	if got := created.types(); len(got) != 1 || got[0] != StudentCreated {
+		t.Fatalf("student.created subscriber got %v", got)
+	}
+	if event := created.events[0]; event.EntityID != 7 || event.ActorID != 1 || event.Data != "Anna" {
+		t.Fatalf("delivered event = %+v", event)
+	}
+	if got := all.types(); len(got) != 2 || got[0] != StudentCreated || got[1] != TeacherDeleted {
+		t.Fatalf("AnyType subscriber got %v, want both events in order", got)
+	}
+}
+
+func TestBusSurvivesPanickingSubscriber(t *testing.T) {
+	bus := NewBus()
+	var after recorder
+	bus.Subscribe(StudentCreated, func(Event) { panic("boom") })
+	bus.Subscribe(StudentCreated, after.handle)
+
+	bus.Publish(Event{Type: StudentCreated, EntityID: 1})
+	bus.Publish(Event{Type: StudentCreated, EntityID: 2})
+	bus.Close()
+
+	if got := after.types(); len(got) != 2 {
+		t.Fatalf("other subscriber got %d events, want 2", len(got))
+	}
+}
+
+func TestBusIgnoresEventsAfterClose(t *testing.T) {
+	bus := NewBus()
+	var received recorder
+	bus.Subscribe(AnyType, received.handle)
+	bus.Close()
+
+	bus.Publish(Event{Type: StudentCreated, EntityID: 1})
+	bus.Subscribe(StudentCreated, received.handle)
+	bus.Close()
+
+	if got := received.types(); len(got) != 0 {
+		t.Fatalf("closed bus delivered %v", got)
+	}
+}
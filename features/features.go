@@ -0,0 +1,142 @@
+package features
+
+import (
+	"errors"
+	"fmt"
+	"student-backend/models"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Известные флаги функциональности
+const (
+	RegistrationOpen = "registration_open"
+	MaintenanceMode  = "maintenance_mode"
+)
+
+// ErrUnknownFlag возвращается при попытке изменить незарегистрированный флаг
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// binding связывает флаг с состоянием, которым владеет другой компонент
+// (например, режим обслуживания, который переключается еще и по SIGHUP)
+type binding struct {
+	get func() bool
+	set func(bool)
+}
+
+// Flags - флаги функциональности в памяти с сохранением в таблицу feature_flags.
+// Изменения применяются сразу и переживают перезапуск
+type Flags struct {
+	mu       sync.RWMutex
+	db       *gorm.DB
+	values   map[string]bool
+	bindings map[string]binding
+}
+
+// New создает хранилище с флагами по умолчанию
+func New(db *gorm.DB) *Flags {
+	return &Flags{
+		db:       db,
+		values:   map[string]bool{RegistrationOpen: true},
+		bindings: make(map[string]binding),
+	}
+}
+
+// Bind регистрирует флаг, значение которого хранится во внешнем компоненте
+func (f *Flags) Bind(name string, get func() bool, set func(bool)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.values, name)
+	f.bindings[name] = binding{get: get, set: set}
+}
+
+// Load применяет сохраненные значения известных флагов
+func (f *Flags) Load() error {
+	var stored []models.FeatureFlag
+	if err := f.db.Find(&stored).Error; err != nil {
+		return fmt.Errorf("failed to load feature flags: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, flag := range stored {
+		f.apply(flag.Name, flag.Enabled)
+	}
+	return nil
+}
+
+// Enabled возвращает значение флага; неизвестный флаг считается выключенным
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if b, ok := f.bindings[name]; ok {
+		return b.get()
+	}
+	return f.values[name]
+}
+
+// All возвращает значения всех известных флагов
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	all := make(map[string]bool, len(f.values)+len(f.bindings))
+	for name, enabled := range f.values {
+		all[name] = enabled
+	}
+	for name, b := range f.bindings {
+		all[name] = b.get()
+	}
+	return all
+}
+
+// Set сохраняет значения флагов в базе и применяет их. Неизвестные флаги
+// отклоняются целиком, до записи в базу
+func (f *Flags) Set(db *gorm.DB, updates map[string]bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rows := make([]models.FeatureFlag, 0, len(updates))
+	for name, enabled := range updates {
+		if !f.known(name) {
+			return fmt.Errorf("%w: %s", ErrUnknownFlag, name)
+		}
+		rows = append(rows, models.FeatureFlag{Name: name, Enabled: enabled})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for name, enabled := range updates {
+		f.apply(name, enabled)
+	}
+	return nil
+}
+
+func (f *Flags) known(name string) bool {
+	if _, ok := f.bindings[name]; ok {
+		return true
+	}
+	_, ok := f.values[name]
+	return ok
+}
+
+// apply меняет значение известного флага; вызывается под блокировкой
+func (f *Flags) apply(name string, enabled bool) {
+	if b, ok := f.bindings[name]; ok {
+		b.set(enabled)
+		return
+	}
+	if _, ok := f.values[name]; ok {
+		f.values[name] = enabled
+	}
+}
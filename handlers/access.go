@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/auth"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// authorize проверяет право роли текущего пользователя на действие над ресурсом
+// и отвечает 403, если права нет
+func authorize(w http.ResponseWriter, r *http.Request, action, resource string) bool {
+	claims := middleware.GetUserClaims(r.Context())
+	if claims != nil && models.Can(claims.Role, action, resource) {
+		return true
+	}
+
+	if claims != nil {
+		logf(r, "User %s (role: %s) is not allowed to %s %s", claims.Email, claims.Role, action, resource)
+	}
+	httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Insufficient permissions")
+	return false
+}
+
+// callerTeacherID возвращает ID записи преподавателя, связанной с текущим пользователем
+func callerTeacherID(db *gorm.DB, claims *auth.JWTClaims) (uint, bool) {
+	var user models.User
+	if err := db.Select("id", "teacher_id").First(&user, claims.UserID).Error; err != nil || user.TeacherID == nil {
+		return 0, false
+	}
+	return *user.TeacherID, true
+}
+
+// callerStudent возвращает запись студента, связанную с текущим пользователем
+func callerStudent(db *gorm.DB, claims *auth.JWTClaims) (*models.Student, bool) {
+	var student models.Student
+	if err := db.Where("user_id = ?", claims.UserID).First(&student).Error; err != nil {
+		return nil, false
+	}
+	return &student, true
+}
+
+// canViewGroup проверяет доступ к группе: админ видит все группы,
+// преподаватель - тоже все группы (для выбора группы при работе со студентами),
+// студент - только свою группу
+func canViewGroup(db *gorm.DB, claims *auth.JWTClaims, groupID uint) bool {
+	switch claims.Role {
+	case models.RoleAdmin, models.RoleTeacher:
+		return true
+	case models.RoleStudent:
+		student, ok := callerStudent(db, claims)
+		return ok && student.GroupID != nil && *student.GroupID == groupID
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type APIKeyHandler struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewAPIKeyHandler(db *gorm.DB, cfg *config.Config) *APIKeyHandler {
+	return &APIKeyHandler{db: db, cfg: cfg}
+}
+
+// CreateAPIKeyRequest - запрос на создание ключа; без user_id ключ выдается текущему пользователю
+type CreateAPIKeyRequest struct {
+	Name   string `json:"name" validate:"required,max=100"`
+	UserID *uint  `json:"user_id"`
+}
+
+// CreatedAPIKey - ответ на создание ключа, единственный раз содержит ключ целиком
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// GetAPIKeys возвращает список ключей без их значений, ?user_id= фильтрует по владельцу
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceAPIKeys) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	query := db.Model(&models.APIKey{})
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid user_id")
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var keys []models.APIKey
+	if err := query.Order("id ASC").Find(&keys).Error; err != nil {
+		logf(r, "Error fetching API keys: %v", err)
+		respondDBError(w, err, "Failed to fetch API keys")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey создает ключ API. Ключ возвращается только в этом ответе, в базе хранится его хэш
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionCreate, models.ResourceAPIKeys) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var req CreateAPIKeyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Name is required")
+		return
+	}
+
+	userID := claims.UserID
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+
+	var owner models.User
+	if err := db.Select("id").First(&owner, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+			return
+		}
+		logf(r, "Error fetching API key owner: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	plainKey, err := auth.GenerateAPIKey()
+	if err != nil {
+		logf(r, "Error generating API key: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	apiKey := models.APIKey{
+		Key:    auth.HashToken(plainKey),
+		Prefix: plainKey[:len(auth.APIKeyPrefix)+4],
+		UserID: owner.ID,
+		Name:   req.Name,
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		logf(r, "Error creating API key: %v", err)
+		respondDBError(w, err, "Failed to create API key")
+		return
+	}
+
+	recordAudit(h.db, claims, models.AuditActionCreate, models.AuditEntityAPIKey, apiKey.ID,
+		fmt.Sprintf("%s for user %d", apiKey.Name, apiKey.UserID))
+
+	logf(r, "API key %d (%s) created for user %d", apiKey.ID, apiKey.Name, apiKey.UserID)
+	httputil.RespondJSON(w, http.StatusCreated, CreatedAPIKey{APIKey: apiKey, Key: plainKey})
+}
+
+// RevokeAPIKey отзывает ключ API: запись удаляется, и ключ сразу перестает приниматься
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionDelete, models.ResourceAPIKeys) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid API key ID")
+		return
+	}
+
+	result := db.Delete(&models.APIKey{}, id)
+	if result.Error != nil {
+		logf(r, "Error revoking API key %d: %v", id, result.Error)
+		respondDBError(w, result.Error, "Failed to revoke API key")
+		return
+	}
+	if result.RowsAffected == 0 {
+		httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "API key not found")
+		return
+	}
+
+	recordAudit(h.db, claims, models.AuditActionDelete, models.AuditEntityAPIKey, uint(id), "revoked")
+
+	logf(r, "API key %d revoked", id)
+	httputil.RespondJSON(w, http.StatusOK, map[string]string{"message": "API key revoked"})
+}
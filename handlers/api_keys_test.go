@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"student-backend/middleware"
+	"student-backend/models"
+	"testing"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	admin := createUser(t, env.db, "admin@example.com", models.RoleAdmin)
+	owner := createUser(t, env.db, "service@example.com", models.RoleTeacher)
+	keys := NewAPIKeyHandler(env.db, env.cfg)
+	authHandler, _ := env.newAuthHandler(t)
+	me := env.authenticated(authHandler.GetCurrentUser)
+
+	w := serve(t, keys.CreateAPIKey, request{
+		method: http.MethodPost, target: "/api/api-keys",
+		body: CreateAPIKeyRequest{Name: "export job", UserID: &owner.ID}, claims: claimsOf(admin),
+	})
+	expectStatus(t, w, http.StatusCreated)
+	var created CreatedAPIKey
+	decodeBody(t, w, &created)
+	if created.Key == "" || created.UserID != owner.ID {
+		t.Fatalf("unexpected created key: %+v", created)
+	}
+
+	// Ключ аутентифицирует владельца, а не того, кто его выпустил
+	w = serve(t, me, request{method: http.MethodGet, target: "/api/auth/me", headers: map[string]string{middleware.APIKeyHeader: created.Key}})
+	expectStatus(t, w, http.StatusOK)
+	var user models.User
+	decodeBody(t, w, &user)
+	if user.ID != owner.ID {
+		t.Fatalf("API key authenticated user %d, want %d", user.ID, owner.ID)
+	}
+
+	var stored models.APIKey
+	env.db.First(&stored, created.ID)
+	if stored.Key == created.Key {
+		t.Fatal("API key is stored in plaintext")
+	}
+	if stored.LastUsedAt == nil {
+		t.Fatal("last_used_at is not set after use")
+	}
+
+	w = serve(t, keys.RevokeAPIKey, request{
+		method: http.MethodDelete, target: "/api/api-keys/" + strconv.Itoa(int(created.ID)),
+		claims: claimsOf(admin), vars: map[string]string{"id": strconv.Itoa(int(created.ID))},
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	w = serve(t, me, request{method: http.MethodGet, target: "/api/auth/me", headers: map[string]string{middleware.APIKeyHeader: created.Key}})
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestAPIKeyRejectsUnknownKey(t *testing.T) {
+	env := newTestEnv(t)
+	authHandler, _ := env.newAuthHandler(t)
+
+	w := serve(t, env.authenticated(authHandler.GetCurrentUser), request{
+		method: http.MethodGet, target: "/api/auth/me",
+		headers: map[string]string{middleware.APIKeyHeader: "sk_unknown"},
+	})
+	expectStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestAPIKeysHideStoredValues(t *testing.T) {
+	env := newTestEnv(t)
+	admin := createUser(t, env.db, "admin@example.com", models.RoleAdmin)
+	keys := NewAPIKeyHandler(env.db, env.cfg)
+
+	w := serve(t, keys.CreateAPIKey, request{
+		method: http.MethodPost, target: "/api/api-keys",
+		body: CreateAPIKeyRequest{Name: "ci"}, claims: claimsOf(admin),
+	})
+	expectStatus(t, w, http.StatusCreated)
+
+	w = serve(t, keys.GetAPIKeys, request{method: http.MethodGet, target: "/api/api-keys", claims: claimsOf(admin)})
+	expectStatus(t, w, http.StatusOK)
+	var listed []map[string]interface{}
+	decodeBody(t, w, &listed)
+	if len(listed) != 1 {
+		t.Fatalf("listed %d keys, want 1", len(listed))
+	}
+	if _, ok := listed[0]["key"]; ok {
+		t.Fatal("key list exposes key values")
+	}
+	if listed[0]["user_id"] != float64(admin.ID) {
+		t.Fatalf("key without user_id belongs to %v, want the caller %d", listed[0]["user_id"], admin.ID)
+	}
+}
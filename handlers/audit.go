@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/httputil"
+	"student-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditEntrySortFields - допустимые поля сортировки журнала запросов
+var auditEntrySortFields = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+type AuditHandler struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewAuditHandler(db *gorm.DB, cfg *config.Config) *AuditHandler {
+	return &AuditHandler{db: db, cfg: cfg}
+}
+
+// recordAudit записывает действие в журнал аудита.
+// Ошибка записи только логируется, чтобы не блокировать основную операцию
+func recordAudit(db *gorm.DB, claims *auth.JWTClaims, action, entity string, entityID uint, detail string) {
+	entry := models.AuditLog{
+		Action:   action,
+		Entity:   entity,
+		EntityID: entityID,
+		Detail:   detail,
+	}
+	if claims != nil {
+		entry.UserID = claims.UserID
+	}
+	writeAuditLog(db, entry)
+}
+
+// writeAuditLog сохраняет готовую запись журнала аудита, ошибка только логируется
+func writeAuditLog(db *gorm.DB, entry models.AuditLog) {
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("❌ Error writing audit log (%s %s %d): %v", entry.Action, entry.Entity, entry.EntityID, err)
+	}
+}
+
+// GetAuditLogs возвращает журнал аудита с пагинацией и фильтрами (только для админа)
+func (h *AuditHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceAudit) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	page, limit, offset := parsePagination(r, h.cfg)
+
+	entityFilter := r.URL.Query().Get("entity")
+	actionFilter := r.URL.Query().Get("action")
+	userIDFilter := r.URL.Query().Get("user_id")
+
+	query := db.Model(&models.AuditLog{})
+
+	if entityFilter != "" {
+		query = query.Where("entity = ?", entityFilter)
+	}
+
+	if actionFilter != "" {
+		query = query.Where("action = ?", actionFilter)
+	}
+
+	if userIDFilter != "" {
+		userID, err := strconv.Atoi(userIDFilter)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid user_id")
+			return
+		}
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var totalItems int64
+	if err := query.Count(&totalItems).Error; err != nil {
+		logf(r, "❌ Error counting audit logs: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	var entries []models.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		logf(r, "❌ Error fetching audit logs: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	response := models.PaginatedResponse{
+		Meta:  pageMeta(totalItems, page, limit),
+		Items: entries,
+	}
+
+	setPaginationHeaders(w, r, response.Meta)
+	writeList(w, r, response, false)
+}
+
+// GetAuditEntries возвращает журнал изменяющих запросов (только для админа).
+// Фильтры: entity, entity_id, actor (ID или email пользователя),
+// from и to (RFC3339 или дата YYYY-MM-DD, to включает весь день)
+func (h *AuditHandler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceAudit) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	page, limit, _ := parsePagination(r, h.cfg)
+	query := r.URL.Query()
+
+	var filters []func(*gorm.DB) *gorm.DB
+	addFilter := func(condition string, value interface{}) {
+		filters = append(filters, func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(condition, value)
+		})
+	}
+
+	if entity := query.Get("entity"); entity != "" {
+		addFilter("entity = ?", entity)
+	}
+	if entityID := query.Get("entity_id"); entityID != "" {
+		addFilter("entity_id = ?", entityID)
+	}
+	if actor := strings.TrimSpace(query.Get("actor")); actor != "" {
+		if userID, err := strconv.ParseUint(actor, 10, 64); err == nil {
+			addFilter("user_id = ?", userID)
+		} else {
+			addFilter("LOWER(email) = ?", strings.ToLower(actor))
+		}
+	}
+	if raw := query.Get("from"); raw != "" {
+		from, _, err := parseAuditTime(raw)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid from, expected RFC3339 or YYYY-MM-DD")
+			return
+		}
+		addFilter("created_at >= ?", from)
+	}
+	if raw := query.Get("to"); raw != "" {
+		to, dateOnly, err := parseAuditTime(raw)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid to, expected RFC3339 or YYYY-MM-DD")
+			return
+		}
+		if dateOnly {
+			addFilter("created_at < ?", to.AddDate(0, 0, 1))
+		} else {
+			addFilter("created_at <= ?", to)
+		}
+	}
+
+	sortBy := query.Get("sortBy")
+	if sortBy == "" {
+		sortBy = "-created_at,-id"
+	}
+
+	result, err := Paginate(db, &models.AuditEntry{}, ListOptions{
+		Page:       page,
+		Limit:      limit,
+		SortBy:     sortBy,
+		SortFields: auditEntrySortFields,
+		Filters:    filters,
+	})
+	if errors.Is(err, errInvalidSortField) {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid sort field")
+		return
+	}
+	if err != nil {
+		logf(r, "❌ Error fetching audit entries: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	response := result.Response()
+	setPaginationHeaders(w, r, response.Meta)
+	writeList(w, r, response, false)
+}
+
+// parseAuditTime разбирает границу периода. dateOnly сообщает,
+// что передана только дата без времени
+func parseAuditTime(raw string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err = time.Parse("2006-01-02", raw)
+	return t, true, err
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/models"
+	"testing"
+)
+
+func TestCreateStudentWritesAuditLog(t *testing.T) {
+	env := newTestEnv(t)
+	SubscribeAudit(env.bus, env.db)
+	students := NewStudentHandler(env.db, env.cfg, env.bus)
+	audit := NewAuditHandler(env.db, env.cfg)
+
+	// Запись другой сущности не должна попасть в отфильтрованный ответ
+	recordAudit(env.db, adminClaims(), models.AuditActionCreate, models.AuditEntityGroup, 7, "G-1")
+
+	w := serve(t, students.CreateStudent, request{
+		method: http.MethodPost,
+		target: "/api/students",
+		body:   map[string]interface{}{"name": "Anna", "surname": "Smirnova", "email": "anna@example.com"},
+		claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusCreated)
+	var created models.Student
+	decodeBody(t, w, &created)
+
+	// Журнал пишется подписчиком шины: Close дожидается обработки очереди
+	env.bus.Close()
+
+	w = serve(t, audit.GetAuditLogs, request{
+		method: http.MethodGet,
+		target: "/api/audit?entity=student",
+		claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var page struct {
+		Meta  models.Meta       `json:"meta"`
+		Items []models.AuditLog `json:"items"`
+	}
+	decodeBody(t, w, &page)
+	if page.Meta.TotalItems != 1 || len(page.Items) != 1 {
+		t.Fatalf("got %d entries (total %d), want exactly one student entry", len(page.Items), page.Meta.TotalItems)
+	}
+	entry := page.Items[0]
+	if entry.Action != models.AuditActionCreate || entry.Entity != models.AuditEntityStudent ||
+		entry.EntityID != created.ID || entry.UserID != adminClaims().UserID {
+		t.Errorf("entry = %+v, want create of student %d by user %d", entry, created.ID, adminClaims().UserID)
+	}
+}
+
+func TestGetAuditLogsRejectsInvalidUserID(t *testing.T) {
+	env := newTestEnv(t)
+	audit := NewAuditHandler(env.db, env.cfg)
+
+	w := serve(t, audit.GetAuditLogs, request{
+		method: http.MethodGet,
+		target: "/api/audit?user_id=abc",
+		claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+}
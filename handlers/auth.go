@@ -1,12 +1,19 @@
 package handlers
 
 import (
-	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/features"
+	"student-backend/httputil"
+	"student-backend/mailer"
 	"student-backend/middleware"
 	"student-backend/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,12 +21,29 @@ import (
 type AuthHandler struct {
 	db         *gorm.DB
 	jwtService *auth.JWTService
+	cfg        *config.Config
+	mailer     mailer.Mailer
+	flags      *features.Flags
 }
 
-func NewAuthHandler(db *gorm.DB, jwtService *auth.JWTService) *AuthHandler {
+func NewAuthHandler(db *gorm.DB, jwtService *auth.JWTService, cfg *config.Config, mailer mailer.Mailer, flags *features.Flags) *AuthHandler {
 	return &AuthHandler{
 		db:         db,
 		jwtService: jwtService,
+		cfg:        cfg,
+		mailer:     mailer,
+		flags:      flags,
+	}
+}
+
+// sendVerificationEmail отправляет ссылку подтверждения email с исходным токеном,
+// в базе хранится только его хэш.
+// Ошибка отправки только логируется: письмо можно запросить повторно через администратора
+func (h *AuthHandler) sendVerificationEmail(r *http.Request, user *models.User, token string) {
+	link := fmt.Sprintf("%s/api/auth/verify?token=%s", strings.TrimRight(h.cfg.AppBaseURL, "/"), token)
+	body := fmt.Sprintf("Для подтверждения email перейдите по ссылке:\n%s", link)
+	if err := h.mailer.Send(user.Email, "Подтверждение email", body); err != nil {
+		logf(r, "❌ Error sending verification email to %s: %v", maskEmail(user.Email), err)
 	}
 }
 
@@ -27,34 +51,57 @@ func NewAuthHandler(db *gorm.DB, jwtService *auth.JWTService) *AuthHandler {
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	var loginReq models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&loginReq); err != nil {
-		log.Printf(" Error decoding login request: %v", err)
-		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &loginReq) {
 		return
 	}
+	loginReq.Email = models.NormalizeEmail(loginReq.Email)
 
 	// Ищем пользователя
 	var user models.User
-	result := h.db.Where("email = ?", loginReq.Email).First(&user)
+	result := db.Where("email = ?", loginReq.Email).First(&user)
 	if result.Error != nil {
-		log.Printf("User not found: %s", loginReq.Email)
-		http.Error(w, `{"error": "Invalid email or password"}`, http.StatusUnauthorized)
+		logf(r, "User not found: %s", maskEmail(loginReq.Email))
+		httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid email or password")
 		return
 	}
 
 	// Проверяем пароль
 	if !auth.CheckPassword(loginReq.Password, user.Password) {
-		log.Printf("Invalid password for user: %s", loginReq.Email)
-		http.Error(w, `{"error": "Invalid email or password"}`, http.StatusUnauthorized)
+		logf(r, "Invalid password for user: %s", maskEmail(loginReq.Email))
+		httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid email or password")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		if loginReq.Code == "" {
+			logf(r, "Two-factor code required for user: %s", maskEmail(loginReq.Email))
+			httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Two-factor code required",
+				map[string]interface{}{"two_factor_required": true})
+			return
+		}
+		if !auth.ValidateTOTP(loginReq.Code, user.TwoFactorSecret) {
+			logf(r, "Invalid two-factor code for user: %s", maskEmail(loginReq.Email))
+			httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid two-factor code",
+				map[string]interface{}{"two_factor_required": true})
+			return
+		}
+	}
+
+	if h.cfg.RequireEmailVerification && !user.EmailVerified {
+		logf(r, "Login blocked for unverified user: %s", maskEmail(loginReq.Email))
+		httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Email is not verified")
 		return
 	}
 
 	// Генерируем токен
 	token, err := h.jwtService.GenerateToken(&user)
 	if err != nil {
-		log.Printf("Error generating token for user %s: %v", user.Email, err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, "Error generating token for user %s: %v", maskEmail(user.Email), err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
 		return
 	}
 
@@ -66,131 +113,495 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		User:  user,
 	}
 
-	log.Printf("User logged in successfully: %s (role: %s)", user.Email, user.Role)
-	json.NewEncoder(w).Encode(response)
+	logf(r, "User logged in successfully: %s (role: %s)", maskEmail(user.Email), user.Role)
+	httputil.RespondJSON(w, http.StatusOK, response)
 }
 
 // Register регистрирует нового пользователя
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if !h.flags.Enabled(features.RegistrationOpen) {
+		httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Registration is closed")
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	var registerReq models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&registerReq); err != nil {
-		log.Printf("Error decoding register request: %v", err)
-		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &registerReq) {
 		return
 	}
+	registerReq.Email = models.NormalizeEmail(registerReq.Email)
 
-	// Проверяем, существует ли пользователь
-	var existingUser models.User
-	if err := h.db.Where("email = ?", registerReq.Email).First(&existingUser).Error; err == nil {
-		log.Printf("User already exists: %s", registerReq.Email)
-		http.Error(w, `{"error": "User with this email already exists"}`, http.StatusConflict)
+	// Проверяем, существует ли пользователь, в том числе отключенный
+	taken, err := userEmailTaken(db, registerReq.Email, 0)
+	if err != nil {
+		logf(r, "Error checking email availability: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+	if taken {
+		logf(r, "User already exists: %s", maskEmail(registerReq.Email))
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "User with this email already exists")
 		return
 	}
 
-	// Хэшируем пароль
-	hashedPassword, err := auth.HashPassword(registerReq.Password)
+	verificationToken, err := auth.GenerateVerificationToken()
 	if err != nil {
-		log.Printf("Error hashing password: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, "Error generating verification token: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
 		return
 	}
 
-	// Создаем пользователя
+	// Создаем пользователя, email подтверждается по ссылке из письма.
+	// Пароль хэшируется в User.BeforeSave
 	user := models.User{
-		Email:    registerReq.Email,
-		Password: hashedPassword,
-		Role:     registerReq.Role,
-	}
-
-	// Создаем связанные записи в зависимости от роли
-	switch registerReq.Role {
-	case models.RoleStudent:
-		// Создаем студента
-		student := models.Student{
-			Email:   registerReq.Email,
-			Name:    "New",
-			Surname: "Student",
-		}
-		if err := h.db.Create(&student).Error; err != nil {
-			log.Printf("Error creating student: %v", err)
-			http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
-			return
+		Email:             registerReq.Email,
+		Password:          registerReq.Password,
+		Role:              registerReq.Role,
+		EmailVerified:     false,
+		VerificationToken: auth.HashToken(verificationToken),
+	}
+
+	// Пользователь и связанная запись создаются вместе, иначе остаются "сироты"
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		// Email студентов и преподавателей не уникален в таблицах, поэтому
+		// дубль связанной записи отсекается проверкой в той же транзакции
+		if registerReq.Role == models.RoleStudent || registerReq.Role == models.RoleTeacher {
+			taken, err := profileEmailTaken(tx, registerReq.Email, registerReq.Role)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return errProfileEmailTaken
+			}
 		}
-		user.StudentID = &student.ID
 
-	case models.RoleTeacher:
-		// Создаем преподавателя
-		teacher := models.Teacher{
-			Email:   registerReq.Email,
-			Name:    "New",
-			Surname: "Teacher",
+		// Создаем связанные записи в зависимости от роли
+		switch registerReq.Role {
+		case models.RoleStudent:
+			student := models.Student{
+				Email:   registerReq.Email,
+				Name:    "New",
+				Surname: "Student",
+			}
+			if err := tx.Create(&student).Error; err != nil {
+				return fmt.Errorf("create student: %w", err)
+			}
+			user.StudentID = &student.ID
+
+		case models.RoleTeacher:
+			teacher := models.Teacher{
+				Email:   registerReq.Email,
+				Name:    "New",
+				Surname: "Teacher",
+			}
+			if err := tx.Create(&teacher).Error; err != nil {
+				return fmt.Errorf("create teacher: %w", err)
+			}
+			user.TeacherID = &teacher.ID
 		}
-		if err := h.db.Create(&teacher).Error; err != nil {
-			log.Printf(" Error creating teacher: %v", err)
-			http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
-			return
+
+		// Сохраняем пользователя
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("create user: %w", err)
 		}
-		user.TeacherID = &teacher.ID
-	}
 
-	// Сохраняем пользователя
-	if err := h.db.Create(&user).Error; err != nil {
-		log.Printf(" Error creating user: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		// Обновляем связанные записи
+		switch registerReq.Role {
+		case models.RoleStudent:
+			return tx.Model(&models.Student{ID: *user.StudentID}).Update("user_id", user.ID).Error
+		case models.RoleTeacher:
+			return tx.Model(&models.Teacher{ID: *user.TeacherID}).Update("user_id", user.ID).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errProfileEmailTaken) {
+		logf(r, "Registration rejected: %s is used by a student or teacher", maskEmail(registerReq.Email))
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Student or teacher with this email already exists")
 		return
 	}
-
-	// Обновляем связанные записи
-	switch registerReq.Role {
-	case models.RoleStudent:
-		h.db.Model(&models.Student{ID: *user.StudentID}).Update("user_id", user.ID)
-	case models.RoleTeacher:
-		h.db.Model(&models.Teacher{ID: *user.TeacherID}).Update("user_id", user.ID)
-	}
-
-	// Генерируем токен
-	token, err := h.jwtService.GenerateToken(&user)
 	if err != nil {
-		log.Printf(" Error generating token: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, " Error registering user %s: %v", maskEmail(registerReq.Email), err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
+	h.sendVerificationEmail(r, &user, verificationToken)
+
 	// Скрываем пароль в ответе
 	user.Password = ""
+	response := models.LoginResponse{User: user}
 
-	response := models.LoginResponse{
-		Token: token,
-		User:  user,
+	// Пока email не подтвержден, вход запрещен - токен не выдается и при регистрации
+	if !h.cfg.RequireEmailVerification {
+		token, err := h.jwtService.GenerateToken(&user)
+		if err != nil {
+			logf(r, " Error generating token: %v", err)
+			httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+			return
+		}
+		response.Token = token
 	}
 
-	log.Printf("User registered successfully: %s (role: %s)", user.Email, user.Role)
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	logf(r, "User registered successfully: %s (role: %s)", maskEmail(user.Email), user.Role)
+	httputil.RespondJSON(w, http.StatusCreated, response)
 }
 
 // GetCurrentUser возвращает текущего пользователя
 func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Извлекаем claims из контекста (через middleware)
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
-		return
-	}
 
 	// Получаем полную информацию о пользователе
 	var user models.User
-	if err := h.db.Preload("Student").Preload("Teacher").First(&user, claims.UserID).Error; err != nil {
-		log.Printf("Error fetching user: %v", err)
-		http.Error(w, `{"error": "User not found"}`, http.StatusNotFound)
+	if err := db.Preload("Student").Preload("Teacher").First(&user, claims.UserID).Error; err != nil {
+		logf(r, "Error fetching user: %v", err)
+		httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
 		return
 	}
 
 	// Скрываем пароль
 	user.Password = ""
-	json.NewEncoder(w).Encode(user)
+	httputil.RespondJSON(w, http.StatusOK, user)
+}
+
+// UpdateCurrentUserRequest - изменение собственной учетной записи
+type UpdateCurrentUserRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// errProfileEmailTaken - email уже указан у студента или преподавателя
+var errProfileEmailTaken = errors.New("student or teacher with this email already exists")
+
+// profileEmailTaken проверяет без учета регистра, указан ли email у студента или преподавателя.
+// Для роли преподавателя учитываются и удаленные преподаватели: уникальный индекс
+// по email распространяется на них
+func profileEmailTaken(tx *gorm.DB, email string, role models.Role) (bool, error) {
+	teachers := tx.Model(&models.Teacher{})
+	if role == models.RoleTeacher {
+		teachers = tx.Unscoped().Model(&models.Teacher{})
+	}
+	for _, query := range []*gorm.DB{tx.Model(&models.Student{}), teachers} {
+		var count int64
+		if err := query.Where("LOWER(email) = LOWER(?)", email).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// errTeacherEmailTaken - email занят другим преподавателем
+var errTeacherEmailTaken = errors.New("teacher with this email already exists")
+
+// UpdateCurrentUser меняет email текущего пользователя: PATCH /api/auth/me.
+// Email связанного студента или преподавателя меняется в той же транзакции,
+// новый адрес нужно подтвердить заново. В ответе - новый токен с новым email,
+// если вход не требует подтверждения email
+func (h *AuthHandler) UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var req UpdateCurrentUserRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	email := models.NormalizeEmail(req.Email)
+
+	var user models.User
+	if err := db.First(&user, claims.UserID).Error; err != nil {
+		logf(r, "Error fetching user %d: %v", claims.UserID, err)
+		httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+
+	if email == user.Email {
+		user.Password = ""
+		httputil.RespondJSON(w, http.StatusOK, user)
+		return
+	}
+
+	verificationToken, err := auth.GenerateVerificationToken()
+	if err != nil {
+		logf(r, "Error generating verification token: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	oldEmail := user.Email
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		taken, err := userEmailTaken(tx, email, user.ID)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errUserEmailTaken
+		}
+
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":              email,
+			"email_verified":     false,
+			"verification_token": auth.HashToken(verificationToken),
+		}).Error; err != nil {
+			return err
+		}
+
+		if user.StudentID != nil {
+			if err := tx.Model(&models.Student{}).Where("id = ?", *user.StudentID).
+				Updates(map[string]interface{}{"email": email, "version": versionBump}).Error; err != nil {
+				return err
+			}
+		}
+
+		if user.TeacherID != nil {
+			// Уникальность email преподавателей распространяется и на удаленных
+			var count int64
+			if err := tx.Unscoped().Model(&models.Teacher{}).
+				Where("LOWER(email) = LOWER(?) AND id != ?", email, *user.TeacherID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errTeacherEmailTaken
+			}
+			if err := tx.Model(&models.Teacher{}).Where("id = ?", *user.TeacherID).
+				Updates(map[string]interface{}{"email": email, "version": versionBump}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errUserEmailTaken) || errors.Is(err, errTeacherEmailTaken) {
+			logf(r, "Email change for user %d rejected: %s is taken", user.ID, maskEmail(email))
+			httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Email is already in use")
+			return
+		}
+		logf(r, "Error changing email for user %d: %v", user.ID, err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	user.Email = email
+	user.EmailVerified = false
+	user.VerificationToken = auth.HashToken(verificationToken)
+
+	h.sendVerificationEmail(r, &user, verificationToken)
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityUser, user.ID,
+		fmt.Sprintf("email changed from %s to %s", maskEmail(oldEmail), maskEmail(email)))
+
+	user.Password = ""
+	response := models.LoginResponse{User: user}
+
+	// В старом токене остался прежний email. Если вход требует подтверждения,
+	// новый токен выдается только при входе после подтверждения нового адреса
+	if !h.cfg.RequireEmailVerification {
+		token, err := h.jwtService.GenerateToken(&user)
+		if err != nil {
+			logf(r, "Error generating token: %v", err)
+			httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+			return
+		}
+		response.Token = token
+	}
+
+	logf(r, "User %d changed email from %s to %s", user.ID, maskEmail(oldEmail), maskEmail(email))
+	httputil.RespondJSON(w, http.StatusOK, response)
+}
+
+// VerifyEmail подтверждает email по токену из письма: GET /api/auth/verify?token=...
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Verification token is required")
+		return
+	}
+
+	var user models.User
+	if err := db.Where("verification_token = ?", auth.HashToken(token)).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "Invalid verification token")
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid or expired verification token")
+			return
+		}
+		logf(r, "Error fetching user by verification token: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	if err := db.Model(&user).Updates(map[string]interface{}{
+		"email_verified":     true,
+		"verification_token": "",
+	}).Error; err != nil {
+		logf(r, "Error verifying email for %s: %v", maskEmail(user.Email), err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, "Email verified: %s", maskEmail(user.Email))
+	httputil.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":        "Email verified",
+		"email":          user.Email,
+		"email_verified": true,
+	})
+}
+
+// ForgotPasswordRequest - запрос ссылки для сброса пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+// ResetPasswordRequest - установка нового пароля по токену из письма
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// ForgotPassword отправляет ссылку для сброса пароля: POST /api/auth/forgot-password.
+// Ответ всегда 200, чтобы по нему нельзя было узнать, зарегистрирован ли email
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	var req ForgotPasswordRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	email := models.NormalizeEmail(req.Email)
+	if email == "" {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Email is required")
+		return
+	}
+
+	response := map[string]string{
+		"message": "If an account with this email exists, a password reset link has been sent",
+	}
+
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			logf(r, "Error fetching user for password reset: %v", err)
+			respondDBError(w, err, "Internal server error")
+			return
+		}
+		logf(r, "Password reset requested for unknown email: %s", maskEmail(email))
+		httputil.RespondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	token, err := auth.GenerateVerificationToken()
+	if err != nil {
+		logf(r, "Error generating password reset token: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	// Новая ссылка отменяет все выданные ранее
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return fmt.Errorf("delete old reset tokens: %w", err)
+		}
+		resetToken := models.PasswordResetToken{
+			UserID:    user.ID,
+			Token:     auth.HashToken(token),
+			ExpiresAt: models.Timestamp(time.Now().Add(h.cfg.PasswordResetTTL)),
+		}
+		if err := tx.Create(&resetToken).Error; err != nil {
+			return fmt.Errorf("create reset token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logf(r, "Error creating password reset token for %s: %v", maskEmail(user.Email), err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(h.cfg.AppBaseURL, "/"), token)
+	body := fmt.Sprintf("Для сброса пароля перейдите по ссылке:\n%s\n\nСсылка действительна %s.", link, h.cfg.PasswordResetTTL)
+	if err := h.mailer.Send(user.Email, "Сброс пароля", body); err != nil {
+		logf(r, "❌ Error sending password reset email to %s: %v", maskEmail(user.Email), err)
+	}
+
+	logf(r, "Password reset requested: %s", maskEmail(user.Email))
+	httputil.RespondJSON(w, http.StatusOK, response)
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма: POST /api/auth/reset-password
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	var req ResetPasswordRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := auth.ValidatePassword(req.NewPassword); err != nil {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, err.Error())
+		return
+	}
+
+	var resetToken models.PasswordResetToken
+	if err := db.Where("token = ? AND expires_at > ?", auth.HashToken(req.Token), time.Now()).
+		First(&resetToken).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "Invalid or expired password reset token")
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid or expired reset token")
+			return
+		}
+		logf(r, "Error fetching password reset token: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	// Новый пароль хэшируется в User.BeforeSave
+	err := database.WithTx(db, func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", resetToken.UserID).Update("password", req.NewPassword)
+		if result.Error != nil {
+			return fmt.Errorf("update password: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("user_id = ?", resetToken.UserID).Delete(&models.PasswordResetToken{}).Error; err != nil {
+			return fmt.Errorf("delete reset tokens: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid or expired reset token")
+			return
+		}
+		logf(r, "Error resetting password for user %d: %v", resetToken.UserID, err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, "Password reset for user %d", resetToken.UserID)
+	httputil.RespondJSON(w, http.StatusOK, map[string]string{"message": "Password has been reset"})
 }
@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"student-backend/auth"
+	"student-backend/models"
+	"testing"
+	"time"
+)
+
+var mailLink = regexp.MustCompile(`https?://\S+`)
+
+// linkToken извлекает параметр token из ссылки в письме
+func linkToken(t *testing.T, mail sentMail) string {
+	t.Helper()
+	link, err := url.Parse(mailLink.FindString(mail.body))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no token link in mail %q", mail.body)
+	}
+	return link.Query().Get("token")
+}
+
+func login(t *testing.T, h *AuthHandler, email, password string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, h.Login, request{
+		method: http.MethodPost, target: "/api/auth/login",
+		body: models.LoginRequest{Email: email, Password: password},
+	})
+}
+
+func TestRegisterWithRequiredVerificationIssuesNoToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.RequireEmailVerification = true
+	h, mail := env.newAuthHandler(t)
+
+	w := serve(t, h.Register, request{
+		method: http.MethodPost, target: "/api/auth/register",
+		body: models.RegisterRequest{Email: "new@example.com", Password: "password123", Role: models.RoleStudent},
+	})
+	expectStatus(t, w, http.StatusCreated)
+	var registered models.LoginResponse
+	decodeBody(t, w, &registered)
+	if registered.Token != "" {
+		t.Fatal("register returned a token for an unverified account")
+	}
+
+	// В базе хранится хэш токена из письма, а не сам токен
+	token := linkToken(t, mail.last(t, "new@example.com"))
+	var user models.User
+	env.db.First(&user, registered.User.ID)
+	if user.VerificationToken != auth.HashToken(token) {
+		t.Fatalf("stored verification token = %q, want hash of the mailed token", user.VerificationToken)
+	}
+
+	expectStatus(t, login(t, h, "new@example.com", "password123"), http.StatusForbidden)
+
+	w = serve(t, h.VerifyEmail, request{method: http.MethodGet, target: "/api/auth/verify?token=" + url.QueryEscape(token)})
+	expectStatus(t, w, http.StatusOK)
+
+	w = login(t, h, "new@example.com", "password123")
+	expectStatus(t, w, http.StatusOK)
+	var loggedIn models.LoginResponse
+	decodeBody(t, w, &loggedIn)
+	if loggedIn.Token == "" {
+		t.Fatal("login after verification returned no token")
+	}
+
+	// Ссылка одноразовая
+	w = serve(t, h.VerifyEmail, request{method: http.MethodGet, target: "/api/auth/verify?token=" + url.QueryEscape(token)})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestRegisterRejectsInvalidRole(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+
+	for _, role := range []string{"superuser", "Admin", " admin", "", "null"} {
+		t.Run(role, func(t *testing.T) {
+			body := fmt.Sprintf(`{"email":"new@example.com","password":"password123","role":%q}`, role)
+			w := serve(t, h.Register, request{method: http.MethodPost, target: "/api/auth/register", body: body})
+			expectStatus(t, w, http.StatusUnprocessableEntity)
+		})
+	}
+
+	var users int64
+	env.db.Model(&models.User{}).Where("email = ?", "new@example.com").Count(&users)
+	if users != 0 || len(mail.sent) != 0 {
+		t.Fatalf("invalid registrations created %d users and sent %d mails", users, len(mail.sent))
+	}
+}
+
+func TestRegisterRejectsEmailOfExistingProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile func(t *testing.T, env *testEnv)
+		role    models.Role
+	}{
+		{"student profile", func(t *testing.T, env *testEnv) {
+			createStudent(t, env.db, "Anna", "Orlova", "taken@example.com", nil)
+		}, models.RoleStudent},
+		{"student profile for teacher", func(t *testing.T, env *testEnv) {
+			createStudent(t, env.db, "Anna", "Orlova", "Taken@Example.com", nil)
+		}, models.RoleTeacher},
+		{"teacher profile", func(t *testing.T, env *testEnv) {
+			createTeacherInGroups(t, env, "taken@example.com")
+		}, models.RoleTeacher},
+		{"teacher profile for student", func(t *testing.T, env *testEnv) {
+			createTeacherInGroups(t, env, "TAKEN@example.com")
+		}, models.RoleStudent},
+		{"deleted teacher profile", func(t *testing.T, env *testEnv) {
+			env.db.Delete(createTeacherInGroups(t, env, "taken@example.com"))
+		}, models.RoleTeacher},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			h, mail := env.newAuthHandler(t)
+			tt.profile(t, env)
+
+			w := serve(t, h.Register, request{
+				method: http.MethodPost, target: "/api/auth/register",
+				body: models.RegisterRequest{Email: "taken@example.com", Password: "password123", Role: tt.role},
+			})
+			expectStatus(t, w, http.StatusConflict)
+
+			var users, students, teachers int64
+			env.db.Model(&models.User{}).Count(&users)
+			env.db.Model(&models.Student{}).Count(&students)
+			env.db.Unscoped().Model(&models.Teacher{}).Count(&teachers)
+			if users != 0 || students+teachers != 1 || len(mail.sent) != 0 {
+				t.Fatalf("rejected registration left %d users, %d profiles and sent %d mails",
+					users, students+teachers, len(mail.sent))
+			}
+		})
+	}
+}
+
+func TestUpdateCurrentUserCannotChangeRole(t *testing.T) {
+	env := newTestEnv(t)
+	h, _ := env.newAuthHandler(t)
+	_, user := createLinkedStudent(t, env, "student@example.com")
+
+	w := serve(t, h.UpdateCurrentUser, request{
+		method: http.MethodPatch, target: "/api/auth/me",
+		body: `{"email":"student@example.com","role":"admin"}`, claims: claimsOf(user),
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var stored models.User
+	env.db.First(&stored, user.ID)
+	if stored.Role != models.RoleStudent {
+		t.Fatalf("role = %q after PATCH /api/auth/me, want it unchanged", stored.Role)
+	}
+}
+
+func TestRegisterWithoutRequiredVerificationIssuesToken(t *testing.T) {
+	env := newTestEnv(t)
+	h, _ := env.newAuthHandler(t)
+
+	w := serve(t, h.Register, request{
+		method: http.MethodPost, target: "/api/auth/register",
+		body: models.RegisterRequest{Email: "new@example.com", Password: "password123", Role: models.RoleStudent},
+	})
+	expectStatus(t, w, http.StatusCreated)
+	var registered models.LoginResponse
+	decodeBody(t, w, &registered)
+	if registered.Token == "" {
+		t.Fatal("register returned no token with verification not required")
+	}
+}
+
+func TestVerifyEmailRejectsInvalidToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.RequireEmailVerification = true
+	h, mail := env.newAuthHandler(t)
+
+	serve(t, h.Register, request{
+		method: http.MethodPost, target: "/api/auth/register",
+		body: models.RegisterRequest{Email: "new@example.com", Password: "password123", Role: models.RoleStudent},
+	})
+	token := linkToken(t, mail.last(t, "new@example.com"))
+
+	for name, target := range map[string]string{
+		"missing": "/api/auth/verify",
+		"unknown": "/api/auth/verify?token=deadbeef",
+		// Хэш из базы не подходит вместо токена из письма
+		"stored hash": "/api/auth/verify?token=" + auth.HashToken(token),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, h.VerifyEmail, request{method: http.MethodGet, target: target})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+
+	var user models.User
+	env.db.Where("email = ?", "new@example.com").First(&user)
+	if user.EmailVerified {
+		t.Fatal("email verified by an invalid token")
+	}
+}
+
+func forgotPassword(t *testing.T, h *AuthHandler, email string) {
+	t.Helper()
+	w := serve(t, h.ForgotPassword, request{
+		method: http.MethodPost, target: "/api/auth/forgot-password",
+		body: ForgotPasswordRequest{Email: email},
+	})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func resetPassword(t *testing.T, h *AuthHandler, token, password string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, h.ResetPassword, request{
+		method: http.MethodPost, target: "/api/auth/reset-password",
+		body: ResetPasswordRequest{Token: token, NewPassword: password},
+	})
+}
+
+func TestPasswordResetCycle(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+	createUser(t, env.db, "user@example.com", models.RoleStudent)
+
+	forgotPassword(t, h, "User@Example.com")
+	token := linkToken(t, mail.last(t, "user@example.com"))
+
+	expectStatus(t, resetPassword(t, h, token, "new-password-1"), http.StatusOK)
+	expectStatus(t, login(t, h, "user@example.com", "new-password-1"), http.StatusOK)
+	expectStatus(t, login(t, h, "user@example.com", "password123"), http.StatusUnauthorized)
+
+	// Ссылка одноразовая
+	expectStatus(t, resetPassword(t, h, token, "new-password-2"), http.StatusBadRequest)
+}
+
+func TestPasswordResetNewLinkRevokesPrevious(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+	createUser(t, env.db, "user@example.com", models.RoleStudent)
+
+	forgotPassword(t, h, "user@example.com")
+	first := linkToken(t, mail.last(t, "user@example.com"))
+	forgotPassword(t, h, "user@example.com")
+	second := linkToken(t, mail.last(t, "user@example.com"))
+
+	expectStatus(t, resetPassword(t, h, first, "new-password-1"), http.StatusBadRequest)
+	expectStatus(t, resetPassword(t, h, second, "new-password-1"), http.StatusOK)
+}
+
+func TestPasswordResetRejectsExpiredToken(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+	createUser(t, env.db, "user@example.com", models.RoleStudent)
+
+	forgotPassword(t, h, "user@example.com")
+	token := linkToken(t, mail.last(t, "user@example.com"))
+	env.db.Model(&models.PasswordResetToken{}).Where("token = ?", auth.HashToken(token)).
+		Update("expires_at", models.Timestamp(time.Now().Add(-time.Minute)))
+
+	expectStatus(t, resetPassword(t, h, token, "new-password-1"), http.StatusBadRequest)
+	expectStatus(t, login(t, h, "user@example.com", "password123"), http.StatusOK)
+}
+
+func TestForgotPasswordForUnknownEmailSendsNothing(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+
+	forgotPassword(t, h, "nobody@example.com")
+	if len(mail.sent) != 0 {
+		t.Fatalf("sent %d mails for an unknown email", len(mail.sent))
+	}
+}
+
+func updateEmail(t *testing.T, h *AuthHandler, user *models.User, email string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, h.UpdateCurrentUser, request{
+		method: http.MethodPatch, target: "/api/auth/me",
+		body: UpdateCurrentUserRequest{Email: email}, claims: claimsOf(user),
+	})
+}
+
+// createLinkedStudent создает студента и его учетную запись
+func createLinkedStudent(t *testing.T, env *testEnv, email string) (*models.Student, *models.User) {
+	t.Helper()
+	student := createStudent(t, env.db, "Anna", "Smirnova", email, nil)
+	user := models.User{Email: email, Password: "password123", Role: models.RoleStudent, EmailVerified: true, StudentID: &student.ID}
+	if err := env.db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	env.db.Model(student).Update("user_id", user.ID)
+	return student, &user
+}
+
+func TestUpdateCurrentUserChangesEmail(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+	student, user := createLinkedStudent(t, env, "old@example.com")
+	teacher, teacherUser := createLinkedTeacher(t, env, "teacher@example.com")
+
+	w := updateEmail(t, h, user, "New@Example.com")
+	expectStatus(t, w, http.StatusOK)
+	var response models.LoginResponse
+	decodeBody(t, w, &response)
+	if response.Token == "" || response.User.Email != "new@example.com" || response.User.EmailVerified {
+		t.Fatalf("response = %+v, want a token and the new unverified email", response)
+	}
+	claims, err := env.jwt.ValidateToken(response.Token)
+	if err != nil || claims.Email != "new@example.com" {
+		t.Fatalf("new token claims = %+v, %v; want the new email", claims, err)
+	}
+
+	// Новый адрес подтверждается по ссылке из письма на него
+	token := linkToken(t, mail.last(t, "new@example.com"))
+	var stored models.User
+	env.db.First(&stored, user.ID)
+	if stored.Email != "new@example.com" || stored.VerificationToken != auth.HashToken(token) {
+		t.Fatalf("stored user = %+v", stored)
+	}
+
+	var linked models.Student
+	env.db.First(&linked, student.ID)
+	if linked.Email != "new@example.com" || linked.Version != student.Version+1 {
+		t.Fatalf("linked student email = %q at version %d, want new@example.com at version %d",
+			linked.Email, linked.Version, student.Version+1)
+	}
+
+	expectStatus(t, updateEmail(t, h, teacherUser, "renamed@example.com"), http.StatusOK)
+	var linkedTeacher models.Teacher
+	env.db.First(&linkedTeacher, teacher.ID)
+	if linkedTeacher.Email != "renamed@example.com" {
+		t.Fatalf("linked teacher email = %q, want renamed@example.com", linkedTeacher.Email)
+	}
+}
+
+func TestUpdateCurrentUserRejectsTakenEmail(t *testing.T) {
+	env := newTestEnv(t)
+	h, _ := env.newAuthHandler(t)
+	_, user := createLinkedStudent(t, env, "student@example.com")
+	createUser(t, env.db, "taken@example.com", models.RoleAdmin)
+	_, teacherUser := createLinkedTeacher(t, env, "teacher@example.com")
+	// Преподаватель без учетной записи
+	env.db.Create(&models.Teacher{Name: "Olga", Surname: "Sokolova", Email: "olga@example.com"})
+
+	tests := []struct {
+		name  string
+		user  *models.User
+		email string
+	}{
+		{"email of another user", user, "Taken@example.com"},
+		{"email of another teacher", teacherUser, "olga@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, updateEmail(t, h, tt.user, tt.email), http.StatusConflict)
+
+			var stored models.User
+			env.db.First(&stored, tt.user.ID)
+			if stored.Email != tt.user.Email || !stored.EmailVerified {
+				t.Fatalf("rejected change modified the user: %+v", stored)
+			}
+		})
+	}
+
+	var student models.Student
+	env.db.Where("id = ?", *user.StudentID).First(&student)
+	if student.Email != "student@example.com" {
+		t.Fatalf("rejected change modified the linked student: %q", student.Email)
+	}
+}
+
+func TestUpdateCurrentUserWithRequiredVerificationIssuesNoToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.RequireEmailVerification = true
+	h, _ := env.newAuthHandler(t)
+	_, user := createLinkedStudent(t, env, "old@example.com")
+
+	w := updateEmail(t, h, user, "new@example.com")
+	expectStatus(t, w, http.StatusOK)
+	var response models.LoginResponse
+	decodeBody(t, w, &response)
+	if response.Token != "" {
+		t.Fatal("email change returned a token for an unverified address")
+	}
+
+	expectStatus(t, login(t, h, "new@example.com", "password123"), http.StatusForbidden)
+}
+
+// captureLog перенаправляет стандартный лог в буфер до конца теста
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestAuthLogsMaskEmails(t *testing.T) {
+	env := newTestEnv(t)
+	h, mail := env.newAuthHandler(t)
+	logs := captureLog(t)
+
+	w := serve(t, h.Register, request{
+		method: http.MethodPost, target: "/api/auth/register",
+		body: models.RegisterRequest{Email: "secret.person@example.com", Password: "password123", Role: models.RoleStudent},
+	})
+	expectStatus(t, w, http.StatusCreated)
+	var registered models.LoginResponse
+	decodeBody(t, w, &registered)
+	user := &registered.User
+
+	token := linkToken(t, mail.last(t, "secret.person@example.com"))
+	expectStatus(t, serve(t, h.VerifyEmail, request{method: http.MethodGet, target: "/api/auth/verify?token=" + token}), http.StatusOK)
+	expectStatus(t, login(t, h, "secret.person@example.com", "password123"), http.StatusOK)
+	expectStatus(t, login(t, h, "secret.person@example.com", "wrong-password"), http.StatusUnauthorized)
+	forgotPassword(t, h, "secret.person@example.com")
+	expectStatus(t, serve(t, h.SetupTwoFactor, request{method: http.MethodPost, target: "/api/auth/2fa/setup", claims: claimsOf(user)}), http.StatusOK)
+	expectStatus(t, updateEmail(t, h, user, "other.secret@example.com"), http.StatusOK)
+
+	for _, local := range []string{"secret.person", "other.secret"} {
+		if strings.Contains(logs.String(), local) {
+			t.Errorf("log contains unmasked email %s@example.com:\n%s", local, logs.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "s***@example.com") {
+		t.Errorf("log has no masked email:\n%s", logs.String())
+	}
+
+	var entry models.AuditLog
+	env.db.Where("entity = ? AND entity_id = ? AND detail LIKE ?", models.AuditEntityUser, user.ID, "email changed%").Last(&entry)
+	if entry.Detail != "email changed from s***@example.com to o***@example.com" {
+		t.Errorf("audit detail = %q, want masked emails", entry.Detail)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"student-backend/httputil"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// requestDB возвращает сессию GORM, привязанную к контексту запроса с таймаутом.
+// Если клиент отключится или таймаут истечет, запрос к базе будет прерван
+func requestDB(r *http.Request, db *gorm.DB, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	return db.WithContext(ctx), cancel
+}
+
+// respondDBError отвечает на ошибку базы данных: 504 при истечении таймаута,
+// 503 при отмене запроса, иначе 500 с переданным сообщением
+func respondDBError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("❌ Database query timed out: %v", err)
+		httputil.RespondError(w, http.StatusGatewayTimeout, httputil.CodeTimeout, "Database query timed out")
+	case errors.Is(err, context.Canceled):
+		log.Printf("❌ Database query canceled: %v", err)
+		httputil.RespondError(w, http.StatusServiceUnavailable, httputil.CodeUnavailable, "Request canceled")
+	default:
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, message)
+	}
+}
+
+// respondBodyError отвечает на ошибку чтения или разбора тела запроса:
+// 413 при превышении лимита размера, иначе 400 с переданным сообщением
+func respondBodyError(w http.ResponseWriter, err error, message string) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		httputil.RespondError(w, http.StatusRequestEntityTooLarge, httputil.CodePayloadTooLarge, "Request body too large",
+			map[string]interface{}{"limit_bytes": maxBytesErr.Limit})
+		return
+	}
+	httputil.RespondError(w, http.StatusBadRequest, httputil.CodeInvalidBody, message)
+}
+
+// decodeRequest разбирает JSON из тела запроса в dst и проверяет его по тегам validate.
+// При ошибке пишет ответ (400/413 - тело не разобрано, 422 - с ошибками по полям) и возвращает false
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := httputil.DecodeAndValidate(r, dst)
+	if err == nil {
+		return true
+	}
+	if respondValidationError(w, r, err) {
+		return false
+	}
+
+	logf(r, "Error decoding request body: %v", err)
+	respondBodyError(w, err, "Invalid request body")
+	return false
+}
+
+// validateRequest проверяет уже разобранное тело запроса и при ошибке пишет ответ 422
+func validateRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := httputil.Validate(dst)
+	if err == nil {
+		return true
+	}
+	if !respondValidationError(w, r, err) {
+		logf(r, "Error validating request: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+	}
+	return false
+}
+
+// respondValidationError отвечает 422 с ошибками по полям, если err - ошибка проверки
+func respondValidationError(w http.ResponseWriter, r *http.Request, err error) bool {
+	var validationErr *httputil.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	logf(r, "Validation failed: %v", validationErr)
+	httputil.RespondError(w, http.StatusUnprocessableEntity, httputil.CodeValidationFailed, "Validation failed",
+		validationErr.Details()...)
+	return true
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCanceledContextRespondsWithError(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", nil)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+	}{
+		{"canceled", canceled, http.StatusServiceUnavailable},
+		{"deadline exceeded", expired, http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan int, 1)
+			go func() {
+				w := serve(t, h.GetStudents, request{
+					method: http.MethodGet,
+					target: "/api/students",
+					claims: adminClaims(),
+					ctx:    tt.ctx,
+				})
+				done <- w.Code
+			}()
+
+			select {
+			case status := <-done:
+				if status != tt.status {
+					t.Errorf("status = %d, want %d", status, tt.status)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("handler did not respond with a canceled context")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"student-backend/auth"
+	"student-backend/events"
+	"student-backend/mailer"
+	"student-backend/models"
+	"student-backend/realtime"
+
+	"gorm.io/gorm"
+)
+
+// publishEvent публикует доменное событие от имени пользователя из claims.
+// Вызывается после фиксации изменений
+func publishEvent(bus *events.Bus, claims *auth.JWTClaims, eventType string, id uint, detail string, data interface{}) {
+	event := events.Event{Type: eventType, EntityID: id, Detail: detail, Data: data}
+	if claims != nil {
+		event.ActorID = claims.UserID
+	}
+	bus.Publish(event)
+}
+
+// auditEvents - действие и сущность журнала аудита для доменных событий
+var auditEvents = map[string]struct{ action, entity string }{
+	events.StudentCreated:  {models.AuditActionCreate, models.AuditEntityStudent},
+	events.StudentUpdated:  {models.AuditActionUpdate, models.AuditEntityStudent},
+	events.StudentDeleted:  {models.AuditActionDelete, models.AuditEntityStudent},
+	events.TeacherCreated:  {models.AuditActionCreate, models.AuditEntityTeacher},
+	events.TeacherUpdated:  {models.AuditActionUpdate, models.AuditEntityTeacher},
+	events.TeacherDeleted:  {models.AuditActionDelete, models.AuditEntityTeacher},
+	events.TeacherRestored: {models.AuditActionRestore, models.AuditEntityTeacher},
+}
+
+// SubscribeAudit записывает доменные события в журнал аудита
+func SubscribeAudit(bus *events.Bus, db *gorm.DB) {
+	bus.Subscribe(events.AnyType, func(event events.Event) {
+		target, ok := auditEvents[event.Type]
+		if !ok {
+			return
+		}
+		writeAuditLog(db, models.AuditLog{
+			UserID:   event.ActorID,
+			Action:   target.action,
+			Entity:   target.entity,
+			EntityID: event.EntityID,
+			Detail:   event.Detail,
+		})
+	})
+}
+
+// SubscribeLiveUpdates рассылает изменения подключенным к /ws клиентам.
+// События о преподавателях получают только администраторы
+func SubscribeLiveUpdates(bus *events.Bus, hub *realtime.Hub) {
+	forward := func(adminOnly bool) events.Handler {
+		return func(event events.Event) {
+			hub.Broadcast(realtime.Event{Type: event.Type, ID: event.EntityID, Data: event.Data, AdminOnly: adminOnly})
+		}
+	}
+
+	for _, eventType := range []string{events.StudentCreated, events.StudentUpdated, events.StudentDeleted} {
+		bus.Subscribe(eventType, forward(false))
+	}
+	for _, eventType := range []string{events.TeacherCreated, events.TeacherUpdated, events.TeacherDeleted, events.TeacherRestored} {
+		bus.Subscribe(eventType, forward(true))
+	}
+}
+
+// SubscribeCreateNotifications отправляет письмо добавленному студенту или преподавателю
+func SubscribeCreateNotifications(bus *events.Bus, m mailer.Mailer) {
+	notify := func(event events.Event) {
+		var email, name string
+		switch entity := event.Data.(type) {
+		case models.Student:
+			email, name = entity.Email, entity.Name
+		case models.Teacher:
+			email, name = entity.Email, entity.Name
+		default:
+			return
+		}
+		if strings.TrimSpace(email) == "" {
+			return
+		}
+
+		body := fmt.Sprintf("Здравствуйте, %s!\nВы добавлены в систему учета студентов.", name)
+		if err := m.Send(email, "Добавление в систему", body); err != nil {
+			log.Printf("❌ Error sending %s notification to %s: %v", event.Type, maskEmail(email), err)
+		}
+	}
+
+	bus.Subscribe(events.StudentCreated, notify)
+	bus.Subscribe(events.TeacherCreated, notify)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/events"
+	"student-backend/models"
+	"sync"
+	"testing"
+)
+
+func TestCreateStudentPublishesStudentCreated(t *testing.T) {
+	env := newTestEnv(t)
+	var mu sync.Mutex
+	var received []events.Event
+	env.bus.Subscribe(events.StudentCreated, func(event events.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, event)
+	})
+	mail := &recordingMailer{}
+	SubscribeCreateNotifications(env.bus, mail)
+	SubscribeAudit(env.bus, env.db)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+
+	w := serve(t, h.CreateStudent, request{
+		method: http.MethodPost, target: "/api/students",
+		body: StudentRequest{Name: "Anna", Surname: "Smirnova", Email: "anna@example.com"}, claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusCreated)
+	var created models.Student
+	decodeBody(t, w, &created)
+
+	// Close дожидается, пока подписчики разберут очереди
+	env.bus.Close()
+
+	if len(received) != 1 {
+		t.Fatalf("received %d student.created events, want 1", len(received))
+	}
+	event := received[0]
+	student, ok := event.Data.(models.Student)
+	if event.EntityID != created.ID || event.ActorID != adminClaims().UserID || !ok || student.Email != "anna@example.com" {
+		t.Fatalf("event = %+v, want student %d created by the admin", event, created.ID)
+	}
+
+	if got := mail.last(t, "anna@example.com"); got.subject == "" {
+		t.Fatal("notification mail has no subject")
+	}
+	var entry models.AuditLog
+	if err := env.db.Where("entity = ? AND entity_id = ?", models.AuditEntityStudent, created.ID).First(&entry).Error; err != nil {
+		t.Fatalf("no audit log for the created student: %v", err)
+	}
+	if entry.Action != models.AuditActionCreate {
+		t.Fatalf("audit action = %q, want %q", entry.Action, models.AuditActionCreate)
+	}
+}
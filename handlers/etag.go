@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"student-backend/httputil"
+
+	"gorm.io/gorm"
+)
+
+// rowsVersion - число строк выборки и время последнего изменения среди них.
+// Меняется при создании, изменении и удалении строк, поэтому подходит для ETag
+// без выборки самих данных. MaxUpdated читается строкой: драйверы возвращают
+// агрегат по времени по-разному (PostgreSQL - время, SQLite - текст)
+type rowsVersion struct {
+	Count      int64
+	MaxUpdated *string
+}
+
+func (v rowsVersion) String() string {
+	if v.MaxUpdated == nil {
+		return fmt.Sprintf("%d", v.Count)
+	}
+	return fmt.Sprintf("%d/%s", v.Count, *v.MaxUpdated)
+}
+
+// queryRowsVersion считает rowsVersion для отфильтрованного запроса одним агрегатом.
+// table - таблица, чей updated_at учитывается (запрос может содержать JOIN)
+func queryRowsVersion(query *gorm.DB, table string) (rowsVersion, error) {
+	var version rowsVersion
+	err := query.Session(&gorm.Session{}).
+		Select(fmt.Sprintf("COUNT(*) AS count, MAX(%s.updated_at) AS max_updated", table)).
+		Scan(&version).Error
+	return version, err
+}
+
+// versionETag строит слабый ETag из сведений о версии данных. В хэш входят путь
+// и строка запроса, поэтому ETag различается для разных страниц, фильтров и сортировок
+func versionETag(r *http.Request, parts ...interface{}) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.RawQuery))
+	for _, part := range parts {
+		fmt.Fprintf(hash, "\x00%v", part)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// checkNotModified выставляет ETag и отвечает 304, если клиент прислал совпадающий
+// If-None-Match. Возвращает true, если ответ уже записан
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// writeJSONWithETag сериализует ответ, вычисляет слабый ETag по содержимому и отвечает 304,
+// если клиент прислал совпадающий If-None-Match. Используется для списков со связанными
+// данными, изменения которых не видны по updated_at самих строк.
+// В хэш входит строка запроса, поэтому ETag различается для разных страниц, фильтров и сортировок
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		logf(r, "❌ Error encoding response: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.URL.RawQuery))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Write(append(body, '\n'))
+}
+
+// etagMatches проверяет заголовок If-None-Match (список значений или "*") на совпадение с ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		// Слабое сравнение: префикс W/ не учитывается
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
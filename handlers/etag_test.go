@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestListETagNotModified(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", nil)
+
+	list := func(target string, headers map[string]string) string {
+		t.Helper()
+		w := serve(t, h.GetStudents, request{method: http.MethodGet, target: target, claims: adminClaims(), headers: headers})
+		expectStatus(t, w, http.StatusOK)
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("GET %s returned no ETag", target)
+		}
+		return etag
+	}
+
+	etag := list("/api/students?page=1", nil)
+
+	w := serve(t, h.GetStudents, request{
+		method:  http.MethodGet,
+		target:  "/api/students?page=1",
+		claims:  adminClaims(),
+		headers: map[string]string{"If-None-Match": etag},
+	})
+	expectStatus(t, w, http.StatusNotModified)
+	if w.Body.Len() != 0 {
+		t.Errorf("304 response has a body: %q", w.Body.String())
+	}
+
+	if other := list("/api/students?page=1&sort=surname", nil); other == etag {
+		t.Error("ETag does not vary with query parameters")
+	}
+
+	createStudent(t, env.db, "Boris", "Ivanov", "boris@example.com", nil)
+	if changed := list("/api/students?page=1", map[string]string{"If-None-Match": etag}); changed == etag {
+		t.Error("ETag did not change after a new student was added")
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"student-backend/middleware"
+	"student-backend/models"
+	"time"
+)
+
+// eventsKeepAlive - период комментария, не дающего прокси закрыть простаивающий поток
+const eventsKeepAlive = 15 * time.Second
+
+type EventsHandler struct {
+	trail *middleware.AuditTrail
+}
+
+func NewEventsHandler(trail *middleware.AuditTrail) *EventsHandler {
+	return &EventsHandler{trail: trail}
+}
+
+// Stream отдает журнал изменяющих запросов потоком Server-Sent Events
+// (только для админа): каждая сохраненная запись приходит событием audit.
+// Поток живет, пока клиент не отключится
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceAudit) {
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Поток не ограничен WriteTimeout сервера
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logf(r, "Cannot clear write deadline: %v", err)
+	}
+
+	entries, unsubscribe := h.trail.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		logf(r, "❌ Event stream is not supported: %v", err)
+		return
+	}
+
+	claims := middleware.GetUserClaims(r.Context())
+	logf(r, "Event stream opened for %s", claims.Email)
+	defer logf(r, "Event stream closed for %s", claims.Email)
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				logf(r, "❌ Error encoding audit entry %d: %v", entry.ID, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: audit\ndata: %s\n\n", entry.ID, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"student-backend/config"
+	"student-backend/features"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+
+	"gorm.io/gorm"
+)
+
+type FeatureFlagHandler struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	flags *features.Flags
+}
+
+func NewFeatureFlagHandler(db *gorm.DB, cfg *config.Config, flags *features.Flags) *FeatureFlagHandler {
+	return &FeatureFlagHandler{db: db, cfg: cfg, flags: flags}
+}
+
+// GetFlags возвращает значения всех флагов функциональности (только для админа)
+func (h *FeatureFlagHandler) GetFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceFeatureFlags) {
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, h.flags.All())
+}
+
+// UpdateFlags меняет переданные флаги: {"registration_open": false} (только для админа).
+// Значения сохраняются в базе и применяются сразу
+func (h *FeatureFlagHandler) UpdateFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceFeatureFlags) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var updates map[string]bool
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		respondBodyError(w, err, "Invalid request body, expected an object of boolean flags")
+		return
+	}
+	if len(updates) == 0 {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "At least one flag is required")
+		return
+	}
+
+	if err := h.flags.Set(db, updates); err != nil {
+		if errors.Is(err, features.ErrUnknownFlag) {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, err.Error())
+			return
+		}
+		logf(r, "Error saving feature flags: %v", err)
+		respondDBError(w, err, "Failed to save feature flags")
+		return
+	}
+
+	logf(r, "Feature flags %v updated by %s", updates, claims.Email)
+	httputil.RespondJSON(w, http.StatusOK, h.flags.All())
+}
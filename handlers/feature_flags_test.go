@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"student-backend/features"
+	"student-backend/models"
+	"testing"
+)
+
+func TestRegistrationOpenFlag(t *testing.T) {
+	env := newTestEnv(t)
+	authHandler, _ := env.newAuthHandler(t)
+	flagHandler := NewFeatureFlagHandler(env.db, env.cfg, authHandler.flags)
+
+	setRegistrationOpen := func(open bool) {
+		t.Helper()
+		w := serve(t, flagHandler.UpdateFlags, request{
+			method: http.MethodPatch, target: "/api/admin/flags",
+			body: map[string]bool{features.RegistrationOpen: open}, claims: adminClaims(),
+		})
+		expectStatus(t, w, http.StatusOK)
+	}
+	registered := 0
+	register := func() int {
+		t.Helper()
+		registered++
+		w := serve(t, authHandler.Register, request{
+			method: http.MethodPost, target: "/api/auth/register",
+			body: models.RegisterRequest{Email: fmt.Sprintf("user%d@example.com", registered), Password: "password123", Role: models.RoleStudent},
+		})
+		return w.Code
+	}
+
+	if got := register(); got != http.StatusCreated {
+		t.Fatalf("open registration: status = %d, want 201", got)
+	}
+
+	setRegistrationOpen(false)
+	if got := register(); got != http.StatusForbidden {
+		t.Fatalf("closed registration: status = %d, want 403", got)
+	}
+
+	// Значение сохранено в базе и переживает перезапуск
+	reloaded := features.New(env.db)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("load feature flags: %v", err)
+	}
+	if reloaded.Enabled(features.RegistrationOpen) {
+		t.Fatal("registration_open is not persisted")
+	}
+
+	setRegistrationOpen(true)
+	if got := register(); got != http.StatusCreated {
+		t.Fatalf("reopened registration: status = %d, want 201", got)
+	}
+}
+
+func TestUpdateFlagsRejectsUnknownFlag(t *testing.T) {
+	env := newTestEnv(t)
+	authHandler, _ := env.newAuthHandler(t)
+	flagHandler := NewFeatureFlagHandler(env.db, env.cfg, authHandler.flags)
+
+	for name, body := range map[string]string{
+		"unknown flag": `{"no_such_flag": true}`,
+		"empty":        `{}`,
+		"not boolean":  `{"registration_open": "no"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, flagHandler.UpdateFlags, request{
+				method: http.MethodPatch, target: "/api/admin/flags", body: body, claims: adminClaims(),
+			})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+
+	if !authHandler.flags.Enabled(features.RegistrationOpen) {
+		t.Fatal("rejected update changed registration_open")
+	}
+}
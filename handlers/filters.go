@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// likeEscaper экранирует спецсимволы шаблона LIKE, чтобы они искались буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike экранирует %, _ и \ во введенной пользователем строке
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// containsPattern строит шаблон "содержит" с экранированием спецсимволов
+// для условия из containsCondition
+func containsPattern(value string) string {
+	return "%" + escapeLike(value) + "%"
+}
+
+// containsCondition - условие поиска без учета регистра по колонке column.
+// LOWER(...) LIKE вместо ILIKE одинаково работает в PostgreSQL и SQLite
+func containsCondition(column string) string {
+	return "LOWER(" + column + `) LIKE LOWER(?) ESCAPE '\'`
+}
+
+// applySearch добавляет поиск строки q по нескольким колонкам:
+// WHERE (col1 LIKE ? OR col2 LIKE ? ...) без учета регистра. Условие объединяется с остальными фильтрами через AND
+func applySearch(query *gorm.DB, q string, columns ...string) *gorm.DB {
+	q = strings.TrimSpace(q)
+	if q == "" || len(columns) == 0 {
+		return query
+	}
+
+	pattern := containsPattern(q)
+	conditions := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		conditions[i] = containsCondition(column)
+		args[i] = pattern
+	}
+
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"student-backend/auth"
+	"student-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordGroupChanges записывает в историю перевод студентов в группу toGroupID.
+// Студенты, уже состоящие в этой группе, пропускаются.
+// Вызывается внутри транзакции перевода до обновления group_id
+func recordGroupChanges(tx *gorm.DB, claims *auth.JWTClaims, students []models.Student, toGroupID uint) error {
+	now := time.Now()
+	seen := make(map[uint]bool, len(students))
+	history := make([]models.StudentGroupHistory, 0, len(students))
+	for _, student := range students {
+		if seen[student.ID] || (student.GroupID != nil && *student.GroupID == toGroupID) {
+			continue
+		}
+		seen[student.ID] = true
+		target := toGroupID
+		history = append(history, models.StudentGroupHistory{
+			StudentID:   student.ID,
+			FromGroupID: student.GroupID,
+			ToGroupID:   &target,
+			ChangedBy:   claims.UserID,
+			ChangedAt:   models.Timestamp(now),
+		})
+	}
+
+	if len(history) == 0 {
+		return nil
+	}
+	return tx.Create(&history).Error
+}
+
+// recordStudentGroupChange записывает в историю смену группы одного студента
+// при его обновлении; toGroupID == nil - студент выведен из группы
+func recordStudentGroupChange(tx *gorm.DB, claims *auth.JWTClaims, student *models.Student, toGroupID *uint) error {
+	return tx.Create(&models.StudentGroupHistory{
+		StudentID:   student.ID,
+		FromGroupID: student.GroupID,
+		ToGroupID:   toGroupID,
+		ChangedBy:   claims.UserID,
+		ChangedAt:   models.Timestamp(time.Now()),
+	}).Error
+}
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/httputil"
 	"student-backend/middleware"
 	"student-backend/models"
 
@@ -14,330 +20,1160 @@ import (
 	"gorm.io/gorm"
 )
 
+// GroupRequest - тело создания и обновления группы
+type GroupRequest struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	Code      string `json:"code" validate:"required,max=20"`
+	Year      int    `json:"year"`
+	Semester  int    `json:"semester"`
+	CuratorID *uint  `json:"curator_id"`
+	// Version - версия, прочитанная клиентом; обязательна при обновлении
+	Version int `json:"version"`
+}
+
+// PatchGroupRequest - тело частичного обновления группы: nil - поле не меняется.
+// Название и код нельзя очистить: min=1 в тегах
+type PatchGroupRequest struct {
+	Name      *string `json:"name" validate:"omitnil,min=1,max=100"`
+	Code      *string `json:"code" validate:"omitnil,min=1,max=20"`
+	Year      *int    `json:"year"`
+	Semester  *int    `json:"semester"`
+	CuratorID *uint   `json:"curator_id"`
+	Version   int     `json:"version"`
+}
+
+// TransferStudentsRequest - тело перевода всех студентов группы {id} в другую группу
+type TransferStudentsRequest struct {
+	TargetGroupID uint `json:"target_group_id" validate:"required"`
+}
+
+// MoveStudentsRequest - тело перевода студентов в группу {id}: перечисленных
+// в student_ids или всех студентов группы from_group_id. Если заданы оба поля,
+// переводятся только перечисленные студенты группы from_group_id
+type MoveStudentsRequest struct {
+	FromGroupID uint   `json:"from_group_id"`
+	StudentIDs  []uint `json:"student_ids" validate:"omitempty,dive,gt=0"`
+}
+
 type GroupHandler struct {
-	db *gorm.DB
+	db          *gorm.DB
+	cfg         *config.Config
+	codePattern *regexp.Regexp
+}
+
+func NewGroupHandler(db *gorm.DB, cfg *config.Config) *GroupHandler {
+	codePattern, err := regexp.Compile(cfg.GroupCodePattern)
+	if err != nil {
+		log.Printf("❌ Invalid GROUP_CODE_PATTERN %q, using default: %v", cfg.GroupCodePattern, err)
+		codePattern = regexp.MustCompile(config.DefaultGroupCodePattern)
+	}
+
+	return &GroupHandler{db: db, cfg: cfg, codePattern: codePattern}
+}
+
+// normalizeGroupCode приводит код группы к каноническому виду: без пробелов по краям
+// и в верхнем регистре. Применяется везде, где код приходит от клиента
+func normalizeGroupCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// validateCode проверяет нормализованный код группы по шаблону и при несовпадении пишет ответ 400
+func (h *GroupHandler) validateCode(w http.ResponseWriter, code string) bool {
+	if h.codePattern.MatchString(code) {
+		return true
+	}
+
+	log.Printf("Validation failed: invalid group code '%s'", code)
+	httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed,
+		fmt.Sprintf("Invalid group code format, expected %s", h.codePattern.String()))
+	return false
+}
+
+// groupStudentCountExpr считает студентов группы коррелированным подзапросом,
+// чтобы список групп получался одним запросом без подсчета по каждой строке
+const groupStudentCountExpr = "(SELECT COUNT(*) FROM students WHERE students.group_id = groups.id AND students.deleted_at IS NULL)"
+
+// groupSortFields - поля сортировки групп и соответствующие им колонки
+var groupSortFields = map[string]string{
+	"id":            "groups.id",
+	"name":          "groups.name",
+	"code":          "groups.code",
+	"year":          "groups.year",
+	"semester":      "groups.semester",
+	"created_at":    "groups.created_at",
+	"updated_at":    "groups.updated_at",
+	"student_count": "student_count",
+}
+
+// applyArchivedFilter применяет параметр archived: архивные группы по умолчанию скрыты,
+// "true" - только архивные, "all" - все. Возвращает false при недопустимом значении
+func applyArchivedFilter(query *gorm.DB, archived string) (*gorm.DB, bool) {
+	switch archived {
+	case "", "false":
+		return query.Where("groups.archived = ?", false), true
+	case "true":
+		return query.Where("groups.archived = ?", true), true
+	case "all":
+		return query, true
+	}
+	return query, false
 }
 
-func NewGroupHandler(db *gorm.DB) *GroupHandler {
-	return &GroupHandler{db: db}
+// groupListItem - элемент списка групп с количеством студентов
+type groupListItem struct {
+	models.Group
+	StudentCount int64 `json:"student_count"`
+}
+
+// TableName - элементы списка выбираются из таблицы групп
+func (groupListItem) TableName() string {
+	return "groups"
+}
+
+// loadGroupCurators подгружает кураторов для страницы списка одним запросом
+func loadGroupCurators(db *gorm.DB, groups []groupListItem) error {
+	var ids []uint
+	for _, g := range groups {
+		if g.CuratorID != nil {
+			ids = append(ids, *g.CuratorID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var curators []models.GroupCurator
+	if err := db.Where("id IN ?", ids).Find(&curators).Error; err != nil {
+		return err
+	}
+
+	byID := make(map[uint]*models.GroupCurator, len(curators))
+	for i := range curators {
+		byID[curators[i].ID] = &curators[i]
+	}
+	for i := range groups {
+		if groups[i].CuratorID != nil {
+			groups[i].Curator = byID[*groups[i].CuratorID]
+		}
+	}
+	return nil
+}
+
+// validateGroupPeriod проверяет учебный год и семестр группы.
+// При ошибке сам пишет ответ и возвращает false
+func validateGroupPeriod(w http.ResponseWriter, year, semester int) bool {
+	if !models.IsValidGroupYear(year) {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, fmt.Sprintf("Year must be between %d and %d", models.MinGroupYear, models.MaxGroupYear))
+		return false
+	}
+	if !models.IsValidSemester(semester) {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Semester must be 1 or 2")
+		return false
+	}
+	return true
+}
+
+// validateCurator проверяет, что назначаемый куратор существует.
+// При ошибке сам пишет ответ и возвращает false
+func validateCurator(db *gorm.DB, w http.ResponseWriter, curatorID *uint) bool {
+	if curatorID == nil {
+		return true
+	}
+	if *curatorID == 0 {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid curator_id")
+		return false
+	}
+
+	var curator models.GroupCurator
+	if err := db.First(&curator, *curatorID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			middleware.Logf(db.Statement.Context, "Curator teacher with ID %d not found", *curatorID)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Curator teacher not found")
+			return false
+		}
+		middleware.Logf(db.Statement.Context, "Error checking curator: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return false
+	}
+	return true
 }
 
 func (h *GroupHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionRead, models.ResourceGroups) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf("User %s (role: %s) tried to access groups without permission",
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	// ?mine=true ограничивает список группами, где преподаватель назначен или является куратором.
+	// Для преподавателя это поведение по умолчанию, все группы - по ?mine=false
+	mine := r.URL.Query().Get("mine") == "true"
+	switch claims.Role {
+	case models.RoleAdmin, models.RoleStudent:
+	case models.RoleTeacher:
+		mine = r.URL.Query().Get("mine") != "false"
+	default:
+		logf(r, "User %s (role: %s) tried to access groups without permission",
 			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+		httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Insufficient permissions")
 		return
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	page, limit, _ := parsePagination(r, h.cfg)
+
+	sortBy := r.URL.Query().Get("sortBy")
+	nameFilter := r.URL.Query().Get("name")
+	codeFilter := r.URL.Query().Get("code")
+
+	query := db.Model(&models.Group{})
+
+	query, ok := applyArchivedFilter(query, r.URL.Query().Get("archived"))
+	if !ok {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid archived value, expected true, false or all")
+		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 {
-		limit = 5
+	// Студент видит только свою группу
+	if claims.Role == models.RoleStudent {
+		student, ok := callerStudent(db, claims)
+		if !ok || student.GroupID == nil {
+			query = query.Where("1 = 0")
+		} else {
+			query = query.Where("id = ?", *student.GroupID)
+		}
+	} else if mine {
+		teacherID, ok := callerTeacherID(db, claims)
+		if !ok {
+			logf(r, "User %s requested own groups without a linked teacher profile", claims.Email)
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Teacher profile not found")
+			return
+		}
+		query = query.Where("(curator_id = ? OR id IN (SELECT group_id FROM teacher_groups WHERE teacher_id = ?))",
+			teacherID, teacherID)
 	}
 
-	offset := (page - 1) * limit
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid year")
+			return
+		}
+		query = query.Where("year = ?", year)
+	}
 
-	sortBy := r.URL.Query().Get("sortBy")
-	nameFilter := r.URL.Query().Get("name")
-	codeFilter := r.URL.Query().Get("code")
+	if semesterStr := r.URL.Query().Get("semester"); semesterStr != "" {
+		semester, err := strconv.Atoi(semesterStr)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid semester")
+			return
+		}
+		query = query.Where("semester = ?", semester)
+	}
 
-	query := h.db.Model(&models.Group{})
+	if curatorStr := r.URL.Query().Get("curator_id"); curatorStr != "" {
+		curatorID, err := strconv.ParseUint(curatorStr, 10, 64)
+		if err != nil {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid curator_id")
+			return
+		}
+		query = query.Where("curator_id = ?", curatorID)
+	}
+
+	if minStr := r.URL.Query().Get("min_students"); minStr != "" {
+		minStudents, err := strconv.Atoi(minStr)
+		if err != nil || minStudents < 0 {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid min_students")
+			return
+		}
+		query = query.Where(groupStudentCountExpr+" >= ?", minStudents)
+	}
+
+	if maxStr := r.URL.Query().Get("max_students"); maxStr != "" {
+		maxStudents, err := strconv.Atoi(maxStr)
+		if err != nil || maxStudents < 0 {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid max_students")
+			return
+		}
+		query = query.Where(groupStudentCountExpr+" <= ?", maxStudents)
+	}
 
 	if nameFilter != "" {
 		cleanName := strings.Trim(nameFilter, "*")
-		query = query.Where("name ILIKE ?", "%"+cleanName+"%")
+		query = query.Where(containsCondition("name"), containsPattern(cleanName))
 	}
 
 	if codeFilter != "" {
 		cleanCode := strings.Trim(codeFilter, "*")
-		query = query.Where("code ILIKE ?", "%"+cleanCode+"%")
+		query = query.Where(containsCondition("code"), containsPattern(cleanCode))
+	}
+
+	// student_count - псевдоним вычисляемой колонки, Postgres допускает его в ORDER BY
+	result, err := Paginate(query, &groupListItem{}, ListOptions{
+		Page:       page,
+		Limit:      limit,
+		SortBy:     sortBy,
+		SortFields: groupSortFields,
+		Select:     "groups.*, " + groupStudentCountExpr + " AS student_count",
+	})
+	if errors.Is(err, errInvalidSortField) {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid sort field")
+		return
+	}
+	if err != nil {
+		logf(r, "Error fetching groups: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
 	}
 
-	var totalItems int64
-	if err := query.Count(&totalItems).Error; err != nil {
-		log.Printf("Error counting groups: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	if err := loadGroupCurators(db, result.Items); err != nil {
+		logf(r, "Error fetching group curators: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
-	if sortBy != "" {
-		if strings.HasPrefix(sortBy, "-") {
-			field := strings.TrimPrefix(sortBy, "-")
-			query = query.Order(field + " DESC")
-		} else {
-			query = query.Order(sortBy + " ASC")
+	setPaginationHeaders(w, r, result.Meta)
+	writeList(w, r, result.Response(), true)
+}
+
+// groupStudentSummary - краткие сведения о студенте в карточке группы
+type groupStudentSummary struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+}
+
+// maxGroupStudents ограничивает число студентов в карточке группы
+const maxGroupStudents = 100
+
+// GetGroup возвращает группу со списком студентов и их количеством.
+// Доступна админу, назначенным в группу преподавателям и студентам этой группы
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
+		return
+	}
+
+	var group models.Group
+	if err := db.Preload("Curator").First(&group, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "Group with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
+			return
 		}
-	} else {
-		query = query.Order("id ASC")
+		logf(r, "Error fetching group: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
 	}
 
-	var groups []models.Group
-	if err := query.Offset(offset).Limit(limit).Find(&groups).Error; err != nil {
-		log.Printf("Error fetching groups: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	if !canViewGroup(db, claims, group.ID) {
+		logf(r, "User %s (role: %s) tried to view group %d without permission",
+			claims.Email, claims.Role, group.ID)
+		httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Insufficient permissions")
+		return
+	}
+
+	studentsLimit, _ := strconv.Atoi(r.URL.Query().Get("students_limit"))
+	if studentsLimit < 1 || studentsLimit > maxGroupStudents {
+		studentsLimit = maxGroupStudents
+	}
+
+	// Число студентов и их последнее изменение входят в ETag вместе с версией группы и куратором
+	studentsVersion, err := queryRowsVersion(db.Model(&models.Student{}).Where("group_id = ?", group.ID), "students")
+	if err != nil {
+		logf(r, "Error counting group students: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
+	studentCount := studentsVersion.Count
 
-	totalPages := (int(totalItems) + limit - 1) / limit
-	remainingCount := int(totalItems) - (page * limit)
-	if remainingCount < 0 {
-		remainingCount = 0
+	etag := versionETag(r, group.Version, group.UpdatedAt.Time().UnixNano(), studentsVersion, curatorETagPart(group.Curator))
+	if checkNotModified(w, r, etag) {
+		return
 	}
 
-	response := models.PaginatedResponse{
-		Meta: models.Meta{
-			TotalItems:     int(totalItems),
-			TotalPages:     totalPages,
-			CurrentPage:    page,
-			PerPage:        limit,
-			RemainingCount: remainingCount,
-		},
-		Items: groups,
+	students := []groupStudentSummary{}
+	if err := db.Model(&models.Student{}).
+		Select("id", "name", "surname").
+		Where("group_id = ?", group.ID).
+		Order("surname ASC, name ASC").
+		Limit(studentsLimit).
+		Find(&students).Error; err != nil {
+		logf(r, "Error fetching group students: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	response := struct {
+		models.Group
+		Students     []groupStudentSummary `json:"students"`
+		StudentCount int64                 `json:"student_count"`
+	}{
+		Group:        group,
+		Students:     students,
+		StudentCount: studentCount,
 	}
+
+	httputil.RespondJSON(w, http.StatusOK, response)
 }
 
-func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+// curatorETagPart описывает куратора для ETag карточки группы: его изменения
+// не отражаются в updated_at группы
+func curatorETagPart(curator *models.GroupCurator) string {
+	if curator == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%s:%s", curator.ID, curator.Name, curator.Surname)
+}
+
+// GetGroupStudents возвращает студентов группы с пагинацией, фильтрами и сортировкой
+// как у общего списка студентов. Несуществующая группа возвращает 404
+func (h *GroupHandler) GetGroupStudents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if !authorize(w, r, models.ActionRead, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf("User %s (role: %s) tried to create group without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+	var group models.Group
+	if err := db.First(&group, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "Group with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
+			return
+		}
+		logf(r, "Error fetching group: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	if !canViewGroup(db, claims, group.ID) {
+		logf(r, "User %s (role: %s) tried to list students of group %d without permission",
+			claims.Email, claims.Role, group.ID)
+		httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Insufficient permissions")
 		return
 	}
 
-	var createReq struct {
-		Name string `json:"name"`
-		Code string `json:"code"`
+	query, err := buildStudentQuery(db, r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
 	}
+	writeStudentPage(w, r, h.cfg, query.Where("students.group_id = ?", group.ID))
+}
+
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionCreate, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var createReq GroupRequest
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v", err)
-		http.Error(w, `{"error": "Cannot read request body"}`, http.StatusBadRequest)
+		logf(r, "Error reading request body: %v", err)
+		respondBodyError(w, err, "Cannot read request body")
 		return
 	}
 
-	log.Printf("Request body: %s", string(body))
+	logRequestBody(r, h.cfg.LogRequestBodies, body, "name", "code", "year", "semester", "curator_id")
 
 	if err := json.Unmarshal(body, &createReq); err != nil {
-		log.Printf("Error decoding JSON: %v", err)
-		http.Error(w, `{"error": "Invalid JSON format"}`, http.StatusBadRequest)
+		logf(r, "Error decoding JSON: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeInvalidBody, "Invalid JSON format")
+		return
+	}
+
+	if !validateRequest(w, r, &createReq) {
 		return
 	}
 
-	log.Printf("Creating group: Name='%s', Code='%s'", createReq.Name, createReq.Code)
+	createReq.Code = normalizeGroupCode(createReq.Code)
+	logf(r, "Creating group: Name='%s', Code='%s'", createReq.Name, createReq.Code)
 
 	if createReq.Name == "" || createReq.Code == "" {
-		log.Printf("Validation failed: Name and Code are required")
-		http.Error(w, `{"error": "Name and code are required"}`, http.StatusBadRequest)
+		logf(r, "Validation failed: Name and Code are required")
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Name and code are required")
+		return
+	}
+
+	if !h.validateCode(w, createReq.Code) {
+		return
+	}
+
+	if !validateGroupPeriod(w, createReq.Year, createReq.Semester) {
 		return
 	}
 
 	var existingGroup models.Group
-	if err := h.db.Where("code = ?", createReq.Code).First(&existingGroup).Error; err == nil {
-		log.Printf("Group with code %s already exists", createReq.Code)
-		http.Error(w, `{"error": "Group with this code already exists"}`, http.StatusConflict)
+	if err := db.Where("code = ? AND year = ? AND semester = ?", createReq.Code, createReq.Year, createReq.Semester).
+		First(&existingGroup).Error; err == nil {
+		logf(r, "Group %s for %d/%d already exists", createReq.Code, createReq.Year, createReq.Semester)
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Group with this code already exists for this year and semester")
+		return
+	}
+
+	if !validateCurator(db, w, createReq.CuratorID) {
 		return
 	}
 
 	group := models.Group{
-		Name: createReq.Name,
-		Code: createReq.Code,
+		Name:      createReq.Name,
+		Code:      createReq.Code,
+		Year:      createReq.Year,
+		Semester:  createReq.Semester,
+		CuratorID: createReq.CuratorID,
 	}
 
-	result := h.db.Create(&group)
+	result := db.Create(&group)
 	if result.Error != nil {
-		log.Printf("Database error creating group: %v", result.Error)
-		http.Error(w, `{"error": "Failed to create group in database"}`, http.StatusInternalServerError)
+		logf(r, "Database error creating group: %v", result.Error)
+		respondDBError(w, result.Error, "Failed to create group in database")
 		return
 	}
 
-	log.Printf("Group created successfully with ID: %d", group.ID)
+	logf(r, "Group created successfully with ID: %d", group.ID)
+	recordAudit(h.db, claims, models.AuditActionCreate, models.AuditEntityGroup, group.ID, group.Code)
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(group); err != nil {
-		log.Printf("Error encoding response: %v", err)
-	}
+	db.Preload("Curator").First(&group, group.ID)
+
+	httputil.RespondJSON(w, http.StatusCreated, group)
 }
 
 func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionUpdate, models.ResourceGroups) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf("User %s (role: %s) tried to update group without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
-		return
-	}
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
 
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf("Error converting id to int: %v", err)
-		http.Error(w, `{"error": "Invalid group ID"}`, http.StatusBadRequest)
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
 		return
 	}
 
-	log.Printf("Updating group with ID: %d (by admin %s)", id, claims.Email)
+	logf(r, "Updating group with ID: %d (by admin %s)", id, claims.Email)
 
-	var updateReq struct {
-		Name string `json:"name"`
-		Code string `json:"code"`
-	}
+	var updateReq GroupRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		log.Printf("Error decoding request body: %v", err)
-		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+	if !decodeRequest(w, r, &updateReq) {
 		return
 	}
 
-	log.Printf("Update data - Name: '%s', Code: '%s'", updateReq.Name, updateReq.Code)
+	updateReq.Code = normalizeGroupCode(updateReq.Code)
+	logf(r, "Update data - Name: '%s', Code: '%s'", updateReq.Name, updateReq.Code)
 
 	if updateReq.Name == "" || updateReq.Code == "" {
-		log.Printf("Validation failed: Name and Code are required")
-		http.Error(w, `{"error": "Name and code are required"}`, http.StatusBadRequest)
+		logf(r, "Validation failed: Name and Code are required")
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Name and code are required")
 		return
 	}
 
-	var existingGroup models.Group
-	result := h.db.First(&existingGroup, id)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf("Group with ID %d not found", id)
-			http.Error(w, `{"error": "Group not found"}`, http.StatusNotFound)
+	if !h.validateCode(w, updateReq.Code) {
+		return
+	}
+
+	if !requireVersion(w, updateReq.Version) {
+		return
+	}
+
+	existingGroup, ok := findGroup(w, r, db, id)
+	if !ok {
+		return
+	}
+
+	if !checkGroupChange(w, r, db, existingGroup, updateReq) {
+		return
+	}
+
+	err = updateVersioned(db, existingGroup, updateReq.Version, map[string]interface{}{
+		"name":       updateReq.Name,
+		"code":       updateReq.Code,
+		"year":       updateReq.Year,
+		"semester":   updateReq.Semester,
+		"curator_id": updateReq.CuratorID,
+	})
+	if err != nil {
+		if respondVersionConflict(w, err) {
+			logf(r, "Stale version %d for group %d", updateReq.Version, id)
 			return
 		}
-		log.Printf("Error checking group existence: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, "Error updating group in database: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
-	if updateReq.Code != existingGroup.Code {
-		var groupWithSameCode models.Group
-		if err := h.db.Where("code = ? AND id != ?", updateReq.Code, id).First(&groupWithSameCode).Error; err == nil {
-			log.Printf("Code %s already used by another group", updateReq.Code)
-			http.Error(w, `{"error": "Code already in use by another group"}`, http.StatusConflict)
+	logf(r, "Group updated successfully")
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityGroup, existingGroup.ID, existingGroup.Code)
+
+	var updatedGroup models.Group
+	db.Preload("Curator").First(&updatedGroup, id)
+
+	httputil.RespondJSON(w, http.StatusOK, updatedGroup)
+}
+
+// PatchGroup частично обновляет группу: меняются только переданные поля.
+// Проверки те же, что у PUT, и выполняются над группой с примененными изменениями
+func (h *GroupHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
+		return
+	}
+
+	var patchReq PatchGroupRequest
+	if !decodeRequest(w, r, &patchReq) {
+		return
+	}
+
+	if !requireVersion(w, patchReq.Version) {
+		return
+	}
+
+	existingGroup, ok := findGroup(w, r, db, id)
+	if !ok {
+		return
+	}
+
+	// Итоговое состояние группы: текущие значения с переданными изменениями
+	merged := GroupRequest{
+		Name:      existingGroup.Name,
+		Code:      existingGroup.Code,
+		Year:      existingGroup.Year,
+		Semester:  existingGroup.Semester,
+		CuratorID: existingGroup.CuratorID,
+	}
+	updates := map[string]interface{}{}
+	if patchReq.Name != nil {
+		merged.Name = *patchReq.Name
+		updates["name"] = merged.Name
+	}
+	if patchReq.Code != nil {
+		merged.Code = normalizeGroupCode(*patchReq.Code)
+		if !h.validateCode(w, merged.Code) {
 			return
 		}
+		updates["code"] = merged.Code
+	}
+	if patchReq.Year != nil {
+		merged.Year = *patchReq.Year
+		updates["year"] = merged.Year
+	}
+	if patchReq.Semester != nil {
+		merged.Semester = *patchReq.Semester
+		updates["semester"] = merged.Semester
+	}
+	if patchReq.CuratorID != nil {
+		merged.CuratorID = patchReq.CuratorID
+		updates["curator_id"] = merged.CuratorID
 	}
 
-	existingGroup.Name = updateReq.Name
-	existingGroup.Code = updateReq.Code
+	if !checkGroupChange(w, r, db, existingGroup, merged) {
+		return
+	}
 
-	result = h.db.Save(&existingGroup)
-	if result.Error != nil {
-		log.Printf("Error updating group in database: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	if err := updateVersioned(db, existingGroup, patchReq.Version, updates); err != nil {
+		if respondVersionConflict(w, err) {
+			logf(r, "Stale version %d for group %d", patchReq.Version, id)
+			return
+		}
+		logf(r, "Error patching group: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
-	log.Printf("Group updated successfully. Rows affected: %d", result.RowsAffected)
+	logf(r, "Group %d patched (fields: %d) by admin %s", existingGroup.ID, len(updates), claims.Email)
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityGroup, existingGroup.ID,
+		fmt.Sprintf("patched fields: %d", len(updates)))
 
 	var updatedGroup models.Group
-	h.db.First(&updatedGroup, id)
+	db.Preload("Curator").First(&updatedGroup, id)
+
+	httputil.RespondJSON(w, http.StatusOK, updatedGroup)
+}
+
+// findGroup загружает группу по id, отвечая 404, если ее нет
+func findGroup(w http.ResponseWriter, r *http.Request, db *gorm.DB, id int) (*models.Group, bool) {
+	var group models.Group
+	if err := db.First(&group, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logf(r, "Group with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
+			return nil, false
+		}
+		logf(r, "Error checking group existence: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return nil, false
+	}
+	return &group, true
+}
+
+// checkGroupChange проверяет итоговое состояние изменяемой группы: период обучения,
+// уникальность кода в пределах года и семестра и существование куратора
+func checkGroupChange(w http.ResponseWriter, r *http.Request, db *gorm.DB, existing *models.Group, next GroupRequest) bool {
+	if !validateGroupPeriod(w, next.Year, next.Semester) {
+		return false
+	}
 
-	if err := json.NewEncoder(w).Encode(updatedGroup); err != nil {
-		log.Printf("Error encoding response: %v", err)
+	if next.Code != existing.Code || next.Year != existing.Year || next.Semester != existing.Semester {
+		var groupWithSameCode models.Group
+		if err := db.Where("code = ? AND year = ? AND semester = ? AND id != ?", next.Code, next.Year, next.Semester, existing.ID).
+			First(&groupWithSameCode).Error; err == nil {
+			logf(r, "Code %s for %d/%d already used by another group", next.Code, next.Year, next.Semester)
+			httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Code already in use by another group for this year and semester")
+			return false
+		}
 	}
+
+	return validateCurator(db, w, next.CuratorID)
 }
 
 func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionDelete, models.ResourceGroups) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf("User %s (role: %s) tried to delete group without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
-		return
-	}
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
 
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf("Error converting id to int: %v", err)
-		http.Error(w, `{"error": "Invalid group ID"}`, http.StatusBadRequest)
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
 		return
 	}
 
-	log.Printf("Deleting group with ID: %d (by admin %s)", id, claims.Email)
+	logf(r, "Deleting group with ID: %d (by admin %s)", id, claims.Email)
 
 	var group models.Group
-	result := h.db.First(&group, id)
+	result := db.First(&group, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf("Group with ID %d not found", id)
-			http.Error(w, `{"error": "Group not found"}`, http.StatusNotFound)
+			logf(r, "Group with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
 			return
 		}
-		log.Printf("Error checking group existence: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, "Error checking group existence: %v", result.Error)
+		respondDBError(w, result.Error, "Internal server error")
 		return
 	}
 
-	result = h.db.Delete(&group)
+	result = db.Delete(&group)
 	if result.Error != nil {
-		log.Printf("Error deleting group: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, "Error deleting group: %v", result.Error)
+		respondDBError(w, result.Error, "Internal server error")
 		return
 	}
 
-	log.Printf("Group deleted successfully. Rows affected: %d", result.RowsAffected)
+	logf(r, "Group deleted successfully. Rows affected: %d", result.RowsAffected)
+	recordAudit(h.db, claims, models.AuditActionDelete, models.AuditEntityGroup, group.ID, group.Code)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *GroupHandler) GetAllGroups(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionRead, models.ResourceGroups) {
 		return
 	}
 
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	var groups []models.Group
-	if err := h.db.Order("name ASC").Find(&groups).Error; err != nil {
-		log.Printf("❌ Error fetching all groups: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	if err := db.Where("archived = ?", false).Order("name ASC").Find(&groups).Error; err != nil {
+		logf(r, "❌ Error fetching all groups: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, groups)
+}
+
+// groupStatsItem - заполненность группы
+type groupStatsItem struct {
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Code         string `json:"code"`
+	StudentCount int64  `json:"student_count"`
+}
+
+// groupStatsSortFields - поля сортировки статистики групп
+var groupStatsSortFields = map[string]string{
+	"id":            "groups.id",
+	"name":          "groups.name",
+	"code":          "groups.code",
+	"student_count": "student_count",
+}
+
+// GetGroupStats возвращает число студентов в каждой группе одним запросом с GROUP BY,
+// группы без студентов попадают в ответ с нулем (только для админа)
+func (h *GroupHandler) GetGroupStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceGroupStats) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	query := db.Model(&models.Group{}).
+		Select("groups.id, groups.name, groups.code, COUNT(students.id) AS student_count").
+		Joins("LEFT JOIN students ON students.group_id = groups.id AND students.deleted_at IS NULL").
+		Group("groups.id, groups.name, groups.code")
+
+	query, ok := applyArchivedFilter(query, r.URL.Query().Get("archived"))
+	if !ok {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid archived value, expected true, false or all")
+		return
+	}
+
+	sortBy := r.URL.Query().Get("sortBy")
+	query, ok = applySort(query, sortBy, groupStatsSortFields)
+	if !ok {
+		logf(r, "Invalid sort field for group stats: %s", sortBy)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid sort field")
+		return
+	}
+
+	stats := []groupStatsItem{}
+	if err := query.Scan(&stats).Error; err != nil {
+		logf(r, "❌ Error fetching group stats: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, stats)
+}
+
+// Причины, по которым студент пропущен при переводе
+const (
+	skipReasonNotFound        = "not_found"
+	skipReasonAlreadyInTarget = "already_in_target"
+	skipReasonNotInSource     = "not_in_source"
+)
+
+// transferSkip - студент, которого не удалось перевести
+type transferSkip struct {
+	StudentID uint   `json:"student_id"`
+	Reason    string `json:"reason"`
+}
+
+// TransferStudents переводит всех студентов группы {id} в группу target_group_id
+// (только для админа): POST /api/groups/{id}/transfer
+func (h *GroupHandler) TransferStudents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
+		return
+	}
+
+	var transferReq TransferStudentsRequest
+	if !decodeRequest(w, r, &transferReq) {
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(groups); err != nil {
-		log.Printf("❌ Error encoding response: %v", err)
+	h.moveStudents(w, r, db, uint(id), transferReq.TargetGroupID, nil)
+}
+
+// MoveStudents переводит в группу {id} перечисленных студентов или всех студентов
+// группы from_group_id (только для админа): POST /api/groups/{id}/students
+func (h *GroupHandler) MoveStudents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
+		return
 	}
+
+	var moveReq MoveStudentsRequest
+	if !decodeRequest(w, r, &moveReq) {
+		return
+	}
+
+	if moveReq.FromGroupID == 0 && len(moveReq.StudentIDs) == 0 {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "One of from_group_id or student_ids is required")
+		return
+	}
+
+	h.moveStudents(w, r, db, moveReq.FromGroupID, uint(id), moveReq.StudentIDs)
+}
+
+// moveStudents переводит студентов в группу targetID в одной транзакции и пишет ответ.
+// sourceID (0 - любая группа) ограничивает перевод студентами этой группы, а пустой
+// studentIDs означает всех ее студентов. Каждый студент проверяется отдельно:
+// пропущенные попадают в ответ с причиной, у переведенных растет версия
+func (h *GroupHandler) moveStudents(w http.ResponseWriter, r *http.Request, db *gorm.DB, sourceID, targetID uint, studentIDs []uint) {
+	claims := middleware.GetUserClaims(r.Context())
+
+	if sourceID == targetID {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Source and target groups must differ")
+		return
+	}
+
+	groupIDs := []uint{targetID}
+	if sourceID != 0 {
+		groupIDs = append(groupIDs, sourceID)
+	}
+	for _, groupID := range groupIDs {
+		var group models.Group
+		if err := db.First(&group, groupID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				logf(r, "Group with ID %d not found", groupID)
+				httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
+				return
+			}
+			logf(r, "Error checking group existence: %v", err)
+			respondDBError(w, err, "Internal server error")
+			return
+		}
+		if group.ID == targetID && group.Archived {
+			logf(r, "Refusing to transfer students into archived group %d", group.ID)
+			httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Cannot assign students to an archived group")
+			return
+		}
+	}
+
+	var moved int64
+	skipped := []transferSkip{}
+
+	err := database.WithTx(db, func(tx *gorm.DB) error {
+		query := tx.Select("id", "group_id")
+		if len(studentIDs) > 0 {
+			query = query.Where("id IN ?", studentIDs)
+		} else {
+			query = query.Where("group_id = ?", sourceID)
+		}
+
+		var students []models.Student
+		if err := query.Order("id").Find(&students).Error; err != nil {
+			return err
+		}
+
+		requested := studentIDs
+		if len(requested) == 0 {
+			for _, student := range students {
+				requested = append(requested, student.ID)
+			}
+		}
+
+		found := make(map[uint]models.Student, len(students))
+		for _, student := range students {
+			found[student.ID] = student
+		}
+
+		var moveIDs []uint
+		var moveStudents []models.Student
+		for _, studentID := range requested {
+			student, ok := found[studentID]
+			switch {
+			case !ok:
+				skipped = append(skipped, transferSkip{StudentID: studentID, Reason: skipReasonNotFound})
+			case sameGroup(student.GroupID, &targetID):
+				skipped = append(skipped, transferSkip{StudentID: studentID, Reason: skipReasonAlreadyInTarget})
+			case sourceID != 0 && !sameGroup(student.GroupID, &sourceID):
+				skipped = append(skipped, transferSkip{StudentID: studentID, Reason: skipReasonNotInSource})
+			default:
+				moveIDs = append(moveIDs, studentID)
+				moveStudents = append(moveStudents, student)
+			}
+		}
+
+		if len(moveIDs) == 0 {
+			return nil
+		}
+
+		if err := recordGroupChanges(tx, claims, moveStudents, targetID); err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Student{}).Where("id IN ?", moveIDs).
+			Updates(map[string]interface{}{"group_id": targetID, "version": versionBump})
+		moved = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		logf(r, "Error transferring students: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, "Transferred %d students to group %d, skipped %d (by admin %s)",
+		moved, targetID, len(skipped), claims.Email)
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityGroup, targetID,
+		fmt.Sprintf("transferred %d students into group", moved))
+
+	response := map[string]interface{}{
+		"target_group_id": targetID,
+		"moved":           moved,
+		"skipped":         skipped,
+	}
+	if sourceID != 0 {
+		response["source_group_id"] = sourceID
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, response)
+}
+
+// ArchiveGroup переносит группу в архив. Группа со студентами архивируется
+// только с подтверждением ?confirm=true
+func (h *GroupHandler) ArchiveGroup(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// UnarchiveGroup возвращает группу из архива
+func (h *GroupHandler) UnarchiveGroup(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *GroupHandler) setArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceGroups) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid group ID")
+		return
+	}
+
+	var group models.Group
+	if err := db.First(&group, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "Group with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Group not found")
+			return
+		}
+		logf(r, "Error fetching group: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	if archived && !group.Archived && r.URL.Query().Get("confirm") != "true" {
+		var studentCount int64
+		if err := db.Model(&models.Student{}).Where("group_id = ?", group.ID).Count(&studentCount).Error; err != nil {
+			logf(r, "Error counting group students: %v", err)
+			respondDBError(w, err, "Internal server error")
+			return
+		}
+		if studentCount > 0 {
+			logf(r, "Archiving group %d with %d students requires confirmation", group.ID, studentCount)
+			httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict,
+				"Group has active students, repeat with ?confirm=true to archive it",
+				map[string]interface{}{"student_count": studentCount})
+			return
+		}
+	}
+
+	if err := db.Model(&group).Updates(map[string]interface{}{"archived": archived, "version": versionBump}).Error; err != nil {
+		logf(r, "Error updating group archive state: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	detail := "unarchived"
+	if archived {
+		detail = "archived"
+	}
+	logf(r, "Group %d %s (by admin %s)", group.ID, detail, claims.Email)
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityGroup, group.ID, detail)
+
+	// Перечитываем группу: версия увеличена выражением в базе
+	var updatedGroup models.Group
+	db.Preload("Curator").First(&updatedGroup, group.ID)
+
+	httputil.RespondJSON(w, http.StatusOK, updatedGroup)
 }
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"student-backend/models"
+	"testing"
+)
+
+// transferResponse - ответ перевода студентов между группами
+type transferResponse struct {
+	SourceGroupID uint           `json:"source_group_id"`
+	TargetGroupID uint           `json:"target_group_id"`
+	Moved         int64          `json:"moved"`
+	Skipped       []transferSkip `json:"skipped"`
+}
+
+func groupVars(id uint) map[string]string {
+	return map[string]string{"id": strconv.Itoa(int(id))}
+}
+
+func TestTransferStudentsMovesWholeGroup(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewGroupHandler(env.db, env.cfg)
+	source := createGroup(t, env.db, "A-1")
+	target := createGroup(t, env.db, "B-1")
+	first := createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", &source.ID)
+	second := createStudent(t, env.db, "Boris", "Ivanov", "boris@example.com", &source.ID)
+
+	w := serve(t, h.TransferStudents, request{
+		method: http.MethodPost,
+		target: "/api/groups/1/transfer",
+		body:   map[string]interface{}{"target_group_id": target.ID},
+		claims: adminClaims(),
+		vars:   groupVars(source.ID),
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var response transferResponse
+	decodeBody(t, w, &response)
+	if response.Moved != 2 || len(response.Skipped) != 0 || response.SourceGroupID != source.ID {
+		t.Errorf("response = %+v, want 2 moved from group %d and none skipped", response, source.ID)
+	}
+
+	for _, student := range []*models.Student{first, second} {
+		var stored models.Student
+		env.db.First(&stored, student.ID)
+		if stored.GroupID == nil || *stored.GroupID != target.ID {
+			t.Errorf("student %d: group_id = %v, want %d", student.ID, stored.GroupID, target.ID)
+		}
+		if stored.Version != student.Version+1 {
+			t.Errorf("student %d: version = %d, want %d", student.ID, stored.Version, student.Version+1)
+		}
+	}
+
+	var remaining int64
+	env.db.Model(&models.Student{}).Where("group_id = ?", source.ID).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("source group still has %d students", remaining)
+	}
+}
+
+func TestTransferStudentsValidatesGroups(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewGroupHandler(env.db, env.cfg)
+	source := createGroup(t, env.db, "A-1")
+
+	tests := []struct {
+		name   string
+		target uint
+		status int
+	}{
+		{"same group", source.ID, http.StatusBadRequest},
+		{"missing target", 999, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.TransferStudents, request{
+				method: http.MethodPost,
+				target: "/api/groups/1/transfer",
+				body:   map[string]interface{}{"target_group_id": tt.target},
+				claims: adminClaims(),
+				vars:   groupVars(source.ID),
+			})
+			expectStatus(t, w, tt.status)
+		})
+	}
+}
+
+func TestMoveStudentsPartialTransfer(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewGroupHandler(env.db, env.cfg)
+	source := createGroup(t, env.db, "A-1")
+	target := createGroup(t, env.db, "B-1")
+	other := createGroup(t, env.db, "C-1")
+	movable := createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", &source.ID)
+	alreadyThere := createStudent(t, env.db, "Boris", "Ivanov", "boris@example.com", &target.ID)
+	elsewhere := createStudent(t, env.db, "Vera", "Orlova", "vera@example.com", &other.ID)
+
+	tests := []struct {
+		name    string
+		body    map[string]interface{}
+		moved   int64
+		skipped map[uint]string
+	}{
+		{
+			name:  "listed students",
+			body:  map[string]interface{}{"student_ids": []uint{movable.ID, alreadyThere.ID, 999}},
+			moved: 1,
+			skipped: map[uint]string{
+				alreadyThere.ID: skipReasonAlreadyInTarget,
+				999:             skipReasonNotFound,
+			},
+		},
+		{
+			name:    "listed students of a source group",
+			body:    map[string]interface{}{"from_group_id": source.ID, "student_ids": []uint{elsewhere.ID}},
+			skipped: map[uint]string{elsewhere.ID: skipReasonNotInSource},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.MoveStudents, request{
+				method: http.MethodPost,
+				target: "/api/groups/2/students",
+				body:   tt.body,
+				claims: adminClaims(),
+				vars:   groupVars(target.ID),
+			})
+			expectStatus(t, w, http.StatusOK)
+
+			var response transferResponse
+			decodeBody(t, w, &response)
+			if response.Moved != tt.moved {
+				t.Errorf("moved = %d, want %d", response.Moved, tt.moved)
+			}
+			if len(response.Skipped) != len(tt.skipped) {
+				t.Fatalf("skipped = %+v, want %v", response.Skipped, tt.skipped)
+			}
+			for _, skip := range response.Skipped {
+				if tt.skipped[skip.StudentID] != skip.Reason {
+					t.Errorf("student %d skipped as %q, want %q", skip.StudentID, skip.Reason, tt.skipped[skip.StudentID])
+				}
+			}
+		})
+	}
+
+	var stored models.Student
+	env.db.First(&stored, movable.ID)
+	if stored.GroupID == nil || *stored.GroupID != target.ID {
+		t.Errorf("moved student group_id = %v, want %d", stored.GroupID, target.ID)
+	}
+	var untouched models.Student
+	env.db.First(&untouched, elsewhere.ID)
+	if untouched.GroupID == nil || *untouched.GroupID != other.ID {
+		t.Errorf("skipped student group_id = %v, want %d", untouched.GroupID, other.ID)
+	}
+}
+
+func TestMoveStudentsRequiresSource(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewGroupHandler(env.db, env.cfg)
+	target := createGroup(t, env.db, "B-1")
+
+	w := serve(t, h.MoveStudents, request{
+		method: http.MethodPost,
+		target: "/api/groups/1/students",
+		body:   map[string]interface{}{},
+		claims: adminClaims(),
+		vars:   groupVars(target.ID),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestGetGroupStatsCountsActiveStudents(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewGroupHandler(env.db, env.cfg)
+	full := createGroup(t, env.db, "A-1")
+	empty := createGroup(t, env.db, "B-1")
+	createStudent(t, env.db, "Anna", "Orlova", "", &full.ID)
+	createStudent(t, env.db, "Boris", "Antonov", "", &full.ID)
+	expelled := createStudent(t, env.db, "Clara", "Zueva", "", &full.ID)
+	if err := env.db.Delete(expelled).Error; err != nil {
+		t.Fatalf("delete student: %v", err)
+	}
+
+	w := serve(t, h.GetGroupStats, request{method: http.MethodGet, target: "/api/groups/stats?sortBy=code", claims: adminClaims()})
+	expectStatus(t, w, http.StatusOK)
+	var stats []groupStatsItem
+	decodeBody(t, w, &stats)
+
+	want := []groupStatsItem{
+		{ID: full.ID, Name: full.Name, Code: "A-1", StudentCount: 2},
+		{ID: empty.ID, Name: empty.Name, Code: "B-1", StudentCount: 0},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+	// Пустая группа отдает явный ноль, а не пропущенное поле
+	if !strings.Contains(w.Body.String(), `"code":"B-1","student_count":0`) {
+		t.Fatalf("empty group has no explicit student_count: %s", w.Body.String())
+	}
+}
+
+// setGroupArchived вызывает archive или unarchive для группы от имени администратора
+func setGroupArchived(t *testing.T, env *testEnv, group *models.Group, archived bool, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	h := NewGroupHandler(env.db, env.cfg)
+	handler, action := h.UnarchiveGroup, "unarchive"
+	if archived {
+		handler, action = h.ArchiveGroup, "archive"
+	}
+	return serve(t, handler, request{
+		method: http.MethodPost, target: "/api/groups/" + strconv.Itoa(int(group.ID)) + "/" + action + query,
+		claims: adminClaims(), vars: groupVars(group.ID),
+	})
+}
+
+// listGroupCodes возвращает коды групп из GetGroups с параметрами query
+func listGroupCodes(t *testing.T, env *testEnv, query string) []string {
+	t.Helper()
+	w := serve(t, NewGroupHandler(env.db, env.cfg).GetGroups, request{
+		method: http.MethodGet, target: "/api/groups?sortBy=code&" + query, claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusOK)
+	var page struct {
+		Items []models.Group `json:"items"`
+	}
+	decodeBody(t, w, &page)
+	codes := make([]string, len(page.Items))
+	for i, group := range page.Items {
+		codes[i] = group.Code
+	}
+	return codes
+}
+
+func TestArchiveGroupReturnsBumpedVersion(t *testing.T) {
+	env := newTestEnv(t)
+	group := createGroup(t, env.db, "A-1")
+
+	for i, archived := range []bool{true, false} {
+		w := setGroupArchived(t, env, group, archived, "")
+		expectStatus(t, w, http.StatusOK)
+		var response models.Group
+		decodeBody(t, w, &response)
+
+		var stored models.Group
+		env.db.First(&stored, group.ID)
+		if response.Archived != archived || stored.Archived != archived {
+			t.Fatalf("archived = %t in response, %t stored; want %t", response.Archived, stored.Archived, archived)
+		}
+		if want := group.Version + i + 1; response.Version != want || stored.Version != want {
+			t.Fatalf("version = %d in response, %d stored; want %d", response.Version, stored.Version, want)
+		}
+	}
+}
+
+func TestArchiveGroupWithStudentsRequiresConfirm(t *testing.T) {
+	env := newTestEnv(t)
+	group := createGroup(t, env.db, "A-1")
+	createStudent(t, env.db, "Anna", "Orlova", "", &group.ID)
+
+	w := setGroupArchived(t, env, group, true, "")
+	expectStatus(t, w, http.StatusConflict)
+	if !strings.Contains(w.Body.String(), `"student_count":1`) {
+		t.Fatalf("conflict body = %s, want student_count 1", w.Body.String())
+	}
+	var stored models.Group
+	env.db.First(&stored, group.ID)
+	if stored.Archived || stored.Version != group.Version {
+		t.Fatalf("group changed without confirmation: %+v", stored)
+	}
+
+	expectStatus(t, setGroupArchived(t, env, group, true, "?confirm=true"), http.StatusOK)
+	env.db.First(&stored, group.ID)
+	if !stored.Archived {
+		t.Fatal("group is not archived with confirm=true")
+	}
+}
+
+func TestGetGroupsArchivedFilter(t *testing.T) {
+	env := newTestEnv(t)
+	createGroup(t, env.db, "A-1")
+	archived := createGroup(t, env.db, "B-1")
+	expectStatus(t, setGroupArchived(t, env, archived, true, ""), http.StatusOK)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"A-1"}},
+		{"archived=false", []string{"A-1"}},
+		{"archived=true", []string{"B-1"}},
+		{"archived=all", []string{"A-1", "B-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := listGroupCodes(t, env, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("%s returned %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	w := serve(t, NewGroupHandler(env.db, env.cfg).GetGroups, request{
+		method: http.MethodGet, target: "/api/groups?archived=yes", claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+}
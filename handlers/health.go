@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"student-backend/httputil"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// healthCheckTimeout ограничивает проверку одной зависимости, чтобы проверка
+// отвечала быстрее таймаута балансировщика
+const healthCheckTimeout = 2 * time.Second
+
+// Состояния проверок
+const (
+	healthStatusOK       = "ok"
+	healthStatusUp       = "up"
+	healthStatusDown     = "down"
+	healthStatusDegraded = "degraded"
+)
+
+type HealthHandler struct {
+	db *gorm.DB
+	// ready - сервер принимает трафик: миграции выполнены и остановка не началась
+	ready atomic.Bool
+}
+
+// SetReady переключает готовность к приему трафика
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// dependencyCheck - результат проверки одной зависимости
+type dependencyCheck struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Health проверяет сервис и его зависимости: 200, если все доступны, иначе 503
+// со статусом degraded. С ?live=true (и на /health/live) зависимости не проверяются:
+// режим для проверки живости процесса
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"status":    healthStatusOK,
+		"service":   "student-backend",
+		"orm":       "GORM",
+		"auth":      "JWT",
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+
+	if r.URL.Query().Get("live") == "true" || r.URL.Path == "/health/live" {
+		httputil.RespondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	database := h.checkDatabase(r.Context())
+	response["checks"] = map[string]dependencyCheck{"database": database}
+	if stats, ok := h.poolStats(); ok {
+		response["database_pool"] = stats
+	}
+
+	status := http.StatusOK
+	if database.Status != healthStatusUp {
+		logf(r, "❌ Health check failed: database %s", database.Error)
+		response["status"] = healthStatusDegraded
+		status = http.StatusServiceUnavailable
+	}
+
+	httputil.RespondJSON(w, status, response)
+}
+
+// Live - проверка живости для Kubernetes: процесс отвечает, зависимости не проверяются
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	httputil.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    healthStatusOK,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// Ready - проверка готовности для Kubernetes: 200, если сервер запущен, не останавливается
+// и база доступна, иначе 503. Балансировщик перестает слать запросы, пока ответ 503
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	lifecycle := dependencyCheck{Status: healthStatusUp}
+	if !h.ready.Load() {
+		lifecycle = dependencyCheck{Status: healthStatusDown, Error: "server is starting or shutting down"}
+	}
+	database := h.checkDatabase(r.Context())
+
+	status := http.StatusOK
+	overall := healthStatusOK
+	if lifecycle.Status != healthStatusUp || database.Status != healthStatusUp {
+		status = http.StatusServiceUnavailable
+		overall = healthStatusDegraded
+	}
+
+	httputil.RespondJSON(w, status, map[string]interface{}{
+		"status":    overall,
+		"timestamp": time.Now().Format(time.RFC3339),
+		"checks": map[string]dependencyCheck{
+			"lifecycle": lifecycle,
+			"database":  database,
+		},
+	})
+}
+
+// checkDatabase пингует базу с коротким таймаутом
+func (h *HealthHandler) checkDatabase(ctx context.Context) dependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.pingDatabase(ctx)
+	check := dependencyCheck{
+		Status:    healthStatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		check.Status = healthStatusDown
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// poolStatsResponse - состояние пула соединений с базой
+type poolStatsResponse struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMs     float64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+// poolStats возвращает текущую статистику пула соединений
+func (h *HealthHandler) poolStats() (poolStatsResponse, bool) {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return poolStatsResponse{}, false
+	}
+	stats := sqlDB.Stats()
+	return poolStatsResponse{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     float64(stats.WaitDuration.Microseconds()) / 1000,
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, true
+}
+
+func (h *HealthHandler) pingDatabase(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/events"
+	"student-backend/features"
+	"student-backend/middleware"
+	"student-backend/models"
+	"student-backend/testutil"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// testEnv - база, конфигурация, шина событий и сервис JWT для тестов обработчиков
+type testEnv struct {
+	db  *gorm.DB
+	cfg *config.Config
+	bus *events.Bus
+	jwt *auth.JWTService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	bus := events.NewBus()
+	t.Cleanup(bus.Close)
+	cfg := testutil.Config()
+	return &testEnv{
+		db:  testutil.NewDB(t),
+		cfg: cfg,
+		bus: bus,
+		jwt: auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiry, cfg.JWTRoleExpiry),
+	}
+}
+
+// sentMail - письмо, отправленное через recordingMailer
+type sentMail struct {
+	to, subject, body string
+}
+
+// recordingMailer запоминает письма вместо отправки
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []sentMail
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+// last возвращает последнее письмо на адрес to
+func (m *recordingMailer) last(t *testing.T, to string) sentMail {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if m.sent[i].to == to {
+			return m.sent[i]
+		}
+	}
+	t.Fatalf("no mail sent to %s", to)
+	return sentMail{}
+}
+
+// newAuthHandler создает AuthHandler с флагами по умолчанию и перехватом писем
+func (env *testEnv) newAuthHandler(t *testing.T) (*AuthHandler, *recordingMailer) {
+	t.Helper()
+	flags := features.New(env.db)
+	if err := flags.Load(); err != nil {
+		t.Fatalf("load feature flags: %v", err)
+	}
+	mail := &recordingMailer{}
+	return NewAuthHandler(env.db, env.jwt, env.cfg, mail, flags), mail
+}
+
+// authenticated пропускает handler через AuthMiddleware, как это делает защищенный роутер
+func (env *testEnv) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return middleware.NewAuthMiddleware(env.jwt, env.db, 0).AuthMiddleware(handler).ServeHTTP
+}
+
+// tokenFor выпускает JWT для user
+func (env *testEnv) tokenFor(t *testing.T, user *models.User) string {
+	t.Helper()
+	token, err := env.jwt.GenerateToken(user)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// bearer - заголовки запроса с токеном
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// createUser создает подтвержденную учетную запись с паролем password123
+func createUser(t *testing.T, db *gorm.DB, email string, role models.Role) *models.User {
+	t.Helper()
+	user := models.User{Email: email, Password: "password123", Role: role, EmailVerified: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user %s: %v", email, err)
+	}
+	return &user
+}
+
+// claimsOf возвращает claims, которые AuthMiddleware положил бы в контекст для user
+func claimsOf(user *models.User) *auth.JWTClaims {
+	return &auth.JWTClaims{UserID: user.ID, Email: user.Email, Role: user.Role}
+}
+
+// adminClaims - claims администратора, которого нет в базе
+func adminClaims() *auth.JWTClaims {
+	return &auth.JWTClaims{UserID: 1, Email: "admin@example.com", Role: models.RoleAdmin}
+}
+
+// createGroup создает группу с кодом code
+func createGroup(t *testing.T, db *gorm.DB, code string) *models.Group {
+	t.Helper()
+	group := models.Group{Name: "Group " + code, Code: code, Year: 2024, Semester: 1}
+	if err := db.Create(&group).Error; err != nil {
+		t.Fatalf("create group %s: %v", code, err)
+	}
+	return &group
+}
+
+// createStudent создает студента в группе groupID (nil - без группы)
+func createStudent(t *testing.T, db *gorm.DB, name, surname, email string, groupID *uint) *models.Student {
+	t.Helper()
+	student := models.Student{Name: name, Surname: surname, Email: email, GroupID: groupID}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("create student %s %s: %v", name, surname, err)
+	}
+	return &student
+}
+
+// request описывает вызов обработчика в тесте
+type request struct {
+	method string
+	target string
+	// body - строка передается как есть, остальное кодируется в JSON
+	body    interface{}
+	claims  *auth.JWTClaims
+	vars    map[string]string
+	headers map[string]string
+	// ctx заменяет контекст запроса, например уже отмененным
+	ctx context.Context
+}
+
+// serve вызывает обработчик напрямую, минуя роутер: переменные маршрута
+// и claims кладутся в запрос так же, как это делают mux и AuthMiddleware
+func serve(t *testing.T, handler http.HandlerFunc, req request) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	switch value := req.body.(type) {
+	case nil:
+	case string:
+		body.WriteString(value)
+	default:
+		if err := json.NewEncoder(&body).Encode(value); err != nil {
+			t.Fatalf("encode request body: %v", err)
+		}
+	}
+
+	r := httptest.NewRequest(req.method, req.target, &body)
+	if req.ctx != nil {
+		r = r.WithContext(req.ctx)
+	}
+	if req.body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range req.headers {
+		r.Header.Set(name, value)
+	}
+	if req.claims != nil {
+		r = r.WithContext(middleware.SetUserClaims(r.Context(), req.claims))
+	}
+	if req.vars != nil {
+		r = mux.SetURLVars(r, req.vars)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// decodeBody разбирает JSON-ответ в dst
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, dst interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), dst); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body.String(), err)
+	}
+}
+
+// expectStatus проверяет код ответа и выводит тело при несовпадении
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, status, w.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"student-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// errInvalidSortField - поле сортировки не входит в список допустимых
+var errInvalidSortField = errors.New("Invalid sort field")
+
+// ListOptions - параметры выборки страницы списка
+type ListOptions struct {
+	Page  int
+	Limit int
+	// SortBy - поле сортировки из запроса, "-" в начале означает убывание
+	SortBy string
+	// SortFields - допустимые поля сортировки и соответствующие им колонки.
+	// В ORDER BY попадают только колонки из этого списка
+	SortFields map[string]string
+	// Filters применяются и к подсчету, и к выборке страницы
+	Filters []func(*gorm.DB) *gorm.DB
+	// Select заменяет список колонок выборки, например для вычисляемых полей
+	Select string
+}
+
+// Page - страница списка с метаданными пагинации
+type Page[T any] struct {
+	Items []T
+	Meta  models.Meta
+}
+
+// Response возвращает страницу в формате ответа API
+func (p Page[T]) Response() models.PaginatedResponse {
+	return models.PaginatedResponse{Meta: p.Meta, Items: p.Items}
+}
+
+// Paginate считает записи запроса db с фильтрами, применяет сортировку и смещение
+// и возвращает страницу. model задает тип элементов и таблицу выборки.
+// Недопустимое поле сортировки возвращает errInvalidSortField до обращения к базе
+func Paginate[T any](db *gorm.DB, model *T, opts ListOptions) (Page[T], error) {
+	base := db.Model(model).Scopes(opts.Filters...).Session(&gorm.Session{})
+
+	sorted, ok := applySort(base, opts.SortBy, opts.SortFields)
+	if !ok {
+		return Page[T]{}, errInvalidSortField
+	}
+
+	var totalItems int64
+	if err := base.Count(&totalItems).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	if opts.Select != "" {
+		sorted = sorted.Select(opts.Select)
+	}
+
+	items := []T{}
+	page := clampPage(opts.Page, opts.Limit)
+	offset := (page - 1) * opts.Limit
+	if err := sorted.Offset(offset).Limit(opts.Limit).Find(&items).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	return Page[T]{Items: items, Meta: pageMeta(totalItems, page, opts.Limit)}, nil
+}
+
+// pageMeta считает метаданные страницы по общему числу записей.
+// RemainingCount - число записей после текущей страницы: на последней и на
+// страницах за ней это 0. Номер страницы сравнивается с totalPages до
+// умножения, поэтому большой page из запроса не переполняет page*limit
+func pageMeta(totalItems int64, page, limit int) models.Meta {
+	totalPages := (int(totalItems) + limit - 1) / limit
+	remainingCount := 0
+	if page < totalPages {
+		remainingCount = int(totalItems) - page*limit
+	}
+
+	return models.Meta{
+		TotalItems:     int(totalItems),
+		TotalPages:     totalPages,
+		CurrentPage:    page,
+		PerPage:        limit,
+		RemainingCount: remainingCount,
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"student-backend/models"
+	"testing"
+)
+
+func TestPageMeta(t *testing.T) {
+	tests := []struct {
+		name                        string
+		total                       int64
+		page, limit                 int
+		wantTotalPages, wantRemains int
+	}{
+		{"empty list", 0, 1, 10, 0, 0},
+		{"single partial page", 3, 1, 10, 1, 0},
+		{"first of several", 25, 1, 10, 3, 15},
+		{"middle page", 25, 2, 10, 3, 5},
+		{"last partial page", 25, 3, 10, 3, 0},
+		{"exact multiple, last page", 20, 2, 10, 2, 0},
+		{"exact multiple, first page", 20, 1, 10, 2, 10},
+		{"past the last page", 25, 7, 10, 3, 0},
+		{"huge page", 25, math.MaxInt, 100, 1, 0},
+		{"limit of one", 3, 2, 1, 3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := pageMeta(tt.total, tt.page, tt.limit)
+			if meta.TotalPages != tt.wantTotalPages || meta.RemainingCount != tt.wantRemains {
+				t.Fatalf("pageMeta(%d, %d, %d) = %d pages, %d remaining; want %d pages, %d remaining",
+					tt.total, tt.page, tt.limit, meta.TotalPages, meta.RemainingCount, tt.wantTotalPages, tt.wantRemains)
+			}
+			if meta.TotalItems != int(tt.total) || meta.CurrentPage != tt.page || meta.PerPage != tt.limit {
+				t.Fatalf("meta = %+v", meta)
+			}
+		})
+	}
+}
+
+func TestClampPage(t *testing.T) {
+	tests := []struct {
+		page, limit, want int
+	}{
+		{1, 20, 1},
+		{500, 20, 500},
+		{maxOffset/20 + 1, 20, maxOffset/20 + 1},
+		{maxOffset/20 + 2, 20, maxOffset/20 + 1},
+		{math.MaxInt, 100, maxOffset/100 + 1},
+		{math.MaxInt, 1, maxOffset + 1},
+	}
+	for _, tt := range tests {
+		got := clampPage(tt.page, tt.limit)
+		if got != tt.want {
+			t.Errorf("clampPage(%d, %d) = %d, want %d", tt.page, tt.limit, got, tt.want)
+		}
+		if offset := (got - 1) * tt.limit; offset < 0 || offset > maxOffset {
+			t.Errorf("clampPage(%d, %d): offset %d out of range", tt.page, tt.limit, offset)
+		}
+	}
+}
+
+func TestParsePaginationDefaultPageSize(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.DefaultPageSize = 7
+	env.cfg.MaxPageSize = 30
+
+	tests := []struct {
+		query      string
+		wantLimit  int
+		wantOffset int
+	}{
+		{"", 7, 0},
+		{"page=3", 7, 14},
+		{"limit=0", 7, 0},
+		{"limit=-5", 7, 0},
+		{"limit=abc", 7, 0},
+		{"limit=10&page=2", 10, 10},
+		{"limit=500", 30, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/students?"+tt.query, nil)
+			_, limit, offset := parsePagination(r, env.cfg)
+			if limit != tt.wantLimit || offset != tt.wantOffset {
+				t.Fatalf("limit %d, offset %d; want %d, %d", limit, offset, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+
+	// Размер страницы по умолчанию доходит до ответа списка
+	for i := 0; i < 10; i++ {
+		createStudent(t, env.db, "Name", fmt.Sprintf("Surname%d", i), "", nil)
+	}
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	if got := listStudents(t, h, "/api/students"); len(got) != 7 {
+		t.Fatalf("list without limit returned %d students, want 7", len(got))
+	}
+}
+
+func TestParsePaginationHugePage(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Значение вне диапазона int strconv.Atoi превращает в math.MaxInt
+	for _, page := range []string{strconv.Itoa(math.MaxInt), "99999999999999999999999", strconv.Itoa(math.MaxInt / 50)} {
+		r := httptest.NewRequest(http.MethodGet, "/api/students?limit=50&page="+page, nil)
+		_, limit, offset := parsePagination(r, env.cfg)
+		if limit != 50 || offset < 0 || offset > maxOffset {
+			t.Errorf("page=%s: limit %d, offset %d; want a non-negative offset up to %d", page, limit, offset, maxOffset)
+		}
+	}
+}
+
+func TestPaginateHugePage(t *testing.T) {
+	env := newTestEnv(t)
+	for i := 0; i < 3; i++ {
+		createStudent(t, env.db, "Anna", fmt.Sprintf("Smirnova%d", i), "", nil)
+	}
+
+	result, err := Paginate(env.db, &models.Student{}, ListOptions{Page: math.MaxInt, Limit: 100, SortFields: studentSortFields})
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if len(result.Items) != 0 || result.Meta.TotalItems != 3 || result.Meta.RemainingCount != 0 {
+		t.Fatalf("items %d, meta %+v; want an empty page of 3 items", len(result.Items), result.Meta)
+	}
+
+	// Через обработчик большой page дает пустую страницу, а не ошибку базы
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	w := serve(t, h.GetStudents, request{
+		method: http.MethodGet, target: "/api/students?page=" + strconv.Itoa(math.MaxInt), claims: adminClaims(),
+	})
+	expectStatus(t, w, http.StatusOK)
+	var response struct {
+		Items []models.Student `json:"items"`
+		Meta  models.Meta      `json:"meta"`
+	}
+	decodeBody(t, w, &response)
+	if len(response.Items) != 0 || response.Meta.TotalItems != 3 {
+		t.Fatalf("response = %+v", response)
+	}
+}
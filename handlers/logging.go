@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"student-backend/middleware"
+)
+
+// logf пишет в лог строку с идентификатором текущего запроса
+func logf(r *http.Request, format string, args ...interface{}) {
+	middleware.Logf(r.Context(), format, args...)
+}
+
+// logRequestBody пишет в лог тело JSON-запроса, только если включен LOG_REQUEST_BODIES.
+// Поля из visible выводятся как есть, email маскируется через maskEmail, значения
+// остальных полей скрываются. Для запросов аутентификации не вызывается: пароли,
+// коды и токены не должны попадать в лог даже в отладке
+func logRequestBody(r *http.Request, enabled bool, body []byte, visible ...string) {
+	if !enabled {
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		logf(r, "Request body: <not a JSON object, %d bytes>", len(body))
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+redactField(key, fields[key], visible))
+	}
+	logf(r, "Request body: %s", strings.Join(parts, " "))
+}
+
+// redactField возвращает значение поля для лога с учетом списка видимых полей
+func redactField(key string, value interface{}, visible []string) string {
+	for _, name := range visible {
+		if name == key {
+			encoded, _ := json.Marshal(value)
+			return string(encoded)
+		}
+	}
+	if email, ok := value.(string); ok && strings.Contains(strings.ToLower(key), "email") {
+		return maskEmail(email)
+	}
+	return "***"
+}
+
+// maskEmail скрывает адрес для лога, см. middleware.MaskEmail
+func maskEmail(email string) string {
+	return middleware.MaskEmail(email)
+}
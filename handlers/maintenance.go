@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/features"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+	"student-backend/settings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ключи настроек с сообщением и временем окончания обслуживания
+const (
+	settingMaintenanceMessage = "maintenance_message"
+	settingMaintenanceETA     = "maintenance_eta"
+)
+
+// SetMaintenanceRequest - тело переключения режима обслуживания.
+// message и eta учитываются только при включении
+type SetMaintenanceRequest struct {
+	Enabled *bool      `json:"enabled" validate:"required"`
+	Message string     `json:"message" validate:"max=500"`
+	ETA     *time.Time `json:"eta"`
+}
+
+// maintenanceState - состояние режима обслуживания в ответах API
+type maintenanceState struct {
+	Enabled bool `json:"enabled"`
+	middleware.MaintenanceNotice
+}
+
+type MaintenanceHandler struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	maintenance *middleware.Maintenance
+	flags       *features.Flags
+}
+
+func NewMaintenanceHandler(db *gorm.DB, cfg *config.Config, maintenance *middleware.Maintenance, flags *features.Flags) *MaintenanceHandler {
+	return &MaintenanceHandler{db: db, cfg: cfg, maintenance: maintenance, flags: flags}
+}
+
+// GetMaintenance возвращает состояние режима обслуживания (только для админа)
+func (h *MaintenanceHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceMaintenance) {
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, maintenanceState{
+		Enabled:           h.maintenance.Enabled(),
+		MaintenanceNotice: h.maintenance.Notice(),
+	})
+}
+
+// LoadNotice восстанавливает сообщение и время окончания обслуживания,
+// сохраненные до перезапуска. Само включение режима хранится во флагах
+func (h *MaintenanceHandler) LoadNotice() error {
+	values, err := settings.Get(h.db, settingMaintenanceMessage, settingMaintenanceETA)
+	if err != nil {
+		return err
+	}
+
+	notice := middleware.MaintenanceNotice{Message: values[settingMaintenanceMessage]}
+	if raw := values[settingMaintenanceETA]; raw != "" {
+		if eta, err := time.Parse(time.RFC3339, raw); err == nil {
+			notice.ETA = &eta
+		}
+	}
+	h.maintenance.SetNotice(notice)
+	return nil
+}
+
+// SetMaintenance включает или выключает режим обслуживания:
+// {"enabled": true, "message": "...", "eta": "2024-01-01T12:00:00Z"} (только для админа)
+func (h *MaintenanceHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceMaintenance) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var req SetMaintenanceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	// Выключение сбрасывает сообщение, чтобы оно не всплыло при следующем включении
+	var notice middleware.MaintenanceNotice
+	if *req.Enabled {
+		if req.ETA != nil && !req.ETA.After(time.Now()) {
+			httputil.RespondError(w, http.StatusUnprocessableEntity, httputil.CodeValidationFailed, "eta must be in the future")
+			return
+		}
+		notice = middleware.MaintenanceNotice{Message: req.Message, ETA: req.ETA}
+	}
+
+	eta := ""
+	if notice.ETA != nil {
+		eta = notice.ETA.UTC().Format(time.RFC3339)
+	}
+
+	// Через флаги и настройки, чтобы состояние сохранилось после перезапуска
+	err := database.WithTx(db, func(tx *gorm.DB) error {
+		if err := settings.Set(tx, map[string]string{
+			settingMaintenanceMessage: notice.Message,
+			settingMaintenanceETA:     eta,
+		}); err != nil {
+			return err
+		}
+		return h.flags.Set(tx, map[string]bool{features.MaintenanceMode: *req.Enabled})
+	})
+	if err != nil {
+		logf(r, "Error saving maintenance mode: %v", err)
+		respondDBError(w, err, "Failed to save maintenance mode")
+		return
+	}
+	h.maintenance.SetNotice(notice)
+	logf(r, "Maintenance mode set to %v by %s", *req.Enabled, claims.Email)
+
+	httputil.RespondJSON(w, http.StatusOK, maintenanceState{Enabled: *req.Enabled, MaintenanceNotice: notice})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Metrics отдает статистику пула соединений с базой в текстовом формате Prometheus
+func (h *HealthHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	stats, ok := h.poolStats()
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var b strings.Builder
+	metric := func(name, kind, help string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	metric("db_pool_max_open_connections", "gauge", "Maximum number of open connections to the database.", stats.MaxOpenConnections)
+	metric("db_pool_open_connections", "gauge", "Number of established connections, both in use and idle.", stats.OpenConnections)
+	metric("db_pool_in_use_connections", "gauge", "Number of connections currently in use.", stats.InUse)
+	metric("db_pool_idle_connections", "gauge", "Number of idle connections.", stats.Idle)
+	metric("db_pool_wait_count_total", "counter", "Total number of connections waited for.", stats.WaitCount)
+	metric("db_pool_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.", stats.WaitDurationMs/1000)
+	metric("db_pool_max_idle_closed_total", "counter", "Total number of connections closed due to SetMaxIdleConns.", stats.MaxIdleClosed)
+	metric("db_pool_max_idle_time_closed_total", "counter", "Total number of connections closed due to SetConnMaxIdleTime.", stats.MaxIdleTimeClosed)
+	metric("db_pool_max_lifetime_closed_total", "counter", "Total number of connections closed due to SetConnMaxLifetime.", stats.MaxLifetimeClosed)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
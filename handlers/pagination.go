@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"student-backend/config"
+	"student-backend/httputil"
+	"student-backend/models"
+)
+
+// maxOffset - наибольшее смещение выборки. Оно с запасом больше любого списка
+// и помещается в int на всех платформах
+const maxOffset = math.MaxInt32
+
+// clampPage ограничивает номер страницы так, чтобы смещение (page-1)*limit не
+// превысило maxOffset и не переполнило int. Страница за этой границей все равно
+// пуста, как и любая страница после последней
+func clampPage(page, limit int) int {
+	if limit > 0 && page-1 > maxOffset/limit {
+		return maxOffset/limit + 1
+	}
+	return page
+}
+
+// parsePagination читает page и limit из запроса. Без limit или при limit < 1
+// используется DefaultPageSize, limit больше MaxPageSize ограничивается им,
+// а слишком большой page - clampPage.
+// Возвращает номер страницы (с 1), размер страницы и смещение
+func parsePagination(r *http.Request, cfg *config.Config) (page, limit, offset int) {
+	page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = cfg.DefaultPageSize
+	}
+	if cfg.MaxPageSize > 0 && limit > cfg.MaxPageSize {
+		limit = cfg.MaxPageSize
+	}
+
+	page = clampPage(page, limit)
+	return page, limit, (page - 1) * limit
+}
+
+// setPaginationHeaders дублирует метаданные страницы в заголовках для админок,
+// которые не читают meta из тела (react-admin и подобные): X-Total-Count с общим
+// числом записей и Link (RFC 8288) со ссылками first, prev, next и last.
+// Ссылки сохраняют остальные параметры запроса и меняют только page
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, meta models.Meta) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(meta.TotalItems))
+
+	lastPage := meta.TotalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := []string{pageLink(r, 1, "first")}
+	if meta.CurrentPage > 1 {
+		links = append(links, pageLink(r, min(meta.CurrentPage-1, lastPage), "prev"))
+	}
+	if meta.CurrentPage < lastPage {
+		links = append(links, pageLink(r, meta.CurrentPage+1, "next"))
+	}
+	links = append(links, pageLink(r, lastPage, "last"))
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// pageLink строит элемент заголовка Link на страницу page текущего запроса
+func pageLink(r *http.Request, page int, rel string) string {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+}
+
+// writeList пишет страницу списка в формате, выбранном по Accept: по умолчанию
+// {meta, items}, с application/vnd.api+json - {data, meta} в стиле JSON:API.
+// С contentETag ответ получает ETag по содержимому и может вернуть 304
+func writeList(w http.ResponseWriter, r *http.Request, response models.PaginatedResponse, contentETag bool) {
+	varyAccept(w)
+
+	contentType := httputil.MediaTypeJSON
+	var payload interface{} = response
+	if httputil.WantsJSONAPI(r) {
+		contentType = httputil.MediaTypeJSONAPI
+		payload = models.JSONAPIResponse{Data: response.Items, Meta: response.Meta}
+	}
+
+	if contentETag {
+		w.Header().Set("Content-Type", contentType)
+		writeJSONWithETag(w, r, payload)
+		return
+	}
+	httputil.RespondJSONAs(w, http.StatusOK, contentType, payload)
+}
+
+// varyAccept добавляет Accept в Vary один раз: кэши должны хранить форматы списка раздельно
+func varyAccept(w http.ResponseWriter) {
+	for _, value := range w.Header().Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "Accept") {
+				return
+			}
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
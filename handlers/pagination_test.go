@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"student-backend/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+var linkPattern = regexp.MustCompile(`<([^>]*)>; rel="([a-z]+)"`)
+
+// parseLinks разбирает заголовок Link в отображение rel -> URL
+func parseLinks(t *testing.T, header string) map[string]*url.URL {
+	t.Helper()
+	links := make(map[string]*url.URL)
+	for _, match := range linkPattern.FindAllStringSubmatch(header, -1) {
+		link, err := url.Parse(match[1])
+		if err != nil {
+			t.Fatalf("parse link %q: %v", match[1], err)
+		}
+		links[match[2]] = link
+	}
+	return links
+}
+
+func TestGetStudentsPaginationHeaders(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	for i := 0; i < 5; i++ {
+		createStudent(t, env.db, "Anna", fmt.Sprintf("Smirnova%d", i), "", nil)
+	}
+	createStudent(t, env.db, "Boris", "Ivanov", "", nil)
+
+	tests := []struct {
+		page int
+		// Страницы в ссылках, 0 - ссылки нет
+		prev, next int
+	}{
+		{page: 1, prev: 0, next: 2},
+		{page: 2, prev: 1, next: 3},
+		{page: 3, prev: 2, next: 0},
+		// За последней страницей prev ведет на последнюю
+		{page: 9, prev: 3, next: 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("page %d", tt.page), func(t *testing.T) {
+			target := fmt.Sprintf("/api/students?name=Anna&limit=2&page=%d", tt.page)
+			w := serve(t, h.GetStudents, request{method: http.MethodGet, target: target, claims: adminClaims()})
+			expectStatus(t, w, http.StatusOK)
+
+			if got := w.Header().Get("X-Total-Count"); got != "5" {
+				t.Fatalf("X-Total-Count = %q, want 5", got)
+			}
+
+			links := parseLinks(t, w.Header().Get("Link"))
+			want := map[string]int{"first": 1, "last": 3, "prev": tt.prev, "next": tt.next}
+			for rel, page := range want {
+				link, ok := links[rel]
+				if page == 0 {
+					if ok {
+						t.Errorf("unexpected %s link %s", rel, link)
+					}
+					continue
+				}
+				if !ok {
+					t.Errorf("no %s link in %q", rel, w.Header().Get("Link"))
+					continue
+				}
+				query := link.Query()
+				if link.Path != "/api/students" || query.Get("page") != fmt.Sprint(page) ||
+					query.Get("limit") != "2" || query.Get("name") != "Anna" {
+					t.Errorf("%s link = %s, want page %d with the same filters", rel, link, page)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginationHeadersForEmptyList(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+
+	w := serve(t, h.GetStudents, request{method: http.MethodGet, target: "/api/students", claims: adminClaims()})
+	expectStatus(t, w, http.StatusOK)
+	if got := w.Header().Get("X-Total-Count"); got != "0" {
+		t.Fatalf("X-Total-Count = %q, want 0", got)
+	}
+	links := parseLinks(t, w.Header().Get("Link"))
+	if _, ok := links["next"]; ok {
+		t.Fatal("empty list has a next link")
+	}
+	if links["last"] == nil || links["last"].Query().Get("page") != "1" {
+		t.Fatalf("last link = %v, want page 1", links["last"])
+	}
+	var page struct {
+		Items []models.Student `json:"items"`
+	}
+	decodeBody(t, w, &page)
+	if page.Items == nil {
+		t.Fatal("items is null instead of an empty array")
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	env := newTestEnv(t)
+	group := createGroup(t, env.db, "INF-101")
+	// Фамилии задают порядок сортировки, отличный от порядка создания
+	for _, s := range []struct {
+		name, surname string
+		inGroup       bool
+	}{
+		{"Anna", "Orlova", true},
+		{"Boris", "Antonov", false},
+		{"Clara", "Zueva", true},
+		{"Denis", "Karpov", true},
+		{"Elena", "Belova", false},
+	} {
+		var groupID *uint
+		if s.inGroup {
+			groupID = &group.ID
+		}
+		createStudent(t, env.db, s.name, s.surname, "", groupID)
+	}
+	inGroup := func(db *gorm.DB) *gorm.DB { return db.Where("group_id = ?", group.ID) }
+	nameStartsWithVowel := func(db *gorm.DB) *gorm.DB { return db.Where("name IN ?", []string{"Anna", "Elena"}) }
+
+	tests := []struct {
+		name        string
+		opts        ListOptions
+		wantNames   []string
+		wantTotal   int
+		wantPages   int
+		wantRemains int
+		wantErr     error
+	}{
+		{"default order is by id", ListOptions{Page: 1, Limit: 10},
+			[]string{"Anna", "Boris", "Clara", "Denis", "Elena"}, 5, 1, 0, nil},
+		{"first page", ListOptions{Page: 1, Limit: 2},
+			[]string{"Anna", "Boris"}, 5, 3, 3, nil},
+		{"last partial page", ListOptions{Page: 3, Limit: 2},
+			[]string{"Elena"}, 5, 3, 0, nil},
+		{"past the last page", ListOptions{Page: 4, Limit: 2},
+			[]string{}, 5, 3, 0, nil},
+		{"sort ascending", ListOptions{Page: 1, Limit: 3, SortBy: "surname"},
+			[]string{"Boris", "Elena", "Denis"}, 5, 2, 2, nil},
+		{"sort descending", ListOptions{Page: 1, Limit: 3, SortBy: "-surname"},
+			[]string{"Clara", "Anna", "Denis"}, 5, 2, 2, nil},
+		{"sort by several fields", ListOptions{Page: 1, Limit: 5, SortBy: "-group_id,name"},
+			[]string{"Anna", "Clara", "Denis", "Boris", "Elena"}, 5, 1, 0, nil},
+		{"second page keeps the sort", ListOptions{Page: 2, Limit: 3, SortBy: "surname"},
+			[]string{"Anna", "Clara"}, 5, 2, 0, nil},
+		{"filter counts only matching rows", ListOptions{Page: 1, Limit: 2, Filters: []func(*gorm.DB) *gorm.DB{inGroup}},
+			[]string{"Anna", "Clara"}, 3, 2, 1, nil},
+		{"filters combine", ListOptions{Page: 1, Limit: 10, SortBy: "-name",
+			Filters: []func(*gorm.DB) *gorm.DB{inGroup, nameStartsWithVowel}},
+			[]string{"Anna"}, 1, 1, 0, nil},
+		{"filter with sort and paging", ListOptions{Page: 2, Limit: 1, SortBy: "-surname",
+			Filters: []func(*gorm.DB) *gorm.DB{inGroup}},
+			[]string{"Anna"}, 3, 3, 1, nil},
+		{"unknown sort field", ListOptions{Page: 1, Limit: 10, SortBy: "password"},
+			nil, 0, 0, 0, errInvalidSortField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.SortFields = studentSortFields
+			result, err := Paginate(env.db, &models.Student{}, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			names := []string{}
+			for _, student := range result.Items {
+				names = append(names, student.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.wantNames) {
+				t.Fatalf("items = %v, want %v", names, tt.wantNames)
+			}
+			meta := result.Meta
+			if meta.TotalItems != tt.wantTotal || meta.TotalPages != tt.wantPages || meta.RemainingCount != tt.wantRemains {
+				t.Fatalf("meta = %+v, want %d items, %d pages, %d remaining", meta, tt.wantTotal, tt.wantPages, tt.wantRemains)
+			}
+			if meta.CurrentPage != tt.opts.Page || meta.PerPage != tt.opts.Limit {
+				t.Fatalf("meta = %+v, want page %d of size %d", meta, tt.opts.Page, tt.opts.Limit)
+			}
+		})
+	}
+}
+
+func TestWriteListNegotiatesShape(t *testing.T) {
+	response := models.PaginatedResponse{
+		Meta:  models.Meta{TotalItems: 1, TotalPages: 1, CurrentPage: 1},
+		Items: []string{"item"},
+	}
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		wantKeys    []string
+	}{
+		{"default", "", "application/json", []string{"items", "meta"}},
+		{"plain json", "application/json", "application/json", []string{"items", "meta"}},
+		{"json api", "application/vnd.api+json", "application/vnd.api+json", []string{"data", "meta"}},
+		{"json api refused", "application/vnd.api+json;q=0, application/json", "application/json", []string{"items", "meta"}},
+	}
+	for _, tt := range tests {
+		for _, contentETag := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/etag=%t", tt.name, contentETag), func(t *testing.T) {
+				r := httptest.NewRequest(http.MethodGet, "/api/items", nil)
+				if tt.accept != "" {
+					r.Header.Set("Accept", tt.accept)
+				}
+				w := httptest.NewRecorder()
+				w.Header().Set("Vary", "Origin")
+				writeList(w, r, response, contentETag)
+				expectStatus(t, w, http.StatusOK)
+
+				if got := w.Header().Get("Content-Type"); got != tt.contentType {
+					t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+				}
+				if got := w.Header().Values("Vary"); !reflect.DeepEqual(got, []string{"Origin", "Accept"}) {
+					t.Errorf("Vary = %q, want [Origin Accept]", got)
+				}
+
+				var body map[string]json.RawMessage
+				decodeBody(t, w, &body)
+				keys := make([]string, 0, len(body))
+				for key := range body {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				if !reflect.DeepEqual(keys, tt.wantKeys) {
+					t.Errorf("body keys = %v, want %v", keys, tt.wantKeys)
+				}
+			})
+		}
+	}
+}
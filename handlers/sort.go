@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// applySort применяет сортировку из параметра sortBy ("field" - по возрастанию,
+// "-field" - по убыванию). Несколько полей перечисляются через запятую
+// ("-surname,name") и применяются по порядку. Допустимы только поля из белого
+// списка allowed, который сопоставляет имя поля в API с колонкой или выражением SQL.
+// Без sortBy сортирует по полю "id". Возвращает false, если хотя бы одно поле
+// сортировки недопустимо, - тогда запрос не меняется
+func applySort(query *gorm.DB, sortBy string, allowed map[string]string) (*gorm.DB, bool) {
+	if sortBy == "" {
+		return query.Order(allowed["id"] + " ASC"), true
+	}
+
+	fields := strings.Split(sortBy, ",")
+	orders := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		column, ok := allowed[strings.TrimPrefix(field, "-")]
+		if !ok {
+			return query, false
+		}
+
+		if strings.HasPrefix(field, "-") {
+			orders = append(orders, column+" DESC")
+		} else {
+			orders = append(orders, column+" ASC")
+		}
+	}
+
+	for _, order := range orders {
+		query = query.Order(order)
+	}
+	return query, true
+}
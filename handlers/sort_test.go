@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"student-backend/models"
+	"testing"
+)
+
+func TestGetStudentsSortByTwoFields(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	for _, name := range [][2]string{
+		{"Boris", "Ivanov"},
+		{"Anna", "Smirnova"},
+		{"Anna", "Ivanov"},
+		{"Vera", "Smirnova"},
+	} {
+		createStudent(t, env.db, name[0], name[1], "", nil)
+	}
+
+	tests := []struct {
+		sortBy string
+		want   string
+	}{
+		{"surname,name", "Anna Ivanov, Boris Ivanov, Anna Smirnova, Vera Smirnova"},
+		{"surname,-name", "Boris Ivanov, Anna Ivanov, Vera Smirnova, Anna Smirnova"},
+		{"-surname, name", "Anna Smirnova, Vera Smirnova, Anna Ivanov, Boris Ivanov"},
+		{"name,-id", "Anna Ivanov, Anna Smirnova, Boris Ivanov, Vera Smirnova"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			w := serve(t, h.GetStudents, request{
+				method: http.MethodGet, target: "/api/students?sortBy=" + strings.ReplaceAll(tt.sortBy, " ", "%20"), claims: adminClaims(),
+			})
+			expectStatus(t, w, http.StatusOK)
+			var page struct {
+				Items []models.Student `json:"items"`
+			}
+			decodeBody(t, w, &page)
+
+			names := make([]string, len(page.Items))
+			for i, student := range page.Items {
+				names[i] = student.Name + " " + student.Surname
+			}
+			if got := strings.Join(names, ", "); got != tt.want {
+				t.Fatalf("order = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStudentsRejectsInvalidSortField(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+
+	for _, sortBy := range []string{"password", "surname,password", "surname%3BDROP", "--surname", "surname,"} {
+		t.Run(sortBy, func(t *testing.T) {
+			w := serve(t, h.GetStudents, request{
+				method: http.MethodGet, target: "/api/students?sortBy=" + sortBy, claims: adminClaims(),
+			})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"student-backend/database"
+	"student-backend/events"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// BulkStudentItem - одна запись в запросе массового создания студентов
+type BulkStudentItem struct {
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+	Email   string `json:"email"`
+	GroupID *uint  `json:"group_id"`
+}
+
+// BulkItemError - ошибка проверки записи с ее индексом в запросе
+type BulkItemError struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+// BulkCreateResponse - ID созданных студентов и ошибки отклоненных записей
+type BulkCreateResponse struct {
+	Created []uint          `json:"created"`
+	Errors  []BulkItemError `json:"errors"`
+}
+
+// BulkCreateStudents создает студентов из массива: POST /api/students/bulk.
+// Записи проверяются по отдельности, прошедшие проверку создаются в одной транзакции.
+// Ошибка базы откатывает всю партию
+func (h *StudentHandler) BulkCreateStudents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionCreate, models.ResourceStudents) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var req struct {
+		Items []BulkStudentItem `json:"items"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if len(req.Items) == 0 {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "items must not be empty")
+		return
+	}
+	if len(req.Items) > h.cfg.BulkMaxItems {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, fmt.Sprintf("Too many items, maximum is %d", h.cfg.BulkMaxItems))
+		return
+	}
+
+	// Группы, на которые ссылаются записи, проверяем одним запросом
+	groupIDs := make([]uint, 0)
+	for _, item := range req.Items {
+		if item.GroupID != nil {
+			groupIDs = append(groupIDs, *item.GroupID)
+		}
+	}
+	groups := make(map[uint]models.Group)
+	if len(groupIDs) > 0 {
+		var found []models.Group
+		if err := db.Select("id", "archived").Where("id IN ?", groupIDs).Find(&found).Error; err != nil {
+			logf(r, "Error fetching groups for bulk students: %v", err)
+			respondDBError(w, err, "Internal server error")
+			return
+		}
+		for _, group := range found {
+			groups[group.ID] = group
+		}
+	}
+
+	response := BulkCreateResponse{Created: []uint{}, Errors: []BulkItemError{}}
+	students := make([]models.Student, 0, len(req.Items))
+	for i, item := range req.Items {
+		student := models.Student{
+			Name:    strings.TrimSpace(item.Name),
+			Surname: strings.TrimSpace(item.Surname),
+			Email:   strings.TrimSpace(item.Email),
+			GroupID: item.GroupID,
+		}
+
+		if reason := validateBulkStudent(&student, groups); reason != "" {
+			response.Errors = append(response.Errors, BulkItemError{Index: i, Reason: reason})
+			continue
+		}
+		students = append(students, student)
+	}
+
+	if len(students) > 0 {
+		err := database.WithTx(db, func(tx *gorm.DB) error {
+			return tx.CreateInBatches(&students, 100).Error
+		})
+		if err != nil {
+			logf(r, "Error bulk creating students: %v", err)
+			respondDBError(w, err, "Failed to create students in database")
+			return
+		}
+	}
+
+	for _, student := range students {
+		response.Created = append(response.Created, student.ID)
+		publishEvent(h.bus, claims, events.StudentCreated, student.ID,
+			fmt.Sprintf("%s %s (bulk)", student.Name, student.Surname), student)
+	}
+
+	logf(r, "Bulk created %d students, rejected %d", len(response.Created), len(response.Errors))
+
+	status := http.StatusCreated
+	if len(response.Created) == 0 {
+		status = http.StatusBadRequest
+	}
+	httputil.RespondJSON(w, status, response)
+}
+
+// validateBulkStudent проверяет запись массового создания и возвращает причину отказа
+func validateBulkStudent(student *models.Student, groups map[uint]models.Group) string {
+	if student.Name == "" || student.Surname == "" {
+		return "Name and surname are required"
+	}
+	if student.Email != "" {
+		if _, err := mail.ParseAddress(student.Email); err != nil {
+			return "Invalid email"
+		}
+	}
+	if student.GroupID != nil {
+		group, ok := groups[*student.GroupID]
+		if !ok {
+			return "Group not found"
+		}
+		if group.Archived {
+			return "Group is archived"
+		}
+	}
+	return ""
+}
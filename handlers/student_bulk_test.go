@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/models"
+	"testing"
+)
+
+func bulkCreate(t *testing.T, h *StudentHandler, items []BulkStudentItem) (int, BulkCreateResponse) {
+	t.Helper()
+	w := serve(t, h.BulkCreateStudents, request{
+		method: http.MethodPost, target: "/api/students/bulk",
+		body: map[string]interface{}{"items": items}, claims: adminClaims(),
+	})
+	var response BulkCreateResponse
+	decodeBody(t, w, &response)
+	return w.Code, response
+}
+
+func TestBulkCreateStudentsAllValid(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	group := createGroup(t, env.db, "B-1")
+
+	status, response := bulkCreate(t, h, []BulkStudentItem{
+		{Name: "Anna", Surname: "Smirnova", Email: "anna@example.com", GroupID: &group.ID},
+		{Name: "Boris", Surname: "Ivanov"},
+		{Name: "Vera", Surname: "Orlova", Email: "vera@example.com"},
+	})
+	if status != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", status)
+	}
+	if len(response.Created) != 3 || len(response.Errors) != 0 {
+		t.Fatalf("created %v, errors %v; want 3 created and no errors", response.Created, response.Errors)
+	}
+
+	var students []models.Student
+	env.db.Where("id IN ?", response.Created).Order("id").Find(&students)
+	if len(students) != 3 || students[0].GroupID == nil || *students[0].GroupID != group.ID {
+		t.Fatalf("stored students = %+v", students)
+	}
+}
+
+func TestBulkCreateStudentsWithInvalidItem(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	archived := createGroup(t, env.db, "B-2")
+	env.db.Model(archived).Update("archived", true)
+	missingGroup := uint(999)
+
+	tests := []struct {
+		name    string
+		invalid BulkStudentItem
+		reason  string
+	}{
+		{"missing surname", BulkStudentItem{Name: "Boris"}, "Name and surname are required"},
+		{"invalid email", BulkStudentItem{Name: "Boris", Surname: "Ivanov", Email: "not-an-email"}, "Invalid email"},
+		{"unknown group", BulkStudentItem{Name: "Boris", Surname: "Ivanov", GroupID: &missingGroup}, "Group not found"},
+		{"archived group", BulkStudentItem{Name: "Boris", Surname: "Ivanov", GroupID: &archived.ID}, "Group is archived"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := bulkCreate(t, h, []BulkStudentItem{
+				{Name: "Anna", Surname: "Smirnova"},
+				tt.invalid,
+				{Name: "Vera", Surname: "Orlova"},
+			})
+			if status != http.StatusCreated {
+				t.Fatalf("status = %d, want 201", status)
+			}
+			if len(response.Created) != 2 {
+				t.Fatalf("created %v, want the 2 valid items", response.Created)
+			}
+			want := []BulkItemError{{Index: 1, Reason: tt.reason}}
+			if len(response.Errors) != 1 || response.Errors[0] != want[0] {
+				t.Fatalf("errors = %+v, want %+v", response.Errors, want)
+			}
+		})
+	}
+}
+
+func TestBulkCreateStudentsRejectsBatch(t *testing.T) {
+	env := newTestEnv(t)
+	env.cfg.BulkMaxItems = 2
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+
+	status, response := bulkCreate(t, h, []BulkStudentItem{{Name: "Anna"}, {Surname: "Ivanov"}})
+	if status != http.StatusBadRequest || len(response.Created) != 0 || len(response.Errors) != 2 {
+		t.Fatalf("all invalid: status %d, response %+v; want 400 with 2 errors", status, response)
+	}
+
+	for name, items := range map[string][]BulkStudentItem{
+		"empty":     {},
+		"too large": {{Name: "A", Surname: "A"}, {Name: "B", Surname: "B"}, {Name: "C", Surname: "C"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, h.BulkCreateStudents, request{
+				method: http.MethodPost, target: "/api/students/bulk",
+				body: map[string]interface{}{"items": items}, claims: adminClaims(),
+			})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+
+	var count int64
+	env.db.Model(&models.Student{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("rejected batches created %d students", count)
+	}
+}
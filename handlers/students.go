@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/events"
+	"student-backend/httputil"
 	"student-backend/middleware"
 	"student-backend/models"
 
@@ -14,201 +20,305 @@ import (
 	"gorm.io/gorm"
 )
 
+// StudentRequest - тело создания и обновления студента
+type StudentRequest struct {
+	Name    string `json:"name" validate:"required,max=100"`
+	Surname string `json:"surname" validate:"required,max=100"`
+	Email   string `json:"email" validate:"omitempty,email,max=255"`
+	GroupID *uint  `json:"group_id"`
+	// Version - версия, прочитанная клиентом; обязательна при обновлении
+	Version int `json:"version"`
+}
+
+// PatchStudentRequest - тело частичного обновления студента: nil - поле не меняется
+type PatchStudentRequest struct {
+	Name    *string `json:"name" validate:"omitnil,min=1,max=100"`
+	Surname *string `json:"surname" validate:"omitnil,min=1,max=100"`
+	Email   *string `json:"email" validate:"omitnil,email,max=255"`
+	GroupID *uint   `json:"group_id"`
+	Version int     `json:"version"`
+}
+
 type StudentHandler struct {
-	db *gorm.DB
+	db  *gorm.DB
+	cfg *config.Config
+	bus *events.Bus
 }
 
-func NewStudentHandler(db *gorm.DB) *StudentHandler {
-	return &StudentHandler{db: db}
+func NewStudentHandler(db *gorm.DB, cfg *config.Config, bus *events.Bus) *StudentHandler {
+	return &StudentHandler{db: db, cfg: cfg, bus: bus}
 }
 
 func (h *StudentHandler) GetStudents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Получаем информацию о текущем пользователе
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionRead, models.ResourceStudents) {
 		return
 	}
 
-	// Параметры пагинации
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	// Создаем запрос с фильтрами
+	query, err := buildStudentQuery(db, r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 {
-		limit = 5
+	// Если пользователь - студент, показываем только его данные
+	claims := middleware.GetUserClaims(r.Context())
+	if claims.Role == models.RoleStudent {
+		if student, ok := callerStudent(db, claims); ok {
+			query = query.Where("students.id = ?", student.ID)
+		} else {
+			// Если у студента нет записи, показываем пустой список
+			query = query.Where("1 = 0")
+		}
 	}
 
-	offset := (page - 1) * limit
+	writeStudentPage(w, r, h.cfg, query)
+}
 
-	// Параметры сортировки
-	sortBy := r.URL.Query().Get("sortBy")
+// studentSortFields - поля сортировки студентов и соответствующие им колонки
+var studentSortFields = map[string]string{
+	"id":         "students.id",
+	"name":       "students.name",
+	"surname":    "students.surname",
+	"email":      "students.email",
+	"group_id":   "students.group_id",
+	"created_at": "students.created_at",
+	"updated_at": "students.updated_at",
+}
+
+// Ошибки фильтров списка студентов, текст возвращается клиенту
+var (
+	errInvalidGroupID          = errors.New("Invalid group_id")
+	errConflictingGroupFilters = errors.New("ungrouped cannot be combined with group_id or group_code")
+)
+
+// writeFilterError отвечает 400 с текстом ошибки фильтра
+func writeFilterError(w http.ResponseWriter, err error) {
+	httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, err.Error())
+}
 
+// buildStudentQuery строит запрос студентов с фильтрами из параметров запроса.
+// Используется общим списком и списком студентов группы. Колонки студентов указываются
+// с именем таблицы, так как фильтр group_code присоединяет таблицу groups.
+// Возвращает errInvalidGroupID или errConflictingGroupFilters при неверных фильтрах группы
+func buildStudentQuery(db *gorm.DB, r *http.Request) (*gorm.DB, error) {
 	// Параметры фильтрации
 	nameFilter := r.URL.Query().Get("name")
 	surnameFilter := r.URL.Query().Get("surname")
 	emailFilter := r.URL.Query().Get("email")
+	searchQuery := r.URL.Query().Get("q")
+
+	query := db.Model(&models.Student{})
 
-	// Создаем запрос с GORM
-	query := h.db.Model(&models.Student{})
+	// Общий поиск по имени, фамилии и email
+	query = applySearch(query, searchQuery, "students.name", "students.surname", "students.email")
 
 	// Применяем фильтрацию
 	if nameFilter != "" {
 		cleanName := strings.Trim(nameFilter, "*")
-		query = query.Where("name ILIKE ?", "%"+cleanName+"%")
+		query = query.Where(containsCondition("students.name"), containsPattern(cleanName))
 	}
 
 	if surnameFilter != "" {
 		cleanSurname := strings.Trim(surnameFilter, "*")
-		query = query.Where("surname ILIKE ?", "%"+cleanSurname+"%")
+		query = query.Where(containsCondition("students.surname"), containsPattern(cleanSurname))
 	}
 
 	// Фильтр по email
 	if emailFilter != "" {
 		cleanEmail := strings.Trim(emailFilter, "*")
-		query = query.Where("email ILIKE ?", "%"+cleanEmail+"%")
+		query = query.Where(containsCondition("students.email"), containsPattern(cleanEmail))
 	}
-	// Если пользователь - студент, показываем только его данные
-	// if claims.Role == models.RoleStudent {
-	// 	var student models.Student
-	// 	if err := h.db.Where("user_id = ?", claims.UserID).First(&student).Error; err == nil {
-	// 		query = query.Where("id = ?", student.ID)
-	// 	} else {
-	// 		// Если у студента нет записи, показываем пустой список
-	// 		query = query.Where("1 = 0")
-	// 	}
-	// }
-
-	// Получаем общее количество
-	var totalItems int64
-	if err := query.Count(&totalItems).Error; err != nil {
-		log.Printf(" Error counting students: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
-		return
-	}
-
-	// Применяем сортировки
-	if sortBy != "" {
-		if strings.HasPrefix(sortBy, "-") {
-			field := strings.TrimPrefix(sortBy, "-")
-			query = query.Order(field + " DESC")
-		} else {
-			query = query.Order(sortBy + " ASC")
+
+	// Фильтр по группе: точный ID, код группы или студенты без группы (?ungrouped=true)
+	groupIDFilter := r.URL.Query().Get("group_id")
+	groupCodeFilter := r.URL.Query().Get("group_code")
+
+	if r.URL.Query().Get("ungrouped") == "true" {
+		if groupIDFilter != "" || groupCodeFilter != "" {
+			return nil, errConflictingGroupFilters
 		}
-	} else {
-		query = query.Order("id ASC")
+		query = query.Where("students.group_id IS NULL")
 	}
 
-	// Применяем пагинацию
-	var students []models.Student
-	if err := query.Offset(offset).Limit(limit).Find(&students).Error; err != nil {
-		log.Printf(" Error fetching students: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	if groupIDFilter != "" {
+		groupID, err := strconv.ParseUint(groupIDFilter, 10, 64)
+		if err != nil {
+			return nil, errInvalidGroupID
+		}
+		query = query.Where("students.group_id = ?", groupID)
+	}
+
+	if groupCodeFilter != "" {
+		query = query.
+			Joins("JOIN groups ON groups.id = students.group_id AND groups.deleted_at IS NULL").
+			Where("groups.code = ?", normalizeGroupCode(groupCodeFilter))
+	}
+
+	return query, nil
+}
+
+// writeStudentPage применяет пагинацию и сортировку к запросу студентов и пишет страницу ответа.
+// С параметром after включается режим курсора, иначе используется смещение по page
+func writeStudentPage(w http.ResponseWriter, r *http.Request, cfg *config.Config, query *gorm.DB) {
+	page, limit, _ := parsePagination(r, cfg)
+
+	// ETag по числу строк и последнему изменению: при неизменных данных
+	// опрос списка получает 304 без выборки страницы
+	version, err := queryRowsVersion(query, "students")
+	if err != nil {
+		logf(r, " Error computing students version: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+	// Формат ответа зависит от Accept, поэтому входит в ETag
+	varyAccept(w)
+	if checkNotModified(w, r, versionETag(r, version, httputil.WantsJSONAPI(r))) {
 		return
 	}
 
-	totalPages := (int(totalItems) + limit - 1) / limit
-	remainingCount := int(totalItems) - (page * limit)
-	if remainingCount < 0 {
-		remainingCount = 0
+	if r.URL.Query().Has("after") {
+		writeStudentCursorPage(w, r, query, limit)
+		return
 	}
 
-	response := models.PaginatedResponse{
-		Meta: models.Meta{
-			TotalItems:     int(totalItems),
-			TotalPages:     totalPages,
-			CurrentPage:    page,
-			PerPage:        limit,
-			RemainingCount: remainingCount,
-		},
-		Items: students,
+	sortBy := r.URL.Query().Get("sortBy")
+	result, err := Paginate(query, &models.Student{}, ListOptions{
+		Page:       page,
+		Limit:      limit,
+		SortBy:     sortBy,
+		SortFields: studentSortFields,
+	})
+	if errors.Is(err, errInvalidSortField) {
+		logf(r, " Invalid sort field for students: %s", sortBy)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid sort field")
+		return
+	}
+	if err != nil {
+		logf(r, " Error fetching students: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf(" Error encoding response: %v", err)
+	setPaginationHeaders(w, r, result.Meta)
+	writeList(w, r, result.Response(), false)
+}
+
+// writeStudentCursorPage пишет страницу студентов с id > after в порядке id.
+// Без OFFSET и подсчета общего числа записей: meta содержит per_page и next_cursor,
+// остальные поля meta в этом режиме не заполняются
+func writeStudentCursorPage(w http.ResponseWriter, r *http.Request, query *gorm.DB, limit int) {
+	after, err := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+	if err != nil {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid after cursor")
+		return
 	}
+
+	if sortBy := r.URL.Query().Get("sortBy"); sortBy != "" && sortBy != "id" {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "sortBy is not supported with after cursor")
+		return
+	}
+
+	// Берем на одну запись больше, чтобы узнать, есть ли следующая страница
+	var students []models.Student
+	if err := query.Where("students.id > ?", after).Order("students.id ASC").
+		Limit(limit + 1).Find(&students).Error; err != nil {
+		logf(r, " Error fetching students: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	meta := models.Meta{PerPage: limit}
+	if len(students) > limit {
+		students = students[:limit]
+		nextCursor := students[limit-1].ID
+		meta.NextCursor = &nextCursor
+	}
+
+	writeList(w, r, models.PaginatedResponse{Meta: meta, Items: students}, false)
 }
 
 func (h *StudentHandler) CreateStudent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Проверяем права - только админ может создавать студентов
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionCreate, models.ResourceStudents) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf(" User %s (role: %s) tried to create student without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
-		return
-	}
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
 
-	log.Printf(" POST /api/students - Content-Type: %s, Content-Length: %d",
+	logf(r, " POST /api/students - Content-Type: %s, Content-Length: %d",
 		r.Header.Get("Content-Type"), r.ContentLength)
 
-	var student models.Student
+	var createReq StudentRequest
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf(" Error reading request body: %v", err)
-		http.Error(w, `{"error": "Cannot read request body"}`, http.StatusBadRequest)
+		logf(r, " Error reading request body: %v", err)
+		respondBodyError(w, err, "Cannot read request body")
 		return
 	}
 
-	log.Printf(" Request body: %s", string(body))
+	logRequestBody(r, h.cfg.LogRequestBodies, body, "name", "surname", "group_id")
 
-	if err := json.Unmarshal(body, &student); err != nil {
-		log.Printf(" Error decoding JSON: %v", err)
-		http.Error(w, `{"error": "Invalid JSON format"}`, http.StatusBadRequest)
+	if err := json.Unmarshal(body, &createReq); err != nil {
+		logf(r, " Error decoding JSON: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeInvalidBody, "Invalid JSON format")
 		return
 	}
 
-	log.Printf(" Creating student: Name='%s', Surname='%s'", student.Name, student.Surname)
+	logf(r, " Creating student: Name='%s', Surname='%s'", createReq.Name, createReq.Surname)
 
-	// Валидация
-	if student.Name == "" || student.Surname == "" {
-		log.Printf(" Validation failed: Name or Surname is empty")
-		http.Error(w, `{"error": "Name and surname are required"}`, http.StatusBadRequest)
+	if !validateRequest(w, r, &createReq) {
 		return
 	}
 
+	student := models.Student{
+		Name:    createReq.Name,
+		Surname: createReq.Surname,
+		Email:   createReq.Email,
+		GroupID: createReq.GroupID,
+	}
+
 	// Создаем студента с GORM
-	result := h.db.Create(&student)
+	result := db.Create(&student)
 	if result.Error != nil {
-		log.Printf(" Database error creating student: %v", result.Error)
-		http.Error(w, `{"error": "Failed to create student in database"}`, http.StatusInternalServerError)
+		logf(r, " Database error creating student: %v", result.Error)
+		respondDBError(w, result.Error, "Failed to create student in database")
 		return
 	}
 
-	log.Printf("Student created successfully with ID: %d", student.ID)
+	logf(r, "Student created successfully with ID: %d", student.ID)
+	publishEvent(h.bus, claims, events.StudentCreated, student.ID,
+		fmt.Sprintf("%s %s", student.Name, student.Surname), student)
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(student); err != nil {
-		log.Printf(" Error encoding response: %v", err)
-	}
+	httputil.RespondJSON(w, http.StatusCreated, student)
 }
 
 func (h *StudentHandler) UpdateStudent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Получаем информацию о текущем пользователе
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionUpdate, models.ResourceStudents) {
 		return
 	}
 
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf(" Error converting id to int: %v", err)
-		http.Error(w, `{"error": "Invalid student ID"}`, http.StatusBadRequest)
+		logf(r, " Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid student ID")
 		return
 	}
 
@@ -216,125 +326,366 @@ func (h *StudentHandler) UpdateStudent(w http.ResponseWriter, r *http.Request) {
 	if claims.Role == models.RoleStudent {
 		// Студент может редактировать только свою запись
 		var userStudent models.Student
-		if err := h.db.Where("user_id = ?", claims.UserID).First(&userStudent).Error; err != nil {
-			log.Printf("Student %s doesn't have a student record", claims.Email)
-			http.Error(w, `{"error": "Student record not found"}`, http.StatusForbidden)
+		if err := db.Where("user_id = ?", claims.UserID).First(&userStudent).Error; err != nil {
+			logf(r, "Student %s doesn't have a student record", claims.Email)
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Student record not found")
 			return
 		}
 
 		if uint(id) != userStudent.ID {
-			log.Printf(" Student %s tried to edit another student's data (ID: %d)",
+			logf(r, " Student %s tried to edit another student's data (ID: %d)",
 				claims.Email, id)
-			http.Error(w, `{"error": "Can only edit your own data"}`, http.StatusForbidden)
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Can only edit your own data")
 			return
 		}
 	}
 
-	log.Printf("🔄 Updating student with ID: %d (by user %s)", id, claims.Email)
+	logf(r, "🔄 Updating student with ID: %d (by user %s)", id, claims.Email)
 
-	var student models.Student
-	if err := json.NewDecoder(r.Body).Decode(&student); err != nil {
-		log.Printf(" Error decoding request body: %v", err)
-		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+	var student StudentRequest
+	if !decodeRequest(w, r, &student) {
 		return
 	}
 
-	log.Printf(" Update data - Name: '%s', Surname: '%s'", student.Name, student.Surname)
+	logf(r, " Update data - Name: '%s', Surname: '%s'", student.Name, student.Surname)
 
-	// Валидация
-	if student.Name == "" || student.Surname == "" {
-		log.Printf(" Validation failed: Name or Surname is empty")
-		http.Error(w, `{"error": "Name and surname are required"}`, http.StatusBadRequest)
+	if !requireVersion(w, student.Version) {
 		return
 	}
 
-	// Проверяем существование студента
-	var existingStudent models.Student
-	result := h.db.First(&existingStudent, id)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf(" Student with ID %d not found", id)
-			http.Error(w, `{"error": "Student not found"}`, http.StatusNotFound)
+	existingStudent, ok := findStudent(w, r, db, id)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"name":    student.Name,
+		"surname": student.Surname,
+	}
+
+	// PUT заменяет запись целиком: без group_id студент выводится из группы
+	groupChanged := !sameGroup(existingStudent.GroupID, student.GroupID)
+	if groupChanged {
+		if !h.checkGroupChange(w, r, db, claims, student.GroupID) {
 			return
 		}
-		log.Printf(" Error checking student existence: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
-		return
+		updates["group_id"] = student.GroupID
 	}
 
-	// Обновляем студента с GORM
-	updateData := models.Student{
-		Name:    student.Name,
-		Surname: student.Surname,
+	// Email меняется вместе с email связанной учетной записи. Пустой email не меняет
+	// текущий; студент меняет свой email через PATCH /api/auth/me с повторным подтверждением
+	newEmail := ""
+	if student.Email != "" && student.Email != existingStudent.Email {
+		if claims.Role == models.RoleStudent {
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Use PATCH /api/auth/me to change your email")
+			return
+		}
+		updates["email"] = student.Email
+		newEmail = student.Email
 	}
 
-	result = h.db.Model(&existingStudent).Updates(updateData)
-	if result.Error != nil {
-		log.Printf(" Error updating student in database: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	// Обновляем студента, если его не изменили после чтения клиентом
+	err = updateStudentRecord(db, claims, existingStudent, student.Version, updates, newEmail, groupChanged, student.GroupID)
+	if err != nil {
+		if respondVersionConflict(w, err) {
+			logf(r, " Stale version %d for student %d", student.Version, id)
+			return
+		}
+		if respondLinkedEmailTaken(w, err) {
+			logf(r, " Email %s of student %d is taken by another user", newEmail, id)
+			return
+		}
+		logf(r, " Error updating student in database: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
-	log.Printf(" Student updated successfully. Rows affected: %d", result.RowsAffected)
+	logf(r, " Student updated successfully")
 
 	// Получаем обновленного студента
 	var updatedStudent models.Student
-	h.db.First(&updatedStudent, id)
+	db.First(&updatedStudent, id)
+	publishEvent(h.bus, claims, events.StudentUpdated, existingStudent.ID,
+		fmt.Sprintf("%s %s", student.Name, student.Surname), updatedStudent)
 
-	if err := json.NewEncoder(w).Encode(updatedStudent); err != nil {
-		log.Printf("Error encoding response: %v", err)
-	}
+	httputil.RespondJSON(w, http.StatusOK, updatedStudent)
 }
 
-func (h *StudentHandler) DeleteStudent(w http.ResponseWriter, r *http.Request) {
+// PatchStudent частично обновляет студента: меняются только переданные поля,
+// остальные остаются прежними. Обязательные поля нельзя очистить: min=1 в тегах
+func (h *StudentHandler) PatchStudent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Проверяем права - только админ может удалять студентов
+	if !authorize(w, r, models.ActionUpdate, models.ResourceStudents) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid student ID")
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf("User %s (role: %s) tried to delete student without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+	if claims.Role == models.RoleStudent {
+		student, ok := callerStudent(db, claims)
+		if !ok || student.ID != uint(id) {
+			logf(r, " Student %s tried to patch student %d", claims.Email, id)
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Can only edit your own data")
+			return
+		}
+	}
+
+	var patchReq PatchStudentRequest
+	if !decodeRequest(w, r, &patchReq) {
+		return
+	}
+
+	if !requireVersion(w, patchReq.Version) {
+		return
+	}
+
+	existingStudent, ok := findStudent(w, r, db, id)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if patchReq.Name != nil {
+		updates["name"] = *patchReq.Name
+	}
+	if patchReq.Surname != nil {
+		updates["surname"] = *patchReq.Surname
+	}
+	newEmail := ""
+	if patchReq.Email != nil && *patchReq.Email != existingStudent.Email {
+		if claims.Role == models.RoleStudent {
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Use PATCH /api/auth/me to change your email")
+			return
+		}
+		updates["email"] = *patchReq.Email
+		newEmail = *patchReq.Email
+	}
+	groupChanged := patchReq.GroupID != nil && !sameGroup(existingStudent.GroupID, patchReq.GroupID)
+	if groupChanged {
+		if !h.checkGroupChange(w, r, db, claims, patchReq.GroupID) {
+			return
+		}
+		updates["group_id"] = *patchReq.GroupID
+	}
+
+	err = updateStudentRecord(db, claims, existingStudent, patchReq.Version, updates, newEmail, groupChanged, patchReq.GroupID)
+	if err != nil {
+		if respondVersionConflict(w, err) {
+			logf(r, " Stale version %d for student %d", patchReq.Version, id)
+			return
+		}
+		if respondLinkedEmailTaken(w, err) {
+			logf(r, " Email %s of student %d is taken by another user", newEmail, id)
+			return
+		}
+		logf(r, " Error patching student: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, " Student %d patched (fields: %d) by %s", existingStudent.ID, len(updates), claims.Email)
+
+	var updatedStudent models.Student
+	db.First(&updatedStudent, id)
+	publishEvent(h.bus, claims, events.StudentUpdated, existingStudent.ID,
+		fmt.Sprintf("patched fields: %d", len(updates)), updatedStudent)
+
+	httputil.RespondJSON(w, http.StatusOK, updatedStudent)
+}
+
+// sameGroup сравнивает группы студента; nil - без группы
+func sameGroup(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// checkGroupChange проверяет смену группы студента: менять группу может только
+// администратор, новая группа должна существовать и не быть архивной.
+// groupID == nil - студент выводится из группы
+func (h *StudentHandler) checkGroupChange(w http.ResponseWriter, r *http.Request, db *gorm.DB, claims *auth.JWTClaims, groupID *uint) bool {
+	if claims.Role == models.RoleStudent {
+		logf(r, " Student %s tried to change own group", claims.Email)
+		httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Only administrators can change the group")
+		return false
+	}
+	if groupID == nil {
+		return true
+	}
+
+	var group models.Group
+	if err := db.Select("id", "archived").First(&group, *groupID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			httputil.RespondError(w, http.StatusUnprocessableEntity, httputil.CodeValidationFailed, "Group not found",
+				map[string]interface{}{"field": "group_id"})
+			return false
+		}
+		logf(r, " Error checking group %d: %v", *groupID, err)
+		respondDBError(w, err, "Internal server error")
+		return false
+	}
+	if group.Archived {
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Cannot assign students to an archived group")
+		return false
+	}
+	return true
+}
+
+// updateStudentRecord обновляет студента с проверкой версии в одной транзакции
+// с email связанной учетной записи и, если группа сменилась, записью в истории групп
+func updateStudentRecord(db *gorm.DB, claims *auth.JWTClaims, student *models.Student, expected int,
+	updates map[string]interface{}, newEmail string, groupChanged bool, groupID *uint) error {
+	return database.WithTx(db, func(tx *gorm.DB) error {
+		if groupChanged {
+			if err := recordStudentGroupChange(tx, claims, student, groupID); err != nil {
+				return err
+			}
+		}
+		return updateVersionedSyncingEmail(tx, student, expected, updates, student.UserID, newEmail)
+	})
+}
+
+// findStudent загружает студента по id, отвечая 404, если его нет
+func findStudent(w http.ResponseWriter, r *http.Request, db *gorm.DB, id int) (*models.Student, bool) {
+	var student models.Student
+	if err := db.First(&student, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logf(r, " Student with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Student not found")
+			return nil, false
+		}
+		logf(r, " Error checking student existence: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return nil, false
+	}
+	return &student, true
+}
+
+func (h *StudentHandler) DeleteStudent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionDelete, models.ResourceStudents) {
 		return
 	}
 
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf(" Error converting id to int: %v", err)
-		http.Error(w, `{"error": "Invalid student ID"}`, http.StatusBadRequest)
+		logf(r, " Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid student ID")
 		return
 	}
 
-	log.Printf("🗑️ Deleting student with ID: %d (by admin %s)", id, claims.Email)
+	logf(r, "🗑️ Deleting student with ID: %d (by admin %s)", id, claims.Email)
 
 	// Проверяем существование студента
 	var student models.Student
-	result := h.db.First(&student, id)
+	result := db.First(&student, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf(" Student with ID %d not found", id)
-			http.Error(w, `{"error": "Student not found"}`, http.StatusNotFound)
+			logf(r, " Student with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Student not found")
 			return
 		}
-		log.Printf("Error checking student existence: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, "Error checking student existence: %v", result.Error)
+		respondDBError(w, result.Error, "Internal server error")
 		return
 	}
 
-	// Удаляем студента с GORM
-	result = h.db.Delete(&student)
-	if result.Error != nil {
-		log.Printf(" Error deleting student: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	deleteUser := deleteUserRequested(r)
+
+	// Удаляем студента вместе с обработкой связанной учетной записи
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		if err := tx.Delete(&student).Error; err != nil {
+			return err
+		}
+		if deleteUser {
+			// Запись уже мягко удалена, поэтому обновляем ее без фильтра deleted_at
+			if err := tx.Unscoped().Model(&student).Update("user_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		return detachLinkedUser(tx, "student_id", student.ID, deleteUser)
+	})
+	if err != nil {
+		logf(r, " Error deleting student: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
-	log.Printf(" Student deleted successfully. Rows affected: %d", result.RowsAffected)
+	logf(r, " Student %d deleted successfully (delete_user: %t)", student.ID, deleteUser)
+	publishEvent(h.bus, claims, events.StudentDeleted, student.ID,
+		fmt.Sprintf("%s %s", student.Name, student.Surname), nil)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// GetStudentGroupHistory возвращает историю смены групп студента в хронологическом порядке.
+// Студент может просматривать только свою историю
+func (h *StudentHandler) GetStudentGroupHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionRead, models.ResourceStudents) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid student ID")
+		return
+	}
+
+	var student models.Student
+	if err := db.Unscoped().First(&student, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "Student with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Student not found")
+			return
+		}
+		logf(r, "Error fetching student: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	if claims.Role == models.RoleStudent {
+		own, ok := callerStudent(db, claims)
+		if !ok || own.ID != student.ID {
+			logf(r, "Student %s tried to view group history of student %d", claims.Email, student.ID)
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeForbidden, "Insufficient permissions")
+			return
+		}
+	}
+
+	history := []models.StudentGroupHistory{}
+	if err := db.Where("student_id = ?", student.ID).
+		Order("changed_at ASC, id ASC").
+		Find(&history).Error; err != nil {
+		logf(r, "Error fetching group history: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, history)
+}
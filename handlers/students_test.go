@@ -0,0 +1,355 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"student-backend/models"
+	"testing"
+	"time"
+)
+
+func TestUpdateStudentAppliesGroupID(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		handle func(*StudentHandler) http.HandlerFunc
+		body   func(student *models.Student, groupID uint) map[string]interface{}
+	}{
+		{
+			name:   "PUT",
+			method: http.MethodPut,
+			handle: func(h *StudentHandler) http.HandlerFunc { return h.UpdateStudent },
+			body: func(student *models.Student, groupID uint) map[string]interface{} {
+				return map[string]interface{}{
+					"name": student.Name, "surname": student.Surname, "email": student.Email,
+					"group_id": groupID, "version": student.Version,
+				}
+			},
+		},
+		{
+			name:   "PATCH",
+			method: http.MethodPatch,
+			handle: func(h *StudentHandler) http.HandlerFunc { return h.PatchStudent },
+			body: func(student *models.Student, groupID uint) map[string]interface{} {
+				return map[string]interface{}{"group_id": groupID, "version": student.Version}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			h := NewStudentHandler(env.db, env.cfg, env.bus)
+			from := createGroup(t, env.db, "A-1")
+			to := createGroup(t, env.db, "B-1")
+			student := createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", &from.ID)
+			id := strconv.Itoa(int(student.ID))
+
+			w := serve(t, tt.handle(h), request{
+				method: tt.method,
+				target: "/api/students/" + id,
+				body:   tt.body(student, to.ID),
+				claims: adminClaims(),
+				vars:   map[string]string{"id": id},
+			})
+			expectStatus(t, w, http.StatusOK)
+
+			var stored models.Student
+			env.db.First(&stored, student.ID)
+			if stored.GroupID == nil || *stored.GroupID != to.ID {
+				t.Fatalf("group_id = %v, want %d", stored.GroupID, to.ID)
+			}
+			if stored.Name != "Anna" || stored.Email != "anna@example.com" {
+				t.Errorf("other fields changed: %+v", stored)
+			}
+
+			var history []models.StudentGroupHistory
+			env.db.Where("student_id = ?", student.ID).Find(&history)
+			if len(history) != 1 || history[0].FromGroupID == nil || *history[0].FromGroupID != from.ID {
+				t.Errorf("history = %+v, want one move from group %d", history, from.ID)
+			}
+		})
+	}
+}
+
+func TestStudentGroupHistoryRecordsEachMove(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	first := createGroup(t, env.db, "A-1")
+	second := createGroup(t, env.db, "B-1")
+	third := createGroup(t, env.db, "C-1")
+	student := createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", &first.ID)
+	id := strconv.Itoa(int(student.ID))
+
+	for _, group := range []*models.Group{second, third} {
+		var current models.Student
+		env.db.First(&current, student.ID)
+		w := serve(t, h.PatchStudent, request{
+			method: http.MethodPatch, target: "/api/students/" + id,
+			body:   map[string]interface{}{"group_id": group.ID, "version": current.Version},
+			claims: adminClaims(), vars: map[string]string{"id": id},
+		})
+		expectStatus(t, w, http.StatusOK)
+	}
+
+	w := serve(t, h.GetStudentGroupHistory, request{
+		method: http.MethodGet, target: "/api/students/" + id + "/group-history",
+		claims: adminClaims(), vars: map[string]string{"id": id},
+	})
+	expectStatus(t, w, http.StatusOK)
+	var history []models.StudentGroupHistory
+	decodeBody(t, w, &history)
+
+	want := [][2]uint{{first.ID, second.ID}, {second.ID, third.ID}}
+	if len(history) != len(want) {
+		t.Fatalf("history has %d rows, want %d: %+v", len(history), len(want), history)
+	}
+	for i, move := range want {
+		got := history[i]
+		if got.FromGroupID == nil || *got.FromGroupID != move[0] || got.ToGroupID == nil || *got.ToGroupID != move[1] {
+			t.Errorf("history[%d] = %v -> %v, want %d -> %d", i, got.FromGroupID, got.ToGroupID, move[0], move[1])
+		}
+		if got.ChangedBy != adminClaims().UserID {
+			t.Errorf("history[%d] changed_by = %d, want %d", i, got.ChangedBy, adminClaims().UserID)
+		}
+	}
+	if time.Time(history[1].ChangedAt).Before(time.Time(history[0].ChangedAt)) {
+		t.Errorf("history is not chronological: %v then %v", history[0].ChangedAt, history[1].ChangedAt)
+	}
+}
+
+func TestPatchStudentUnknownGroup(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	student := createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", nil)
+
+	w := serve(t, h.PatchStudent, request{
+		method: http.MethodPatch,
+		target: "/api/students/1",
+		body:   map[string]interface{}{"group_id": 999, "version": student.Version},
+		claims: adminClaims(),
+		vars:   map[string]string{"id": "1"},
+	})
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+}
+
+// listStudents вызывает GetStudents от имени администратора и возвращает фамилии
+func listStudents(t *testing.T, h *StudentHandler, target string) []string {
+	t.Helper()
+	w := serve(t, h.GetStudents, request{method: http.MethodGet, target: target, claims: adminClaims()})
+	expectStatus(t, w, http.StatusOK)
+
+	var page struct {
+		Items []models.Student `json:"items"`
+	}
+	decodeBody(t, w, &page)
+	surnames := make([]string, len(page.Items))
+	for i, student := range page.Items {
+		surnames[i] = student.Surname
+	}
+	return surnames
+}
+
+func TestGetStudentsSearch(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", nil)
+	createStudent(t, env.db, "Boris", "Ivanov", "b.ivanov@school.org", nil)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"smirn", "Smirnova"},
+		{"SCHOOL.ORG", "Ivanov"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := listStudents(t, h, "/api/students?q="+url.QueryEscape(tt.query))
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("q=%s returned %v, want [%s]", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStudentsSearchEscapesWildcards(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	createStudent(t, env.db, "Anna", "Smirnova", "anna@example.com", nil)
+	createStudent(t, env.db, "Boris", "Sto%", "boris@example.com", nil)
+	createStudent(t, env.db, "Vera", "Or_lova", "vera@example.com", nil)
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/api/students?q=" + url.QueryEscape("%"), "Sto%"},
+		{"/api/students?q=" + url.QueryEscape("_"), "Or_lova"},
+		{"/api/students?surname=" + url.QueryEscape("o%"), "Sto%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got := listStudents(t, h, tt.target)
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("GET %s returned %v, want [%s]", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// cursorPage - страница списка студентов в режиме курсора
+type cursorPage struct {
+	Items []models.Student `json:"items"`
+	Meta  models.Meta      `json:"meta"`
+}
+
+func getCursorPage(t *testing.T, h *StudentHandler, after uint, limit int) cursorPage {
+	t.Helper()
+	target := fmt.Sprintf("/api/students?after=%d&limit=%d", after, limit)
+	w := serve(t, h.GetStudents, request{method: http.MethodGet, target: target, claims: adminClaims()})
+	expectStatus(t, w, http.StatusOK)
+	var page cursorPage
+	decodeBody(t, w, &page)
+	return page
+}
+
+func TestGetStudentsCursorPagination(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	var created []*models.Student
+	for i := 0; i < 7; i++ {
+		created = append(created, createStudent(t, env.db, "Name", fmt.Sprintf("Surname%d", i), "", nil))
+	}
+
+	seen := make(map[uint]int)
+	var after uint
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("cursor paging does not terminate")
+		}
+		page := getCursorPage(t, h, after, 3)
+		for _, student := range page.Items {
+			seen[student.ID]++
+		}
+
+		// Изменения между запросами страниц не сдвигают курсор: удаленная
+		// уже прочитанная запись не приводит к пропуску, новая попадает в конец
+		if pages == 0 {
+			env.db.Delete(created[0])
+			created = append(created, createStudent(t, env.db, "Name", "Late", "", nil))
+		}
+
+		if page.Meta.NextCursor == nil {
+			break
+		}
+		after = *page.Meta.NextCursor
+	}
+
+	for _, student := range created {
+		if seen[student.ID] != 1 {
+			t.Errorf("student %d seen %d times, want once", student.ID, seen[student.ID])
+		}
+	}
+	if len(seen) != len(created) {
+		t.Errorf("paged through %d students, want %d", len(seen), len(created))
+	}
+}
+
+func TestGetStudentsCursorLastFullPageHasNoNextCursor(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	for i := 0; i < 3; i++ {
+		createStudent(t, env.db, "Name", fmt.Sprintf("Surname%d", i), "", nil)
+	}
+
+	page := getCursorPage(t, h, 0, 3)
+	if len(page.Items) != 3 || page.Meta.NextCursor != nil {
+		t.Fatalf("got %d items with next cursor %v, want 3 items and no cursor", len(page.Items), page.Meta.NextCursor)
+	}
+}
+
+func TestGetStudentsCursorValidation(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+
+	for _, target := range []string{
+		"/api/students?after=abc",
+		"/api/students?after=-1",
+		"/api/students?after=0&sortBy=surname",
+	} {
+		t.Run(target, func(t *testing.T) {
+			w := serve(t, h.GetStudents, request{method: http.MethodGet, target: target, claims: adminClaims()})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestGetStudentsGroupFilters(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	first := createGroup(t, env.db, "A-1")
+	second := createGroup(t, env.db, "B-1")
+	deleted := createGroup(t, env.db, "C-1")
+	createStudent(t, env.db, "Anna", "Orlova", "", &first.ID)
+	createStudent(t, env.db, "Boris", "Antonov", "", &second.ID)
+	createStudent(t, env.db, "Clara", "Zueva", "", &deleted.ID)
+	createStudent(t, env.db, "Denis", "Karpov", "", nil)
+	if err := env.db.Delete(deleted).Error; err != nil {
+		t.Fatalf("delete group: %v", err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{fmt.Sprintf("group_id=%d", first.ID), []string{"Orlova"}},
+		{fmt.Sprintf("group_id=%d", second.ID), []string{"Antonov"}},
+		{"group_id=9999", []string{}},
+		{"group_code=B-1", []string{"Antonov"}},
+		{"group_code=+a-1+", []string{"Orlova"}},
+		{"group_code=C-1", []string{}},
+		{"group_code=Z-9", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := listStudents(t, h, "/api/students?"+tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s returned %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	w := serve(t, h.GetStudents, request{method: http.MethodGet, target: "/api/students?group_id=abc", claims: adminClaims()})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestGetStudentsUngrouped(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewStudentHandler(env.db, env.cfg, env.bus)
+	group := createGroup(t, env.db, "A-1")
+	createStudent(t, env.db, "Anna", "Orlova", "", &group.ID)
+	createStudent(t, env.db, "Boris", "Antonov", "", nil)
+
+	if got := listStudents(t, h, "/api/students?ungrouped=true"); !reflect.DeepEqual(got, []string{"Antonov"}) {
+		t.Fatalf("ungrouped=true returned %v, want [Antonov]", got)
+	}
+	if got := listStudents(t, h, "/api/students?ungrouped=false"); len(got) != 2 {
+		t.Fatalf("ungrouped=false returned %v, want both students", got)
+	}
+
+	for _, query := range []string{
+		fmt.Sprintf("ungrouped=true&group_id=%d", group.ID),
+		"ungrouped=true&group_code=A-1",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := serve(t, h.GetStudents, request{method: http.MethodGet, target: "/api/students?" + query, claims: adminClaims()})
+			expectStatus(t, w, http.StatusBadRequest)
+		})
+	}
+}
@@ -1,362 +1,976 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/events"
+	"student-backend/httputil"
 	"student-backend/middleware"
 	"student-backend/models"
+	"time"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
 
+// CreateTeacherRequest - тело создания преподавателя
+type CreateTeacherRequest struct {
+	Name         string `json:"name" validate:"required,max=100"`
+	Surname      string `json:"surname" validate:"required,max=100"`
+	Email        string `json:"email" validate:"required,email,max=255"`
+	Phone        string `json:"phone" validate:"max=20"`
+	Title        string `json:"title"`
+	DepartmentID *uint  `json:"department_id"`
+	// Необязательное создание учетной записи преподавателя
+	CreateAccount bool   `json:"create_account"`
+	Password      string `json:"password" validate:"omitempty,min=6,max=72"`
+}
+
+// UpdateTeacherRequest - тело полной замены преподавателя (PUT)
+type UpdateTeacherRequest struct {
+	Name         string         `json:"name" validate:"required,max=100"`
+	Surname      string         `json:"surname" validate:"required,max=100"`
+	Email        string         `json:"email" validate:"required,email,max=255"`
+	Phone        string         `json:"phone" validate:"max=20"`
+	Title        string         `json:"title"`
+	DepartmentID *uint          `json:"department_id"`
+	Groups       []models.Group `json:"groups"`
+	Version      int            `json:"version"`
+}
+
+// PatchTeacherRequest - тело частичного обновления преподавателя.
+// Указатели позволяют отличить отсутствующее поле от пустого значения
+type PatchTeacherRequest struct {
+	Name         *string         `json:"name" validate:"omitnil,min=1,max=100"`
+	Surname      *string         `json:"surname" validate:"omitnil,min=1,max=100"`
+	Email        *string         `json:"email" validate:"omitnil,email,max=255"`
+	Phone        *string         `json:"phone" validate:"omitnil,max=20"`
+	Title        *string         `json:"title"`
+	DepartmentID *uint           `json:"department_id"`
+	Groups       *[]models.Group `json:"groups"`
+	Version      int             `json:"version"`
+}
+
 type TeacherHandler struct {
-	db *gorm.DB
+	db           *gorm.DB
+	cfg          *config.Config
+	bus          *events.Bus
+	phonePattern *regexp.Regexp
+}
+
+func NewTeacherHandler(db *gorm.DB, cfg *config.Config, bus *events.Bus) *TeacherHandler {
+	phonePattern, err := regexp.Compile(cfg.PhonePattern)
+	if err != nil {
+		log.Printf("❌ Invalid PHONE_PATTERN %q, using default: %v", cfg.PhonePattern, err)
+		phonePattern = regexp.MustCompile(config.DefaultPhonePattern)
+	}
+
+	return &TeacherHandler{db: db, cfg: cfg, bus: bus, phonePattern: phonePattern}
 }
 
-func NewTeacherHandler(db *gorm.DB) *TeacherHandler {
-	return &TeacherHandler{db: db}
+// normalizePhone убирает пробелы и дефисы из номера и проверяет его по шаблону.
+// Пустой номер допустим, так как телефон необязателен
+func (h *TeacherHandler) normalizePhone(phone string) (string, bool) {
+	normalized := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(phone))
+	if normalized == "" {
+		return "", true
+	}
+	return normalized, h.phonePattern.MatchString(normalized)
+}
+
+// validatePhone нормализует телефон и при неверном формате пишет ответ 400
+func (h *TeacherHandler) validatePhone(w http.ResponseWriter, phone *string) bool {
+	normalized, ok := h.normalizePhone(*phone)
+	if !ok {
+		log.Printf("Validation failed: invalid phone '%s'", *phone)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid phone number format")
+		return false
+	}
+	*phone = normalized
+	return true
 }
 
 func (h *TeacherHandler) GetTeachers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionRead, models.ResourceTeachers) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf("❌ User %s (role: %s) tried to access teachers without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	page, limit, _ := parsePagination(r, h.cfg)
+
+	sortBy := r.URL.Query().Get("sortBy")
+
+	// Создаем базовый запрос с фильтрами
+	query, err := buildTeacherQuery(db, r)
+	if err != nil {
+		writeFilterError(w, err)
 		return
 	}
 
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	result, err := Paginate(query, &models.Teacher{}, ListOptions{
+		Page:       page,
+		Limit:      limit,
+		SortBy:     sortBy,
+		SortFields: teacherSortFields,
+	})
+	if errors.Is(err, errInvalidSortField) {
+		logf(r, "❌ Invalid sort field for teachers: %s", sortBy)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid sort field")
+		return
+	}
+	if err != nil {
+		logf(r, "❌ Error fetching teachers: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 {
-		limit = 5
+	// Загружаем группы для каждого преподавателя отдельно
+	teachers := result.Items
+	for i := range teachers {
+		if err := db.Model(&teachers[i]).Association("Groups").Find(&teachers[i].Groups); err != nil {
+			logf(r, "❌ Error loading groups for teacher %d: %v", teachers[i].ID, err)
+		}
 	}
 
-	offset := (page - 1) * limit
+	setPaginationHeaders(w, r, result.Meta)
+	writeList(w, r, result.Response(), true)
+}
 
-	sortBy := r.URL.Query().Get("sortBy")
+// teacherSortFields - поля сортировки преподавателей и соответствующие им колонки
+var teacherSortFields = map[string]string{
+	"id":            "teachers.id",
+	"name":          "teachers.name",
+	"surname":       "teachers.surname",
+	"email":         "teachers.email",
+	"phone":         "teachers.phone",
+	"title":         "teachers.title",
+	"department_id": "teachers.department_id",
+	"created_at":    "teachers.created_at",
+	"updated_at":    "teachers.updated_at",
+}
+
+// errInvalidDepartmentID - нечисловой фильтр department_id, текст возвращается клиенту
+var errInvalidDepartmentID = errors.New("Invalid department_id")
+
+// buildTeacherQuery строит запрос преподавателей с фильтрами из параметров запроса.
+// Используется и списком, и экспортом, чтобы фильтрация совпадала.
+// Возвращает errInvalidDepartmentID при нечисловом department_id
+func buildTeacherQuery(db *gorm.DB, r *http.Request) (*gorm.DB, error) {
 	nameFilter := r.URL.Query().Get("name")
 	surnameFilter := r.URL.Query().Get("surname")
 	emailFilter := r.URL.Query().Get("email")
+	phoneFilter := r.URL.Query().Get("phone")
+	titleFilter := r.URL.Query().Get("title")
+	departmentFilter := r.URL.Query().Get("department_id")
+	searchQuery := r.URL.Query().Get("q")
+	showDeleted := r.URL.Query().Get("deleted") == "true"
 
-	// Создаем базовый запрос
-	query := h.db.Model(&models.Teacher{})
+	query := db.Model(&models.Teacher{})
+
+	// ?deleted=true показывает только удаленных преподавателей
+	if showDeleted {
+		query = db.Unscoped().Model(&models.Teacher{}).Where("deleted_at IS NOT NULL")
+	}
+
+	// Общий поиск по имени, фамилии и email
+	query = applySearch(query, searchQuery, "name", "surname", "email")
 
 	if nameFilter != "" {
 		cleanName := strings.Trim(nameFilter, "*")
-		query = query.Where("name ILIKE ?", "%"+cleanName+"%")
+		query = query.Where(containsCondition("name"), containsPattern(cleanName))
 	}
 
 	if surnameFilter != "" {
 		cleanSurname := strings.Trim(surnameFilter, "*")
-		query = query.Where("surname ILIKE ?", "%"+cleanSurname+"%")
+		query = query.Where(containsCondition("surname"), containsPattern(cleanSurname))
 	}
 
 	if emailFilter != "" {
 		cleanEmail := strings.Trim(emailFilter, "*")
-		query = query.Where("email ILIKE ?", "%"+cleanEmail+"%")
+		query = query.Where(containsCondition("email"), containsPattern(cleanEmail))
 	}
 
-	var totalItems int64
-	if err := query.Count(&totalItems).Error; err != nil {
-		log.Printf("❌ Error counting teachers: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
-		return
+	if phoneFilter != "" {
+		cleanPhone := strings.Trim(phoneFilter, "*")
+		query = query.Where(containsCondition("phone"), containsPattern(cleanPhone))
 	}
 
-	// Сортируем и применяем пагинацию
-	if sortBy != "" {
-		if strings.HasPrefix(sortBy, "-") {
-			field := strings.TrimPrefix(sortBy, "-")
-			query = query.Order(field + " DESC")
-		} else {
-			query = query.Order(sortBy + " ASC")
+	if titleFilter != "" {
+		query = query.Where("title = ?", titleFilter)
+	}
+
+	if departmentFilter != "" {
+		departmentID, err := strconv.ParseUint(departmentFilter, 10, 64)
+		if err != nil {
+			return nil, errInvalidDepartmentID
 		}
-	} else {
-		query = query.Order("id ASC")
+		query = query.Where("department_id = ?", departmentID)
 	}
 
-	var teachers []models.Teacher
-	if err := query.Offset(offset).Limit(limit).Find(&teachers).Error; err != nil {
-		log.Printf("❌ Error fetching teachers: %v", err)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	return query, nil
+}
+
+// extendWriteDeadline продлевает дедлайн записи ответа на d от текущего момента.
+// Нужен потоковым выгрузкам, которые пишут дольше серверного WriteTimeout.
+// Обертки ResponseWriter в middleware должны реализовывать Unwrap, иначе
+// продлить дедлайн нельзя - тогда ошибка только логируется
+func extendWriteDeadline(w http.ResponseWriter, r *http.Request, d time.Duration) {
+	if d <= 0 {
 		return
 	}
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d)); err != nil {
+		logf(r, "Cannot extend write deadline: %v", err)
+	}
+}
 
-	// Загружаем группы для каждого преподавателя отдельно
-	for i := range teachers {
-		if err := h.db.Model(&teachers[i]).Association("Groups").Find(&teachers[i].Groups); err != nil {
-			log.Printf("❌ Error loading groups for teacher %d: %v", teachers[i].ID, err)
-		}
+// formatOptionalID форматирует необязательный ID для CSV, пустая строка - ID не задан
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
 	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
 
-	totalPages := (int(totalItems) + limit - 1) / limit
-	remainingCount := int(totalItems) - (page * limit)
-	if remainingCount < 0 {
-		remainingCount = 0
+// ExportTeachers выгружает всех преподавателей, подходящих под фильтры, в CSV (только для админа)
+func (h *TeacherHandler) ExportTeachers(w http.ResponseWriter, r *http.Request) {
+	if !authorize(w, r, models.ActionRead, models.ResourceTeachers) {
+		return
 	}
 
-	response := models.PaginatedResponse{
-		Meta: models.Meta{
-			TotalItems:     int(totalItems),
-			TotalPages:     totalPages,
-			CurrentPage:    page,
-			PerPage:        limit,
-			RemainingCount: remainingCount,
-		},
-		Items: teachers,
+	claims := middleware.GetUserClaims(r.Context())
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "csv" {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Unsupported export format")
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		log.Printf("❌ Error encoding response: %v", err)
+	// Выгрузка может занимать больше времени, чем обычный запрос,
+	// поэтому ограничиваемся только контекстом клиента
+	query, err := buildTeacherQuery(h.db.WithContext(r.Context()), r)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	query = query.Order("id ASC")
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="teachers.csv"`)
+
+	// BOM нужен Excel, чтобы правильно распознать UTF-8
+	if r.URL.Query().Get("bom") == "true" {
+		w.Write([]byte("\xEF\xBB\xBF"))
+	}
+
+	extendWriteDeadline(w, r, h.cfg.ExportWriteTimeout)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "name", "surname", "email", "phone", "title", "department_id", "created_at"})
+
+	exported := 0
+	var batch []models.Teacher
+	result := query.FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		// Дедлайн считается от каждой порции, поэтому длинная выгрузка
+		// не обрывается, пока клиент продолжает принимать данные
+		extendWriteDeadline(w, r, h.cfg.ExportWriteTimeout)
+		for _, teacher := range batch {
+			record := []string{
+				strconv.FormatUint(uint64(teacher.ID), 10),
+				teacher.Name,
+				teacher.Surname,
+				teacher.Email,
+				teacher.Phone,
+				teacher.Title,
+				formatOptionalID(teacher.DepartmentID),
+				teacher.CreatedAt.Time().Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		exported += len(batch)
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		// Порция уходит клиенту сразу, а не копится в буфере middleware
+		if err := http.NewResponseController(w).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if result.Error != nil {
+		// Заголовки уже отправлены, поэтому остается только прервать выгрузку
+		logf(r, "❌ Error exporting teachers: %v", result.Error)
+		return
 	}
+
+	writer.Flush()
+	logf(r, "Exported %d teachers to CSV (by admin %s)", exported, claims.Email)
 }
 
 func (h *TeacherHandler) CreateTeacher(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Проверяем права - только админ может создавать преподавателей
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionCreate, models.ResourceTeachers) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf(" User %s (role: %s) tried to create teacher without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
-		return
-	}
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
 
-	log.Printf(" POST /api/teachers - Content-Type: %s, Content-Length: %d",
+	claims := middleware.GetUserClaims(r.Context())
+
+	logf(r, " POST /api/teachers - Content-Type: %s, Content-Length: %d",
 		r.Header.Get("Content-Type"), r.ContentLength)
 
-	var createReq struct {
-		Name    string `json:"name"`
-		Surname string `json:"surname"`
-		Email   string `json:"email"`
-		Phone   string `json:"phone"`
-	}
+	var createReq CreateTeacherRequest
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf(" Error reading request body: %v", err)
-		http.Error(w, `{"error": "Cannot read request body"}`, http.StatusBadRequest)
+		logf(r, " Error reading request body: %v", err)
+		respondBodyError(w, err, "Cannot read request body")
 		return
 	}
 
-	log.Printf("📝 Request body: %s", string(body))
-
 	if err := json.Unmarshal(body, &createReq); err != nil {
-		log.Printf(" Error decoding JSON: %v", err)
-		http.Error(w, `{"error": "Invalid JSON format"}`, http.StatusBadRequest)
+		logf(r, " Error decoding JSON: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeInvalidBody, "Invalid JSON format")
 		return
 	}
 
-	log.Printf(" Creating teacher: Name='%s', Surname='%s', Email='%s', Phone='%s'",
-		createReq.Name, createReq.Surname, createReq.Email, createReq.Phone)
+	logRequestBody(r, h.cfg.LogRequestBodies, body, "name", "surname", "title", "department_id", "create_account")
 
-	// Валидация
-	if createReq.Name == "" || createReq.Surname == "" || createReq.Email == "" {
-		log.Printf("Validation failed: Name, Surname and Email are required")
-		http.Error(w, `{"error": "Name, surname and email are required"}`, http.StatusBadRequest)
+	logf(r, " Creating teacher: Name='%s', Surname='%s', Email='%s'",
+		createReq.Name, createReq.Surname, maskEmail(createReq.Email))
+
+	if !validateRequest(w, r, &createReq) {
+		return
+	}
+
+	if !models.IsValidTeacherTitle(createReq.Title) {
+		logf(r, "Validation failed: unknown title '%s'", createReq.Title)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid title")
+		return
+	}
+
+	if !h.validatePhone(w, &createReq.Phone) {
 		return
 	}
 
 	// Проверяем, существует ли преподаватель с таким email
 	var existingTeacher models.Teacher
-	if err := h.db.Where("email = ?", createReq.Email).First(&existingTeacher).Error; err == nil {
-		log.Printf(" Teacher with email %s already exists", createReq.Email)
-		http.Error(w, `{"error": "Teacher with this email already exists"}`, http.StatusConflict)
+	if err := db.Where("email = ?", models.NormalizeEmail(createReq.Email)).First(&existingTeacher).Error; err == nil {
+		logf(r, " Teacher with email %s already exists", createReq.Email)
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Teacher with this email already exists")
 		return
 	}
 
 	// Создаем преподавателя
 	teacher := models.Teacher{
-		Name:    createReq.Name,
-		Surname: createReq.Surname,
-		Email:   createReq.Email,
-		Phone:   createReq.Phone,
+		Name:         createReq.Name,
+		Surname:      createReq.Surname,
+		Email:        createReq.Email,
+		Phone:        createReq.Phone,
+		Title:        createReq.Title,
+		DepartmentID: createReq.DepartmentID,
 	}
 
-	result := h.db.Create(&teacher)
-	if result.Error != nil {
-		log.Printf(" Database error creating teacher: %v", result.Error)
-		http.Error(w, `{"error": "Failed to create teacher in database"}`, http.StatusInternalServerError)
+	// Пароль возвращается в ответе только один раз и нигде не сохраняется в открытом виде
+	var account *accountCredentials
+
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		if err := tx.Create(&teacher).Error; err != nil {
+			return err
+		}
+
+		if !createReq.CreateAccount {
+			return nil
+		}
+
+		credentials, err := createLinkedAccount(tx, teacher.Email, createReq.Password, models.RoleTeacher)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", credentials.UserID).Update("teacher_id", teacher.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&teacher).Update("user_id", credentials.UserID).Error; err != nil {
+			return err
+		}
+
+		account = credentials
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errUserEmailTaken) {
+			logf(r, " User with email %s already exists, teacher creation rolled back", createReq.Email)
+			httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "User with this email already exists")
+			return
+		}
+		logf(r, " Database error creating teacher: %v", err)
+		respondDBError(w, err, "Failed to create teacher in database")
 		return
 	}
 
-	log.Printf(" Teacher created successfully with ID: %d", teacher.ID)
+	logf(r, " Teacher created successfully with ID: %d (account: %t)", teacher.ID, account != nil)
+	publishEvent(h.bus, claims, events.TeacherCreated, teacher.ID, teacher.Email, teacher)
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(teacher); err != nil {
-		log.Printf(" Error encoding response: %v", err)
+	response := struct {
+		models.Teacher
+		Account *accountCredentials `json:"account,omitempty"`
+	}{
+		Teacher: teacher,
+		Account: account,
 	}
+
+	httputil.RespondJSON(w, http.StatusCreated, response)
 }
 
 func (h *TeacherHandler) UpdateTeacher(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	if !authorize(w, r, models.ActionUpdate, models.ResourceTeachers) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
 	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "❌ Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid teacher ID")
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+	var teacher models.Teacher
+	result := db.Preload("Groups").First(&teacher, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Teacher not found")
+			return
+		}
+		respondDBError(w, result.Error, "Internal server error")
+		return
+	}
+
+	// PUT заменяет запись целиком, поэтому все обязательные поля должны быть переданы
+	var updateReq UpdateTeacherRequest
+
+	if !decodeRequest(w, r, &updateReq) {
+		return
+	}
+
+	if !requireVersion(w, updateReq.Version) {
+		return
+	}
+
+	if !models.IsValidTeacherTitle(updateReq.Title) {
+		logf(r, "Validation failed: unknown title '%s'", updateReq.Title)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid title")
+		return
+	}
+
+	if !h.validatePhone(w, &updateReq.Phone) {
+		return
+	}
+
+	// Email хранится нормализованным, поэтому смена регистра - не смена email.
+	// Новый email переносится и в учетную запись преподавателя
+	newEmail := ""
+	if email := models.NormalizeEmail(updateReq.Email); email != teacher.Email {
+		if !h.checkEmailAvailable(db, w, email, teacher.ID) {
+			return
+		}
+		newEmail = email
+	}
+
+	var groups *[]models.Group
+	if updateReq.Groups != nil {
+		resolved, ok := resolveTeacherGroups(w, r, db, updateReq.Groups)
+		if !ok {
+			return
+		}
+		groups = &resolved
+	}
+
+	// Сохраняем основные поля и группы, если запись не изменили после чтения клиентом
+	err = updateTeacherRecord(db, &teacher, updateReq.Version, map[string]interface{}{
+		"name":          updateReq.Name,
+		"surname":       updateReq.Surname,
+		"email":         updateReq.Email,
+		"phone":         updateReq.Phone,
+		"title":         updateReq.Title,
+		"department_id": updateReq.DepartmentID,
+	}, newEmail, groups)
+	if err != nil {
+		if respondVersionConflict(w, err) {
+			logf(r, "Stale version %d for teacher %d", updateReq.Version, teacher.ID)
+			return
+		}
+		if respondLinkedEmailTaken(w, err) {
+			logf(r, "Email %s of teacher %d is taken by another user", updateReq.Email, teacher.ID)
+			return
+		}
+		logf(r, "❌ Error updating teacher: %v", err)
+		respondDBError(w, err, "Failed to update teacher")
+		return
+	}
+
+	// Подгружаем группы для ответа
+	db.Preload("Groups").First(&teacher, teacher.ID)
+	publishEvent(h.bus, claims, events.TeacherUpdated, teacher.ID, teacher.Email, teacher)
+
+	httputil.RespondJSON(w, http.StatusOK, teacher)
+}
+
+// PatchTeacher частично обновляет преподавателя: изменяются только переданные поля
+func (h *TeacherHandler) PatchTeacher(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceTeachers) {
 		return
 	}
 
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf("❌ Error converting id to int: %v", err)
-		http.Error(w, `{"error": "Invalid teacher ID"}`, http.StatusBadRequest)
+		logf(r, "❌ Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid teacher ID")
 		return
 	}
 
 	var teacher models.Teacher
-	result := h.db.Preload("Groups").First(&teacher, id)
+	result := db.First(&teacher, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			http.Error(w, `{"error": "Teacher not found"}`, http.StatusNotFound)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Teacher not found")
 			return
 		}
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		respondDBError(w, result.Error, "Internal server error")
 		return
 	}
 
-	var updateReq struct {
-		Name    string         `json:"name"`
-		Surname string         `json:"surname"`
-		Email   string         `json:"email"`
-		Phone   string         `json:"phone"`
-		Groups  []models.Group `json:"groups"`
+	// Обязательные поля нельзя очистить через PATCH: min=1 в тегах
+	var patchReq PatchTeacherRequest
+
+	if !decodeRequest(w, r, &patchReq) {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
-		log.Printf("❌ Error decoding request body: %v", err)
-		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+	if !requireVersion(w, patchReq.Version) {
 		return
 	}
 
-	// Обновляем основные поля
-	teacher.Name = updateReq.Name
-	teacher.Surname = updateReq.Surname
-	teacher.Email = updateReq.Email
-	teacher.Phone = updateReq.Phone
+	if patchReq.Title != nil && !models.IsValidTeacherTitle(*patchReq.Title) {
+		logf(r, "Validation failed: unknown title '%s'", *patchReq.Title)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Invalid title")
+		return
+	}
 
-	// Обновляем связи с группами
-	if updateReq.Groups != nil {
-		// Получаем ID групп из запроса
-		var groupIDs []uint
-		for _, group := range updateReq.Groups {
-			if group.ID > 0 {
-				groupIDs = append(groupIDs, group.ID)
-			}
-		}
+	if patchReq.Phone != nil && !h.validatePhone(w, patchReq.Phone) {
+		return
+	}
 
-		// Находим группы по ID
-		var groups []models.Group
-		if len(groupIDs) > 0 {
-			if err := h.db.Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
-				log.Printf("❌ Error finding groups: %v", err)
-				http.Error(w, `{"error": "Invalid group IDs"}`, http.StatusBadRequest)
-				return
-			}
+	updates := map[string]interface{}{}
+	newEmail := ""
+	if patchReq.Name != nil {
+		updates["name"] = *patchReq.Name
+	}
+	if patchReq.Surname != nil {
+		updates["surname"] = *patchReq.Surname
+	}
+	if patchReq.Email != nil && models.NormalizeEmail(*patchReq.Email) != teacher.Email {
+		newEmail = models.NormalizeEmail(*patchReq.Email)
+		if !h.checkEmailAvailable(db, w, newEmail, teacher.ID) {
+			return
 		}
+		updates["email"] = newEmail
+	}
+	if patchReq.Phone != nil {
+		updates["phone"] = *patchReq.Phone
+	}
+	if patchReq.Title != nil {
+		updates["title"] = *patchReq.Title
+	}
+	if patchReq.DepartmentID != nil {
+		updates["department_id"] = *patchReq.DepartmentID
+	}
 
-		// Обновляем связи
-		if err := h.db.Model(&teacher).Association("Groups").Replace(&groups); err != nil {
-			log.Printf("❌ Error updating teacher groups: %v", err)
-			http.Error(w, `{"error": "Failed to update groups"}`, http.StatusInternalServerError)
+	var groups *[]models.Group
+	if patchReq.Groups != nil {
+		resolved, ok := resolveTeacherGroups(w, r, db, *patchReq.Groups)
+		if !ok {
 			return
 		}
+		groups = &resolved
 	}
 
-	// Сохраняем изменения
-	if err := h.db.Save(&teacher).Error; err != nil {
-		log.Printf("❌ Error updating teacher: %v", err)
-		http.Error(w, `{"error": "Failed to update teacher"}`, http.StatusInternalServerError)
+	// Версия растет и при изменении только групп
+	if err := updateTeacherRecord(db, &teacher, patchReq.Version, updates, newEmail, groups); err != nil {
+		if respondVersionConflict(w, err) {
+			logf(r, "Stale version %d for teacher %d", patchReq.Version, teacher.ID)
+			return
+		}
+		if respondLinkedEmailTaken(w, err) {
+			logf(r, "Email %s of teacher %d is taken by another user", newEmail, teacher.ID)
+			return
+		}
+		logf(r, "❌ Error patching teacher: %v", err)
+		respondDBError(w, err, "Failed to update teacher")
 		return
 	}
 
+	logf(r, "Teacher %d patched (fields: %d) by admin %s", teacher.ID, len(updates), claims.Email)
+
 	// Подгружаем группы для ответа
-	h.db.Preload("Groups").First(&teacher, teacher.ID)
+	db.Preload("Groups").First(&teacher, teacher.ID)
+	publishEvent(h.bus, claims, events.TeacherUpdated, teacher.ID,
+		fmt.Sprintf("patched fields: %d", len(updates)), teacher)
+
+	httputil.RespondJSON(w, http.StatusOK, teacher)
+}
 
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(teacher); err != nil {
-		log.Printf("❌ Error encoding response: %v", err)
+// checkEmailAvailable проверяет, что email не занят другим преподавателем.
+// При конфликте пишет ответ 409 и возвращает false
+func (h *TeacherHandler) checkEmailAvailable(db *gorm.DB, w http.ResponseWriter, email string, teacherID uint) bool {
+	var teacherWithSameEmail models.Teacher
+	if err := db.Where("email = ? AND id != ?", models.NormalizeEmail(email), teacherID).First(&teacherWithSameEmail).Error; err == nil {
+		middleware.Logf(db.Statement.Context, "Email %s already used by another teacher", email)
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Email already in use by another teacher")
+		return false
 	}
+	return true
+}
+
+// resolveTeacherGroups загружает группы, переданные в запросе, по их ID.
+// Если каких-то групп нет, пишет ответ 422 со списком неизвестных ID и возвращает false
+func resolveTeacherGroups(w http.ResponseWriter, r *http.Request, db *gorm.DB, requested []models.Group) ([]models.Group, bool) {
+	groupIDs := make([]uint, 0, len(requested))
+	seen := make(map[uint]bool, len(requested))
+	for _, group := range requested {
+		if !seen[group.ID] {
+			seen[group.ID] = true
+			groupIDs = append(groupIDs, group.ID)
+		}
+	}
+
+	groups := []models.Group{}
+	if len(groupIDs) > 0 {
+		if err := db.Where("id IN ?", groupIDs).Find(&groups).Error; err != nil {
+			logf(r, "❌ Error finding groups: %v", err)
+			respondDBError(w, err, "Internal server error")
+			return nil, false
+		}
+	}
+
+	found := make(map[uint]bool, len(groups))
+	for _, group := range groups {
+		found[group.ID] = true
+	}
+	unknown := []uint{}
+	for _, id := range groupIDs {
+		if !found[id] {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		logf(r, "Unknown groups for teacher: %v", unknown)
+		httputil.RespondError(w, http.StatusUnprocessableEntity, httputil.CodeValidationFailed, "Group not found",
+			map[string]interface{}{"field": "groups", "ids": unknown})
+		return nil, false
+	}
+	return groups, true
+}
+
+// updateTeacherRecord обновляет преподавателя с проверкой версии в одной транзакции
+// с email связанной учетной записи и, если groups не nil, набором его групп:
+// обновление применяется целиком или не применяется вовсе
+func updateTeacherRecord(db *gorm.DB, teacher *models.Teacher, expected int, updates map[string]interface{},
+	newEmail string, groups *[]models.Group) error {
+	return database.WithTx(db, func(tx *gorm.DB) error {
+		if err := updateVersionedSyncingEmail(tx, teacher, expected, updates, teacher.UserID, newEmail); err != nil {
+			return err
+		}
+		if groups == nil {
+			return nil
+		}
+		if err := tx.Model(teacher).Association("Groups").Replace(groups); err != nil {
+			return fmt.Errorf("failed to replace teacher groups: %w", err)
+		}
+		return nil
+	})
 }
 
 func (h *TeacherHandler) DeleteTeacher(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Проверяем права - только админ может удалять преподавателей
-	claims := middleware.GetUserClaims(r.Context())
-	if claims == nil {
-		http.Error(w, `{"error": "Not authenticated"}`, http.StatusUnauthorized)
+	if !authorize(w, r, models.ActionDelete, models.ResourceTeachers) {
 		return
 	}
 
-	if claims.Role != models.RoleAdmin {
-		log.Printf(" User %s (role: %s) tried to delete teacher without permission",
-			claims.Email, claims.Role)
-		http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
-		return
-	}
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
 
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		log.Printf(" Error converting id to int: %v", err)
-		http.Error(w, `{"error": "Invalid teacher ID"}`, http.StatusBadRequest)
+		logf(r, " Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid teacher ID")
 		return
 	}
 
-	log.Printf("🗑️ Deleting teacher with ID: %d (by admin %s)", id, claims.Email)
+	logf(r, "🗑️ Deleting teacher with ID: %d (by admin %s)", id, claims.Email)
 
 	// Проверяем существование преподавателя
 	var teacher models.Teacher
-	result := h.db.First(&teacher, id)
+	result := db.First(&teacher, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			log.Printf(" Teacher with ID %d not found", id)
-			http.Error(w, `{"error": "Teacher not found"}`, http.StatusNotFound)
+			logf(r, " Teacher with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Teacher not found")
 			return
 		}
-		log.Printf(" Error checking teacher existence: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+		logf(r, " Error checking teacher existence: %v", result.Error)
+		respondDBError(w, result.Error, "Internal server error")
 		return
 	}
 
-	// Удаляем преподавателя
-	result = h.db.Delete(&teacher)
-	if result.Error != nil {
-		log.Printf(" Error deleting teacher: %v", result.Error)
-		http.Error(w, `{"error": "Internal server error"}`, http.StatusInternalServerError)
+	deleteUser := deleteUserRequested(r)
+
+	// Удаляем преподавателя вместе с обработкой связанной учетной записи
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		if err := tx.Delete(&teacher).Error; err != nil {
+			return err
+		}
+		return releaseTeacherLinks(tx, &teacher, deleteUser)
+	})
+	if err != nil {
+		logf(r, " Error deleting teacher: %v", err)
+		respondDBError(w, err, "Internal server error")
 		return
 	}
 
-	log.Printf(" Teacher deleted successfully. Rows affected: %d", result.RowsAffected)
+	logf(r, " Teacher %d deleted successfully (delete_user: %t)", teacher.ID, deleteUser)
+	publishEvent(h.bus, claims, events.TeacherDeleted, teacher.ID, teacher.Email, nil)
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// releaseTeacherLinks снимает ссылки на удаляемого преподавателя внутри транзакции:
+// кураторство групп и учетную запись. Как и у студента, учетная запись отключается
+// и восстанавливается в RestoreTeacher, а при deleteUser удаляется вместе со ссылкой на нее
+func releaseTeacherLinks(tx *gorm.DB, teacher *models.Teacher, deleteUser bool) error {
+	if err := tx.Model(&models.Group{}).Where("curator_id = ?", teacher.ID).
+		Updates(map[string]interface{}{"curator_id": nil, "version": versionBump}).Error; err != nil {
+		return err
+	}
+	if deleteUser {
+		// Запись уже мягко удалена, поэтому обновляем ее без фильтра deleted_at
+		if err := tx.Unscoped().Model(teacher).Update("user_id", nil).Error; err != nil {
+			return err
+		}
+	}
+	return detachLinkedUser(tx, "teacher_id", teacher.ID, deleteUser)
+}
+
+// Статусы пакетного удаления
+const (
+	batchStatusDeleted              = "deleted"
+	batchStatusNotFound             = "not_found"
+	batchStatusHasActiveAssignments = "has_active_assignments"
+)
+
+// batchDeleteResult - результат удаления одной записи в пакетной операции
+type batchDeleteResult struct {
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+	Code   int    `json:"code"`
+}
+
+// BatchDeleteTeachers удаляет нескольких преподавателей в одной транзакции (только для админа).
+// Преподаватели, назначенные в группы или кураторы групп, удаляются только с ?force=true
+func (h *TeacherHandler) BatchDeleteTeachers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionDelete, models.ResourceTeachers) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var deleteReq struct {
+		IDs []uint `json:"ids"`
+	}
+
+	if !decodeRequest(w, r, &deleteReq) {
+		return
+	}
+
+	if len(deleteReq.IDs) == 0 {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "ids must not be empty")
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+	deleteUser := deleteUserRequested(r)
+
+	logf(r, "🗑️ Batch deleting %d teachers (force: %t, by admin %s)", len(deleteReq.IDs), force, claims.Email)
+
+	results := make([]batchDeleteResult, 0, len(deleteReq.IDs))
+	var deleted []models.Teacher
+
+	err := database.WithTx(db, func(tx *gorm.DB) error {
+		for _, id := range deleteReq.IDs {
+			var teacher models.Teacher
+			if err := tx.First(&teacher, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					results = append(results, batchDeleteResult{ID: id, Status: batchStatusNotFound, Code: http.StatusNotFound})
+					continue
+				}
+				return err
+			}
+
+			// Назначением считается и преподавание в группе, и кураторство
+			var curated int64
+			if err := tx.Model(&models.Group{}).Where("curator_id = ?", teacher.ID).Count(&curated).Error; err != nil {
+				return err
+			}
+			assignments := tx.Model(&teacher).Association("Groups").Count() + curated
+			if assignments > 0 {
+				if !force {
+					results = append(results, batchDeleteResult{ID: id, Status: batchStatusHasActiveAssignments, Code: http.StatusConflict})
+					continue
+				}
+				if err := tx.Model(&teacher).Association("Groups").Clear(); err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Delete(&teacher).Error; err != nil {
+				return err
+			}
+			if err := releaseTeacherLinks(tx, &teacher, deleteUser); err != nil {
+				return err
+			}
+
+			deleted = append(deleted, teacher)
+			results = append(results, batchDeleteResult{ID: id, Status: batchStatusDeleted, Code: http.StatusOK})
+		}
+		return nil
+	})
+	if err != nil {
+		logf(r, " Error batch deleting teachers: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	for _, teacher := range deleted {
+		publishEvent(h.bus, claims, events.TeacherDeleted, teacher.ID, teacher.Email, nil)
+	}
+
+	logf(r, " Batch delete finished: %d of %d teachers deleted", len(deleted), len(deleteReq.IDs))
+
+	response := map[string]interface{}{
+		"deleted": len(deleted),
+		"results": results,
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, response)
+}
+
+// RestoreTeacher восстанавливает мягко удаленного преподавателя (только для админа)
+func (h *TeacherHandler) RestoreTeacher(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceTeachers) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, " Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid teacher ID")
+		return
+	}
+
+	// Ищем среди удаленных записей
+	var teacher models.Teacher
+	result := db.Unscoped().Where("deleted_at IS NOT NULL").First(&teacher, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			logf(r, " Deleted teacher with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Deleted teacher not found")
+			return
+		}
+		logf(r, " Error checking teacher existence: %v", result.Error)
+		respondDBError(w, result.Error, "Internal server error")
+		return
+	}
+
+	// Email мог быть занят активным преподавателем после удаления
+	var activeTeacher models.Teacher
+	if err := db.Where("email = ?", teacher.Email).First(&activeTeacher).Error; err == nil {
+		logf(r, " Cannot restore teacher %d: email %s is taken by teacher %d",
+			teacher.ID, teacher.Email, activeTeacher.ID)
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Email already in use by an active teacher")
+		return
+	}
+
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&teacher).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+
+		// Вместе с преподавателем восстанавливаем отключенную учетную запись
+		return tx.Unscoped().Model(&models.User{}).
+			Where("teacher_id = ? AND deleted_at IS NOT NULL", teacher.ID).
+			Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		logf(r, " Error restoring teacher: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, " Teacher %d restored by admin %s", teacher.ID, claims.Email)
+
+	db.Preload("Groups").First(&teacher, teacher.ID)
+	publishEvent(h.bus, claims, events.TeacherRestored, teacher.ID, teacher.Email, teacher)
+
+	httputil.RespondJSON(w, http.StatusOK, teacher)
+}
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"student-backend/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestTeacherPhoneValidation(t *testing.T) {
+	phones := []struct {
+		name  string
+		phone string
+		want  string
+		valid bool
+	}{
+		{"valid", "+79001234567", "+79001234567", true},
+		{"spaces and dashes", " +7 900 123-45-67 ", "+79001234567", true},
+		{"without plus", "89001234567", "89001234567", true},
+		{"empty", "", "", true},
+		{"letters", "+7900abc4567", "", false},
+		{"too short", "12345", "", false},
+		{"too long", "+1234567890123456", "", false},
+	}
+	methods := []struct {
+		method string
+		status int
+	}{
+		{http.MethodPost, http.StatusCreated},
+		{http.MethodPut, http.StatusOK},
+		{http.MethodPatch, http.StatusOK},
+	}
+	for _, m := range methods {
+		for _, tt := range phones {
+			t.Run(m.method+"/"+tt.name, func(t *testing.T) {
+				env := newTestEnv(t)
+				body := map[string]interface{}{
+					"name": "Ivan", "surname": "Petrov", "email": "ivan@example.com", "phone": tt.phone,
+				}
+
+				var w *httptest.ResponseRecorder
+				if m.method == http.MethodPost {
+					w = serve(t, NewTeacherHandler(env.db, env.cfg, env.bus).CreateTeacher, request{
+						method: http.MethodPost, target: "/api/teachers", body: body, claims: adminClaims(),
+					})
+				} else {
+					teacher := createTeacherInGroups(t, env, "ivan@example.com")
+					env.db.Model(teacher).UpdateColumn("phone", "+70000000000")
+					body["version"] = teacher.Version
+					w = teacherUpdate(t, env, m.method, teacher, body)
+				}
+
+				var stored models.Teacher
+				found := env.db.Where("email = ?", "ivan@example.com").First(&stored).Error == nil
+				if !tt.valid {
+					expectStatus(t, w, http.StatusBadRequest)
+					if m.method == http.MethodPost && found {
+						t.Fatal("teacher with an invalid phone was created")
+					}
+					if m.method != http.MethodPost && stored.Phone != "+70000000000" {
+						t.Fatalf("phone = %q after a rejected update, want it unchanged", stored.Phone)
+					}
+					return
+				}
+				expectStatus(t, w, m.status)
+				if stored.Phone != tt.want {
+					t.Fatalf("phone = %q, want %q", stored.Phone, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestPatchTeacherPhoneOnly(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewTeacherHandler(env.db, env.cfg, env.bus)
+
+	teacher := models.Teacher{Name: "Ivan", Surname: "Petrov", Email: "ivan.petrov@example.com", Phone: "+100000000"}
+	if err := env.db.Create(&teacher).Error; err != nil {
+		t.Fatalf("create teacher: %v", err)
+	}
+
+	w := serve(t, h.PatchTeacher, request{
+		method: http.MethodPatch,
+		target: "/api/teachers/1",
+		body:   map[string]interface{}{"phone": "+123456789", "version": teacher.Version},
+		claims: adminClaims(),
+		vars:   map[string]string{"id": "1"},
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	var stored models.Teacher
+	if err := env.db.First(&stored, teacher.ID).Error; err != nil {
+		t.Fatalf("reload teacher: %v", err)
+	}
+	if stored.Phone != "+123456789" {
+		t.Errorf("phone = %q, want +123456789", stored.Phone)
+	}
+	if stored.Name != "Ivan" || stored.Surname != "Petrov" || stored.Email != "ivan.petrov@example.com" {
+		t.Errorf("untouched fields changed: %+v", stored)
+	}
+	if stored.Version != teacher.Version+1 {
+		t.Errorf("version = %d, want %d", stored.Version, teacher.Version+1)
+	}
+}
+
+// createLinkedTeacher создает преподавателя со связанной учетной записью
+func createLinkedTeacher(t *testing.T, env *testEnv, email string) (*models.Teacher, *models.User) {
+	t.Helper()
+	teacher := models.Teacher{Name: "Ivan", Surname: "Petrov", Email: email}
+	if err := env.db.Create(&teacher).Error; err != nil {
+		t.Fatalf("create teacher: %v", err)
+	}
+	user := models.User{Email: email, Password: "password123", Role: models.RoleTeacher, EmailVerified: true, TeacherID: &teacher.ID}
+	if err := env.db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	env.db.Model(&teacher).Update("user_id", user.ID)
+	return &teacher, &user
+}
+
+func TestDeletedTeacherTokenLosesAccess(t *testing.T) {
+	for _, target := range []string{"/api/teachers/1", "/api/teachers/1?delete_user=true"} {
+		t.Run(target, func(t *testing.T) {
+			env := newTestEnv(t)
+			teachers := NewTeacherHandler(env.db, env.cfg, env.bus)
+			groups := env.authenticated(NewGroupHandler(env.db, env.cfg).GetGroups)
+			teacher, user := createLinkedTeacher(t, env, "ivan.petrov@example.com")
+			token := env.tokenFor(t, user)
+
+			listGroups := func() int {
+				return serve(t, groups, request{method: http.MethodGet, target: "/api/groups", headers: bearer(token)}).Code
+			}
+			if status := listGroups(); status != http.StatusOK {
+				t.Fatalf("before delete: status = %d, want 200", status)
+			}
+
+			w := serve(t, teachers.DeleteTeacher, request{
+				method: http.MethodDelete,
+				target: target,
+				claims: adminClaims(),
+				vars:   map[string]string{"id": strconv.Itoa(int(teacher.ID))},
+			})
+			expectStatus(t, w, http.StatusNoContent)
+
+			if status := listGroups(); status != http.StatusUnauthorized {
+				t.Errorf("after delete: status = %d, want 401", status)
+			}
+		})
+	}
+}
+
+func TestRegisterWithEmailOfDeletedTeacherAccount(t *testing.T) {
+	tests := []struct {
+		target string
+		status int
+	}{
+		// Отключенная учетная запись сохраняет email до восстановления
+		{"/api/teachers/1", http.StatusConflict},
+		{"/api/teachers/1?delete_user=true", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			env := newTestEnv(t)
+			teachers := NewTeacherHandler(env.db, env.cfg, env.bus)
+			authHandler, _ := env.newAuthHandler(t)
+			teacher, _ := createLinkedTeacher(t, env, "ivan.petrov@example.com")
+
+			w := serve(t, teachers.DeleteTeacher, request{
+				method: http.MethodDelete,
+				target: tt.target,
+				claims: adminClaims(),
+				vars:   map[string]string{"id": strconv.Itoa(int(teacher.ID))},
+			})
+			expectStatus(t, w, http.StatusNoContent)
+
+			w = serve(t, authHandler.Register, request{
+				method: http.MethodPost,
+				target: "/api/auth/register",
+				body:   map[string]interface{}{"email": "Ivan.Petrov@example.com", "password": "password123", "role": models.RoleStudent},
+			})
+			expectStatus(t, w, tt.status)
+		})
+	}
+}
+
+func TestBatchDeleteTeachersAssignments(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewTeacherHandler(env.db, env.cfg, env.bus)
+
+	curator := models.Teacher{Name: "Curator", Surname: "One", Email: "curator@example.com"}
+	lecturer := models.Teacher{Name: "Lecturer", Surname: "Two", Email: "lecturer@example.com"}
+	free := models.Teacher{Name: "Free", Surname: "Three", Email: "free@example.com"}
+	for _, teacher := range []*models.Teacher{&curator, &lecturer, &free} {
+		if err := env.db.Create(teacher).Error; err != nil {
+			t.Fatalf("create teacher: %v", err)
+		}
+	}
+	curated := createGroup(t, env.db, "C-1")
+	env.db.Model(curated).Update("curator_id", curator.ID)
+	taught := createGroup(t, env.db, "T-1")
+	env.db.Model(&lecturer).Association("Groups").Append(taught)
+
+	batchDelete := func(target string, ids ...uint) map[uint]string {
+		t.Helper()
+		w := serve(t, h.BatchDeleteTeachers, request{
+			method: http.MethodDelete,
+			target: target,
+			body:   map[string]interface{}{"ids": ids},
+			claims: adminClaims(),
+		})
+		expectStatus(t, w, http.StatusOK)
+		var response struct {
+			Results []batchDeleteResult `json:"results"`
+		}
+		decodeBody(t, w, &response)
+		statuses := make(map[uint]string, len(response.Results))
+		for _, result := range response.Results {
+			statuses[result.ID] = result.Status
+		}
+		return statuses
+	}
+
+	statuses := batchDelete("/api/teachers", curator.ID, lecturer.ID, free.ID, 999)
+	want := map[uint]string{
+		curator.ID:  batchStatusHasActiveAssignments,
+		lecturer.ID: batchStatusHasActiveAssignments,
+		free.ID:     batchStatusDeleted,
+		999:         batchStatusNotFound,
+	}
+	for id, status := range want {
+		if statuses[id] != status {
+			t.Errorf("teacher %d: status = %q, want %q", id, statuses[id], status)
+		}
+	}
+
+	statuses = batchDelete("/api/teachers?force=true", curator.ID, lecturer.ID)
+	if statuses[curator.ID] != batchStatusDeleted || statuses[lecturer.ID] != batchStatusDeleted {
+		t.Fatalf("forced delete statuses = %v, want both deleted", statuses)
+	}
+
+	var group models.Group
+	env.db.First(&group, curated.ID)
+	if group.CuratorID != nil {
+		t.Errorf("curator_id = %d after forced delete, want nil", *group.CuratorID)
+	}
+}
+
+// createTeacherInGroups создает преподавателя, ведущего группы groups
+func createTeacherInGroups(t *testing.T, env *testEnv, email string, groups ...models.Group) *models.Teacher {
+	t.Helper()
+	teacher := models.Teacher{Name: "Ivan", Surname: "Petrov", Email: email, Groups: groups}
+	if err := env.db.Create(&teacher).Error; err != nil {
+		t.Fatalf("create teacher: %v", err)
+	}
+	return &teacher
+}
+
+// teacherGroupIDs возвращает ID групп преподавателя из базы
+func teacherGroupIDs(t *testing.T, env *testEnv, teacherID uint) []uint {
+	t.Helper()
+	var teacher models.Teacher
+	if err := env.db.Preload("Groups", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&teacher, teacherID).Error; err != nil {
+		t.Fatalf("reload teacher: %v", err)
+	}
+	ids := []uint{}
+	for _, group := range teacher.Groups {
+		ids = append(ids, group.ID)
+	}
+	return ids
+}
+
+// teacherUpdate вызывает PUT или PATCH преподавателя
+func teacherUpdate(t *testing.T, env *testEnv, method string, teacher *models.Teacher, body map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	h := NewTeacherHandler(env.db, env.cfg, env.bus)
+	handler := h.PatchTeacher
+	if method == http.MethodPut {
+		handler = h.UpdateTeacher
+	}
+	id := strconv.Itoa(int(teacher.ID))
+	return serve(t, handler, request{
+		method: method, target: "/api/teachers/" + id, body: body,
+		claims: adminClaims(), vars: map[string]string{"id": id},
+	})
+}
+
+func TestUpdateTeacherReplacesGroups(t *testing.T) {
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			env := newTestEnv(t)
+			first, second := createGroup(t, env.db, "INF-101"), createGroup(t, env.db, "INF-102")
+			teacher := createTeacherInGroups(t, env, "ivan@example.com", *first)
+
+			w := teacherUpdate(t, env, method, teacher, map[string]interface{}{
+				"name": "Ivan", "surname": "Sidorov", "email": "ivan@example.com",
+				"groups": []map[string]uint{{"id": second.ID}}, "version": teacher.Version,
+			})
+			expectStatus(t, w, http.StatusOK)
+
+			if got := teacherGroupIDs(t, env, teacher.ID); len(got) != 1 || got[0] != second.ID {
+				t.Fatalf("groups = %v, want [%d]", got, second.ID)
+			}
+			var stored models.Teacher
+			env.db.First(&stored, teacher.ID)
+			if stored.Surname != "Sidorov" || stored.Version != teacher.Version+1 {
+				t.Fatalf("stored = %s at version %d, want Sidorov at version %d", stored.Surname, stored.Version, teacher.Version+1)
+			}
+		})
+	}
+}
+
+func TestUpdateTeacherRejectsUnknownGroups(t *testing.T) {
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			env := newTestEnv(t)
+			group := createGroup(t, env.db, "INF-101")
+			teacher := createTeacherInGroups(t, env, "ivan@example.com", *group)
+
+			w := teacherUpdate(t, env, method, teacher, map[string]interface{}{
+				"name": "Ivan", "surname": "Sidorov", "email": "ivan@example.com",
+				"groups": []map[string]uint{{"id": group.ID}, {"id": 9999}}, "version": teacher.Version,
+			})
+			expectStatus(t, w, http.StatusUnprocessableEntity)
+			if !strings.Contains(w.Body.String(), "9999") {
+				t.Fatalf("response does not name the unknown group: %s", w.Body.String())
+			}
+
+			var stored models.Teacher
+			env.db.First(&stored, teacher.ID)
+			if stored.Surname != "Petrov" || stored.Version != teacher.Version {
+				t.Fatalf("stored = %s at version %d, want the teacher unchanged", stored.Surname, stored.Version)
+			}
+			if got := teacherGroupIDs(t, env, teacher.ID); len(got) != 1 || got[0] != group.ID {
+				t.Fatalf("groups = %v, want [%d]", got, group.ID)
+			}
+		})
+	}
+}
+
+func TestUpdateTeacherRollsBackWhenGroupsFail(t *testing.T) {
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			env := newTestEnv(t)
+			first, second := createGroup(t, env.db, "INF-101"), createGroup(t, env.db, "INF-102")
+			teacher := createTeacherInGroups(t, env, "ivan@example.com", *first)
+
+			// Запись связи с группой падает уже после обновления полей
+			err := env.db.Callback().Create().Before("gorm:create").Register("test:fail_teacher_groups", func(tx *gorm.DB) {
+				if tx.Statement.Table == "teacher_groups" {
+					tx.AddError(errors.New("teacher_groups is unavailable"))
+				}
+			})
+			if err != nil {
+				t.Fatalf("register callback: %v", err)
+			}
+
+			w := teacherUpdate(t, env, method, teacher, map[string]interface{}{
+				"name": "Ivan", "surname": "Sidorov", "email": "ivan@example.com",
+				"groups": []map[string]uint{{"id": second.ID}}, "version": teacher.Version,
+			})
+			expectStatus(t, w, http.StatusInternalServerError)
+
+			var stored models.Teacher
+			env.db.First(&stored, teacher.ID)
+			if stored.Surname != "Petrov" || stored.Version != teacher.Version {
+				t.Fatalf("stored = %s at version %d, want the whole update rolled back", stored.Surname, stored.Version)
+			}
+			if got := teacherGroupIDs(t, env, teacher.ID); len(got) != 1 || got[0] != first.ID {
+				t.Fatalf("groups = %v, want [%d]", got, first.ID)
+			}
+		})
+	}
+}
+
+func TestUpdateTeacherSyncsLinkedUserEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		want  string
+	}{
+		{"new email", "Ivan.Sidorov@Example.com", "ivan.sidorov@example.com"},
+		{"case only", "IVAN@Example.com", "ivan@example.com"},
+	}
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		for _, tt := range tests {
+			t.Run(method+"/"+tt.name, func(t *testing.T) {
+				env := newTestEnv(t)
+				teacher, user := createLinkedTeacher(t, env, "ivan@example.com")
+
+				w := teacherUpdate(t, env, method, teacher, map[string]interface{}{
+					"name": "Ivan", "surname": "Petrov", "email": tt.email, "version": teacher.Version,
+				})
+				expectStatus(t, w, http.StatusOK)
+
+				var storedTeacher models.Teacher
+				env.db.First(&storedTeacher, teacher.ID)
+				var storedUser models.User
+				env.db.First(&storedUser, user.ID)
+				if storedTeacher.Email != tt.want || storedUser.Email != tt.want {
+					t.Fatalf("teacher email = %q, user email = %q; want both %q", storedTeacher.Email, storedUser.Email, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestExportTeachersIncludesTitleAndDepartment(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewTeacherHandler(env.db, env.cfg, env.bus)
+	department := uint(7)
+	professor := models.Teacher{Name: "Ivan", Surname: "Petrov", Email: "ivan@example.com", Title: "Professor", DepartmentID: &department}
+	assistant := models.Teacher{Name: "Olga", Surname: "Sidorova", Email: "olga@example.com"}
+	for _, teacher := range []*models.Teacher{&professor, &assistant} {
+		if err := env.db.Create(teacher).Error; err != nil {
+			t.Fatalf("create teacher: %v", err)
+		}
+	}
+
+	w := serve(t, h.ExportTeachers, request{method: http.MethodGet, target: "/api/teachers/export", claims: adminClaims()})
+	expectStatus(t, w, http.StatusOK)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"id", "name", "surname", "email", "phone", "title", "department_id", "created_at"},
+		{strconv.Itoa(int(professor.ID)), "Ivan", "Petrov", "ivan@example.com", "", "Professor", "7"},
+		{strconv.Itoa(int(assistant.ID)), "Olga", "Sidorova", "olga@example.com", "", "", ""},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("CSV has %d rows, want %d: %v", len(records), len(want), records)
+	}
+	for i, row := range want {
+		if got := records[i][:len(row)]; !reflect.DeepEqual(got, row) {
+			t.Errorf("row %d = %v, want %v", i, got, row)
+		}
+	}
+}
+
+func TestTeacherDepartmentFilter(t *testing.T) {
+	env := newTestEnv(t)
+	h := NewTeacherHandler(env.db, env.cfg, env.bus)
+	department := uint(7)
+	env.db.Create(&models.Teacher{Name: "Ivan", Surname: "Petrov", Email: "ivan@example.com", DepartmentID: &department})
+	env.db.Create(&models.Teacher{Name: "Olga", Surname: "Sidorova", Email: "olga@example.com"})
+
+	w := serve(t, h.GetTeachers, request{method: http.MethodGet, target: "/api/teachers?department_id=7", claims: adminClaims()})
+	expectStatus(t, w, http.StatusOK)
+	var page struct {
+		Items []models.Teacher `json:"items"`
+	}
+	decodeBody(t, w, &page)
+	if len(page.Items) != 1 || page.Items[0].Surname != "Petrov" {
+		t.Fatalf("department_id=7 returned %+v, want only Petrov", page.Items)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{"list", h.GetTeachers, "/api/teachers?department_id=abc"},
+		{"export", h.ExportTeachers, "/api/teachers/export?department_id=-1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, tt.handler, request{method: http.MethodGet, target: tt.target, claims: adminClaims()})
+			expectStatus(t, w, http.StatusBadRequest)
+			if !strings.Contains(w.Body.String(), "Invalid department_id") {
+				t.Fatalf("body = %s, want Invalid department_id", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDeleteTeacherWithoutLinkedUser(t *testing.T) {
+	for _, target := range []string{"/api/teachers/1", "/api/teachers/1?delete_user=true"} {
+		t.Run(target, func(t *testing.T) {
+			env := newTestEnv(t)
+			h := NewTeacherHandler(env.db, env.cfg, env.bus)
+			teacher := createTeacherInGroups(t, env, "ivan@example.com")
+			group := createGroup(t, env.db, "INF-101")
+			env.db.Model(group).Update("curator_id", teacher.ID)
+			// Чужие учетные записи удаление не затрагивает
+			other := createUser(t, env.db, "other@example.com", models.RoleTeacher)
+			id := strconv.Itoa(int(teacher.ID))
+
+			w := serve(t, h.DeleteTeacher, request{
+				method: http.MethodDelete, target: target,
+				claims: adminClaims(), vars: map[string]string{"id": id},
+			})
+			expectStatus(t, w, http.StatusNoContent)
+
+			var deleted models.Teacher
+			if err := env.db.Unscoped().First(&deleted, teacher.ID).Error; err != nil || !deleted.DeletedAt.Valid {
+				t.Fatalf("teacher is not soft-deleted: %+v, %v", deleted, err)
+			}
+			var storedGroup models.Group
+			env.db.First(&storedGroup, group.ID)
+			if storedGroup.CuratorID != nil {
+				t.Fatalf("group curator_id = %d, want it cleared", *storedGroup.CuratorID)
+			}
+			var users int64
+			env.db.Model(&models.User{}).Where("id = ?", other.ID).Count(&users)
+			if users != 1 {
+				t.Fatal("unrelated user was deleted")
+			}
+		})
+	}
+}
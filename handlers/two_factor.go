@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/auth"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+)
+
+// TwoFactorCodeRequest - код из приложения-аутентификатора
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// SetupTwoFactor выдает новый секрет TOTP: POST /api/auth/2fa/setup.
+// Секрет сохраняется, но 2FA не действует до подтверждения кодом через /2fa/enable
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var user models.User
+	if err := db.First(&user, claims.UserID).Error; err != nil {
+		logf(r, "Error fetching user %d for 2FA setup: %v", claims.UserID, err)
+		httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Two-factor authentication is already enabled")
+		return
+	}
+
+	setup, err := auth.GenerateTOTP(user.Email)
+	if err != nil {
+		logf(r, "Error generating TOTP secret for %s: %v", maskEmail(user.Email), err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	if err := db.Model(&user).Update("two_factor_secret", setup.Secret).Error; err != nil {
+		logf(r, "Error saving TOTP secret for %s: %v", maskEmail(user.Email), err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, "2FA setup started: %s", maskEmail(user.Email))
+	httputil.RespondJSON(w, http.StatusOK, setup)
+}
+
+// EnableTwoFactor включает 2FA после проверки первого кода: POST /api/auth/2fa/enable
+func (h *AuthHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	var req TwoFactorCodeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, claims.UserID).Error; err != nil {
+		logf(r, "Error fetching user %d for 2FA enable: %v", claims.UserID, err)
+		httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Two-factor authentication is already enabled")
+		return
+	}
+	if user.TwoFactorSecret == "" {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Call /api/auth/2fa/setup first")
+		return
+	}
+	if !auth.ValidateTOTP(req.Code, user.TwoFactorSecret) {
+		logf(r, "Invalid 2FA code on enable for %s", maskEmail(user.Email))
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid two-factor code")
+		return
+	}
+
+	if err := db.Model(&user).Update("two_factor_enabled", true).Error; err != nil {
+		logf(r, "Error enabling 2FA for %s: %v", maskEmail(user.Email), err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityUser, user.ID, "two-factor authentication enabled")
+
+	logf(r, "2FA enabled: %s", maskEmail(user.Email))
+	httputil.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":            "Two-factor authentication enabled",
+		"two_factor_enabled": true,
+	})
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/auth"
+	"student-backend/models"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCode(secret, at)
+	if err != nil {
+		t.Fatalf("generate TOTP code: %v", err)
+	}
+	return code
+}
+
+func TestTwoFactorSetupEnableAndLogin(t *testing.T) {
+	env := newTestEnv(t)
+	h, _ := env.newAuthHandler(t)
+	user := createUser(t, env.db, "user@example.com", models.RoleStudent)
+
+	// Без setup включать нечего
+	w := serve(t, h.EnableTwoFactor, request{
+		method: http.MethodPost, target: "/api/auth/2fa/enable",
+		body: TwoFactorCodeRequest{Code: "123456"}, claims: claimsOf(user),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = serve(t, h.SetupTwoFactor, request{method: http.MethodPost, target: "/api/auth/2fa/setup", claims: claimsOf(user)})
+	expectStatus(t, w, http.StatusOK)
+	var setup auth.TOTPSetup
+	decodeBody(t, w, &setup)
+	if setup.Secret == "" || setup.URI == "" || setup.QRCode == "" {
+		t.Fatalf("incomplete setup response: %+v", setup)
+	}
+
+	// До подтверждения кодом вход по паролю работает как раньше
+	expectStatus(t, login(t, h, "user@example.com", "password123"), http.StatusOK)
+
+	staleCode := totpCode(t, setup.Secret, time.Now().Add(-time.Hour))
+	w = serve(t, h.EnableTwoFactor, request{
+		method: http.MethodPost, target: "/api/auth/2fa/enable",
+		body: TwoFactorCodeRequest{Code: staleCode}, claims: claimsOf(user),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = serve(t, h.EnableTwoFactor, request{
+		method: http.MethodPost, target: "/api/auth/2fa/enable",
+		body: TwoFactorCodeRequest{Code: totpCode(t, setup.Secret, time.Now())}, claims: claimsOf(user),
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	// Повторный setup не подменяет секрет включенной 2FA
+	w = serve(t, h.SetupTwoFactor, request{method: http.MethodPost, target: "/api/auth/2fa/setup", claims: claimsOf(user)})
+	expectStatus(t, w, http.StatusConflict)
+
+	tests := []struct {
+		name string
+		code string
+		want int
+	}{
+		{"without code", "", http.StatusUnauthorized},
+		{"with stale code", staleCode, http.StatusUnauthorized},
+		{"with current code", totpCode(t, setup.Secret, time.Now()), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, h.Login, request{
+				method: http.MethodPost, target: "/api/auth/login",
+				body: models.LoginRequest{Email: "user@example.com", Password: "password123", Code: tt.code},
+			})
+			expectStatus(t, w, tt.want)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"student-backend/auth"
+	"student-backend/database"
+	"student-backend/httputil"
+	"student-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// deleteUserRequested читает флаг ?delete_user=true.
+// По умолчанию учетная запись отключается и восстанавливается вместе с записью,
+// с флагом удаляется безвозвратно
+func deleteUserRequested(r *http.Request) bool {
+	return r.URL.Query().Get("delete_user") == "true"
+}
+
+// detachLinkedUser обрабатывает учетную запись, связанную с удаляемым студентом или преподавателем.
+// linkColumn - колонка в users ("student_id" или "teacher_id").
+// В обоих случаях учетная запись перестает проходить аутентификацию, и выданные ей
+// токены больше не действуют. По умолчанию она мягко удаляется вместе со ссылкой
+// на запись, при deleteUser=true удаляется безвозвратно вместе с ключами API
+// и токенами сброса пароля, а ее email освобождается для новой регистрации
+func detachLinkedUser(tx *gorm.DB, linkColumn string, recordID uint, deleteUser bool) error {
+	if !deleteUser {
+		return tx.Where(linkColumn+" = ?", recordID).Delete(&models.User{}).Error
+	}
+
+	var userIDs []uint
+	if err := tx.Unscoped().Model(&models.User{}).Where(linkColumn+" = ?", recordID).
+		Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	for _, model := range []interface{}{&models.APIKey{}, &models.PasswordResetToken{}} {
+		if err := tx.Where("user_id IN ?", userIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Unscoped().Delete(&models.User{}, userIDs).Error
+}
+
+// userEmailTaken проверяет без учета регистра, занят ли email учетной записью,
+// кроме excludeID (0 - без исключения). Учитываются и отключенные записи: их email
+// остается за ними до восстановления или окончательного удаления
+func userEmailTaken(tx *gorm.DB, email string, excludeID uint) (bool, error) {
+	var count int64
+	err := tx.Unscoped().Model(&models.User{}).
+		Where("LOWER(email) = LOWER(?) AND id != ?", email, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// errUserEmailTaken - учетная запись с таким email уже существует
+var errUserEmailTaken = errors.New("user with this email already exists")
+
+// accountCredentials - данные созданной учетной записи, возвращаемые один раз
+type accountCredentials struct {
+	UserID   uint        `json:"user_id"`
+	Email    string      `json:"email"`
+	Password string      `json:"password"`
+	Role     models.Role `json:"role"`
+}
+
+// createLinkedAccount создает учетную запись внутри транзакции tx.
+// Если пароль не передан, он генерируется. Занятый email возвращает errUserEmailTaken
+func createLinkedAccount(tx *gorm.DB, email, password string, role models.Role) (*accountCredentials, error) {
+	taken, err := userEmailTaken(tx, email, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, errUserEmailTaken
+	}
+
+	if password == "" {
+		generated, err := auth.GeneratePassword()
+		if err != nil {
+			return nil, err
+		}
+		password = generated
+	}
+
+	// Учетную запись заводит администратор, подтверждение email не требуется.
+	// Пароль хэшируется в User.BeforeSave
+	user := models.User{
+		Email:         email,
+		Password:      password,
+		Role:          role,
+		EmailVerified: true,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &accountCredentials{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Password: password,
+		Role:     role,
+	}, nil
+}
+
+// syncLinkedUserEmail переносит новый email студента или преподавателя в связанную
+// учетную запись, чтобы email входа не расходился с email записи. Вызывается в одной
+// транзакции с изменением записи. Email, занятый другим пользователем, возвращает errUserEmailTaken
+func syncLinkedUserEmail(tx *gorm.DB, userID *uint, email string) error {
+	if userID == nil {
+		return nil
+	}
+
+	taken, err := userEmailTaken(tx, email, *userID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errUserEmailTaken
+	}
+
+	return tx.Model(&models.User{}).Where("id = ?", *userID).Update("email", email).Error
+}
+
+// updateVersionedSyncingEmail выполняет updateVersioned и, если newEmail не пуст,
+// в той же транзакции меняет email связанной учетной записи userID
+func updateVersionedSyncingEmail(db *gorm.DB, model interface{}, expected int, updates map[string]interface{},
+	userID *uint, newEmail string) error {
+	return database.WithTx(db, func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, model, expected, updates); err != nil {
+			return err
+		}
+		if newEmail == "" {
+			return nil
+		}
+		return syncLinkedUserEmail(tx, userID, newEmail)
+	})
+}
+
+// respondLinkedEmailTaken отвечает 409, если новый email записи занят другой учетной записью
+func respondLinkedEmailTaken(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, errUserEmailTaken) {
+		return false
+	}
+	httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict,
+		"Email is already used by another account; the record and its login email are changed together")
+	return true
+}
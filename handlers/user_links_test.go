@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"student-backend/models"
+	"testing"
+)
+
+// linkUser вызывает PATCH /api/users/{id}/link с телом body
+func linkUser(t *testing.T, env *testEnv, userID uint, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	id := strconv.Itoa(int(userID))
+	return serve(t, NewUserHandler(env.db, env.cfg).LinkUser, request{
+		method: http.MethodPatch, target: "/api/users/" + id + "/link", body: body,
+		claims: adminClaims(), vars: map[string]string{"id": id},
+	})
+}
+
+func TestLinkUserRelinksToAnotherProfile(t *testing.T) {
+	t.Run("student", func(t *testing.T) {
+		env := newTestEnv(t)
+		previous, user := createLinkedStudent(t, env, "anna@example.com")
+		next := createStudent(t, env.db, "Anna", "Orlova", "orlova@example.com", nil)
+
+		w := linkUser(t, env, user.ID, fmt.Sprintf(`{"student_id":%d}`, next.ID))
+		expectStatus(t, w, http.StatusOK)
+		var response models.User
+		decodeBody(t, w, &response)
+		if response.Student == nil || response.Student.ID != next.ID {
+			t.Fatalf("response student = %+v, want %d", response.Student, next.ID)
+		}
+
+		var stored models.User
+		env.db.First(&stored, user.ID)
+		var relinked, released models.Student
+		env.db.First(&relinked, next.ID)
+		env.db.First(&released, previous.ID)
+		if stored.StudentID == nil || *stored.StudentID != next.ID {
+			t.Fatalf("user student_id = %v, want %d", stored.StudentID, next.ID)
+		}
+		if relinked.UserID == nil || *relinked.UserID != user.ID {
+			t.Fatalf("new student user_id = %v, want %d", relinked.UserID, user.ID)
+		}
+		if released.UserID != nil {
+			t.Fatalf("previous student still points to user %d", *released.UserID)
+		}
+	})
+
+	t.Run("teacher", func(t *testing.T) {
+		env := newTestEnv(t)
+		previous, user := createLinkedTeacher(t, env, "ivan@example.com")
+		next := createTeacherInGroups(t, env, "petrov@example.com")
+
+		expectStatus(t, linkUser(t, env, user.ID, fmt.Sprintf(`{"teacher_id":%d}`, next.ID)), http.StatusOK)
+
+		var stored models.User
+		env.db.First(&stored, user.ID)
+		var relinked, released models.Teacher
+		env.db.First(&relinked, next.ID)
+		env.db.First(&released, previous.ID)
+		if stored.TeacherID == nil || *stored.TeacherID != next.ID || relinked.UserID == nil || *relinked.UserID != user.ID {
+			t.Fatalf("user teacher_id = %v, new teacher user_id = %v; want both linked", stored.TeacherID, relinked.UserID)
+		}
+		if released.UserID != nil {
+			t.Fatalf("previous teacher still points to user %d", *released.UserID)
+		}
+	})
+}
+
+func TestLinkUserRejectsProfileLinkedToAnotherUser(t *testing.T) {
+	env := newTestEnv(t)
+	own, user := createLinkedStudent(t, env, "anna@example.com")
+	taken, owner := createLinkedStudent(t, env, "boris@example.com")
+
+	w := linkUser(t, env, user.ID, fmt.Sprintf(`{"student_id":%d}`, taken.ID))
+	expectStatus(t, w, http.StatusConflict)
+
+	// Обе связи остаются прежними
+	for _, link := range []struct {
+		user    *models.User
+		student *models.Student
+	}{{user, own}, {owner, taken}} {
+		var stored models.User
+		env.db.First(&stored, link.user.ID)
+		var student models.Student
+		env.db.First(&student, link.student.ID)
+		if stored.StudentID == nil || *stored.StudentID != link.student.ID || student.UserID == nil || *student.UserID != link.user.ID {
+			t.Fatalf("user %d: student_id = %v, student %d user_id = %v; want the original link",
+				link.user.ID, stored.StudentID, link.student.ID, student.UserID)
+		}
+	}
+}
+
+func TestLinkUserValidation(t *testing.T) {
+	env := newTestEnv(t)
+	_, user := createLinkedStudent(t, env, "anna@example.com")
+	teacher := createTeacherInGroups(t, env, "ivan@example.com")
+
+	tests := []struct {
+		name   string
+		userID uint
+		body   string
+		want   int
+	}{
+		{"both links", user.ID, `{"student_id":1,"teacher_id":1}`, http.StatusBadRequest},
+		{"no link", user.ID, `{}`, http.StatusBadRequest},
+		{"role mismatch", user.ID, fmt.Sprintf(`{"teacher_id":%d}`, teacher.ID), http.StatusBadRequest},
+		{"unknown profile", user.ID, `{"student_id":9999}`, http.StatusNotFound},
+		{"unknown user", 9999, `{"student_id":1}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, linkUser(t, env, tt.userID, tt.body), tt.want)
+		})
+	}
+}
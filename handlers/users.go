@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/httputil"
+	"student-backend/middleware"
+	"student-backend/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type UserHandler struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewUserHandler(db *gorm.DB, cfg *config.Config) *UserHandler {
+	return &UserHandler{db: db, cfg: cfg}
+}
+
+var (
+	// errLinkTargetNotFound - привязываемая запись студента или преподавателя не найдена
+	errLinkTargetNotFound = errors.New("link target not found")
+	// errLinkTargetOwned - запись уже связана с другой учетной записью
+	errLinkTargetOwned = errors.New("link target already linked to another user")
+)
+
+// LinkUser перепривязывает учетную запись к записи студента ({"student_id": X})
+// или преподавателя ({"teacher_id": Y}). Обе стороны связи (users.*_id и *.user_id)
+// обновляются в одной транзакции, прежняя запись отвязывается. Только для админа
+func (h *UserHandler) LinkUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !authorize(w, r, models.ActionUpdate, models.ResourceUsers) {
+		return
+	}
+
+	db, cancel := requestDB(r, h.db, h.cfg.DBQueryTimeout)
+	defer cancel()
+
+	claims := middleware.GetUserClaims(r.Context())
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		logf(r, "Error converting id to int: %v", err)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Invalid user ID")
+		return
+	}
+
+	var linkReq struct {
+		StudentID *uint `json:"student_id"`
+		TeacherID *uint `json:"teacher_id"`
+	}
+	if !decodeRequest(w, r, &linkReq) {
+		return
+	}
+
+	if (linkReq.StudentID == nil) == (linkReq.TeacherID == nil) {
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeValidationFailed, "Exactly one of student_id or teacher_id is required")
+		return
+	}
+
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logf(r, "User with ID %d not found", id)
+			httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "User not found")
+			return
+		}
+		logf(r, "Error fetching user: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	// Колонка связи в users, модель привязываемой записи и текущее значение ссылки
+	linkColumn, role := "student_id", models.RoleStudent
+	var targetModel interface{} = &models.Student{}
+	targetID, current := linkReq.StudentID, user.StudentID
+	if linkReq.TeacherID != nil {
+		linkColumn, role = "teacher_id", models.RoleTeacher
+		targetModel = &models.Teacher{}
+		targetID, current = linkReq.TeacherID, user.TeacherID
+	}
+
+	if user.Role != role {
+		logf(r, "Cannot link %s to user %d with role %s", linkColumn, user.ID, user.Role)
+		httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, fmt.Sprintf("Only %s accounts can be linked via %s", role, linkColumn))
+		return
+	}
+
+	err = database.WithTx(db, func(tx *gorm.DB) error {
+		var target struct {
+			ID     uint
+			UserID *uint
+		}
+		if err := tx.Model(targetModel).Select("id", "user_id").Where("id = ?", *targetID).Take(&target).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errLinkTargetNotFound
+			}
+			return err
+		}
+		if target.UserID != nil && *target.UserID != user.ID {
+			return errLinkTargetOwned
+		}
+
+		var owners int64
+		if err := tx.Model(&models.User{}).Where(linkColumn+" = ? AND id <> ?", *targetID, user.ID).Count(&owners).Error; err != nil {
+			return err
+		}
+		if owners > 0 {
+			return errLinkTargetOwned
+		}
+
+		// Отвязываем прежнюю запись, чтобы у нее не осталась ссылка на эту учетную запись
+		if current != nil && *current != *targetID {
+			if err := tx.Model(targetModel).Where("id = ?", *current).Update("user_id", nil).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&user).Update(linkColumn, *targetID).Error; err != nil {
+			return err
+		}
+		return tx.Model(targetModel).Where("id = ?", *targetID).Update("user_id", user.ID).Error
+	})
+	switch {
+	case errors.Is(err, errLinkTargetNotFound):
+		logf(r, "Link target %s=%d not found", linkColumn, *targetID)
+		httputil.RespondError(w, http.StatusNotFound, httputil.CodeNotFound, "Linked record not found")
+		return
+	case errors.Is(err, errLinkTargetOwned):
+		logf(r, "Link target %s=%d already linked to another user", linkColumn, *targetID)
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Record is already linked to another user")
+		return
+	case err != nil:
+		logf(r, "Error relinking user %d: %v", user.ID, err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	logf(r, "User %d linked to %s=%d (by admin %s)", user.ID, linkColumn, *targetID, claims.Email)
+	recordAudit(h.db, claims, models.AuditActionUpdate, models.AuditEntityUser, user.ID,
+		fmt.Sprintf("linked %s=%d", linkColumn, *targetID))
+
+	var updated models.User
+	if err := db.Preload("Student").Preload("Teacher").First(&updated, user.ID).Error; err != nil {
+		logf(r, "Error fetching user: %v", err)
+		respondDBError(w, err, "Internal server error")
+		return
+	}
+
+	httputil.RespondJSON(w, http.StatusOK, updated)
+}
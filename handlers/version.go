@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"student-backend/httputil"
+
+	"gorm.io/gorm"
+)
+
+// Оптимистическая блокировка: у студентов, преподавателей и групп есть колонка version.
+// Клиент присылает версию, которую прочитал, а обновление выполняется с условием
+// WHERE version = ? и увеличивает версию. Если запись успела измениться, строк
+// не затронуто и клиент получает 409
+
+// errVersionConflict - запись изменена другим запросом после того, как клиент ее прочитал
+var errVersionConflict = errors.New("version conflict")
+
+// versionBump - выражение для увеличения версии в UPDATE
+var versionBump = gorm.Expr("version + 1")
+
+// requireVersion проверяет, что клиент передал версию записи, иначе отвечает 428
+func requireVersion(w http.ResponseWriter, version int) bool {
+	if version < 1 {
+		httputil.RespondError(w, http.StatusPreconditionRequired, httputil.CodePreconditionRequired, "version is required, send the version you last read")
+		return false
+	}
+	return true
+}
+
+// updateVersioned применяет изменения к записи model, только если ее версия в базе
+// равна expected, и увеличивает версию. Пустой набор изменений тоже увеличивает версию
+func updateVersioned(db *gorm.DB, model interface{}, expected int, updates map[string]interface{}) error {
+	values := make(map[string]interface{}, len(updates)+1)
+	for column, value := range updates {
+		values[column] = value
+	}
+	values["version"] = versionBump
+
+	result := db.Model(model).Where("version = ?", expected).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	return nil
+}
+
+// respondVersionConflict отвечает 409 при конфликте версий и возвращает true,
+// если ошибка была конфликтом версий
+func respondVersionConflict(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, errVersionConflict) {
+		return false
+	}
+	httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict, "Record was modified by another request, reload it and retry")
+	return true
+}
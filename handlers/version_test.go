@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"student-backend/models"
+	"testing"
+)
+
+func TestPatchWithVersion(t *testing.T) {
+	env := newTestEnv(t)
+	students := NewStudentHandler(env.db, env.cfg, env.bus)
+	teachers := NewTeacherHandler(env.db, env.cfg, env.bus)
+	groups := NewGroupHandler(env.db, env.cfg)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		create  func(t *testing.T) uint
+	}{
+		{"student", students.PatchStudent, func(t *testing.T) uint {
+			return createStudent(t, env.db, "Anna", "Smirnova", "", nil).ID
+		}},
+		{"teacher", teachers.PatchTeacher, func(t *testing.T) uint {
+			teacher, _ := createLinkedTeacher(t, env, "teacher@example.com")
+			return teacher.ID
+		}},
+		{"group", groups.PatchGroup, func(t *testing.T) uint {
+			return createGroup(t, env.db, "V-1").ID
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := tt.create(t)
+			patch := func(body map[string]interface{}) *httptest.ResponseRecorder {
+				return serve(t, tt.handler, request{
+					method: http.MethodPatch, target: "/" + strconv.Itoa(int(id)),
+					body: body, claims: adminClaims(), vars: groupVars(id),
+				})
+			}
+
+			w := patch(map[string]interface{}{"name": "Changed", "version": 1})
+			expectStatus(t, w, http.StatusOK)
+			var updated struct {
+				Name    string `json:"name"`
+				Version int    `json:"version"`
+			}
+			decodeBody(t, w, &updated)
+			if updated.Name != "Changed" || updated.Version != 2 {
+				t.Fatalf("updated = %+v, want name Changed at version 2", updated)
+			}
+
+			// Клиент, прочитавший версию 1, не перезаписывает чужое изменение
+			expectStatus(t, patch(map[string]interface{}{"name": "Stale", "version": 1}), http.StatusConflict)
+			expectStatus(t, patch(map[string]interface{}{"name": "Unversioned"}), http.StatusPreconditionRequired)
+		})
+	}
+
+	var student models.Student
+	env.db.Where("name = ?", "Stale").Or("name = ?", "Unversioned").Limit(1).Find(&student)
+	if student.ID != 0 {
+		t.Fatalf("rejected update was applied to student %d", student.ID)
+	}
+}
+
+func TestTransferBumpsStudentVersion(t *testing.T) {
+	env := newTestEnv(t)
+	students := NewStudentHandler(env.db, env.cfg, env.bus)
+	groups := NewGroupHandler(env.db, env.cfg)
+	source := createGroup(t, env.db, "S-1")
+	target := createGroup(t, env.db, "T-1")
+	student := createStudent(t, env.db, "Anna", "Smirnova", "", &source.ID)
+
+	w := serve(t, groups.TransferStudents, request{
+		method: http.MethodPost, target: "/api/groups/transfer",
+		body: TransferStudentsRequest{TargetGroupID: target.ID}, claims: adminClaims(), vars: groupVars(source.ID),
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	// Перевод - тоже изменение записи: версия, прочитанная до него, устарела
+	w = serve(t, students.PatchStudent, request{
+		method: http.MethodPatch, target: "/api/students",
+		body: map[string]interface{}{"name": "Stale", "version": student.Version}, claims: adminClaims(), vars: groupVars(student.ID),
+	})
+	expectStatus(t, w, http.StatusConflict)
+}
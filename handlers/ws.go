@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"student-backend/middleware"
+	"student-backend/models"
+	"student-backend/realtime"
+)
+
+type WSHandler struct {
+	hub *realtime.Hub
+}
+
+func NewWSHandler(hub *realtime.Hub) *WSHandler {
+	return &WSHandler{hub: hub}
+}
+
+// Serve подключает клиента к потоку изменений студентов и преподавателей.
+// Токен передается в параметре token, так как браузер не может задать
+// заголовки для WebSocket. События о преподавателях получают только админы
+func (h *WSHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	if !authorize(w, r, models.ActionRead, models.ResourceLiveUpdates) {
+		return
+	}
+
+	claims := middleware.GetUserClaims(r.Context())
+	// Upgrade сам отвечает клиенту при ошибке рукопожатия
+	if err := h.hub.Serve(w, r, claims.Role == models.RoleAdmin); err != nil {
+		logf(r, "❌ WebSocket upgrade failed for %s: %v", claims.Email, err)
+		return
+	}
+	logf(r, "WebSocket client connected: %s (role: %s)", claims.Email, claims.Role)
+}
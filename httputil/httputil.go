@@ -0,0 +1,101 @@
+package httputil
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// Коды ошибок API: стабильные строки, по которым клиент различает ошибки,
+// не разбирая текст сообщения
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidBody          = "invalid_body"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeIPNotAllowed         = "ip_not_allowed"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMedia     = "unsupported_media_type"
+	CodePreconditionRequired = "precondition_required"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+	CodeTimeout              = "timeout"
+)
+
+// requestIDHeader совпадает с middleware.RequestIDHeader: middleware выставляет
+// его в ответ до вызова обработчика, поэтому ID берется из заголовков ответа
+const requestIDHeader = "X-Request-ID"
+
+// envelope включает формат {"error": {"code", "message", "details", "request_id"}}.
+// По умолчанию выключен: фронтенд разбирает плоский {"error": "...", "code": "..."},
+// см. ERROR_ENVELOPE в config
+var envelope atomic.Bool
+
+// SetEnvelope переключает формат ошибок
+func SetEnvelope(enabled bool) {
+	envelope.Store(enabled)
+}
+
+// ErrorBody - ошибка в формате конверта
+type ErrorBody struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Details   []interface{} `json:"details,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+}
+
+// RespondJSON пишет payload в формате JSON с указанным статусом
+func RespondJSON(w http.ResponseWriter, status int, payload interface{}) {
+	RespondJSONAs(w, status, MediaTypeJSON, payload)
+}
+
+// RespondJSONAs пишет payload в формате JSON с указанным Content-Type,
+// например MediaTypeJSONAPI для ответов в стиле JSON:API
+func RespondJSONAs(w http.ResponseWriter, status int, contentType string, payload interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Printf("❌ Error encoding response: %v", err)
+	}
+}
+
+// RespondError пишет ошибку с кодом и сообщением. details - дополнительные сведения:
+// в конверте они попадают в поле details, в плоском формате поля из map
+// переносятся на верхний уровень, как было в прежних ответах
+func RespondError(w http.ResponseWriter, status int, code, message string, details ...interface{}) {
+	requestID := w.Header().Get(requestIDHeader)
+
+	if envelope.Load() {
+		RespondJSON(w, status, map[string]interface{}{
+			"error": ErrorBody{Code: code, Message: message, Details: details, RequestID: requestID},
+		})
+		return
+	}
+
+	body := map[string]interface{}{}
+	var rest []interface{}
+	for _, detail := range details {
+		if fields, ok := detail.(map[string]interface{}); ok {
+			for key, value := range fields {
+				body[key] = value
+			}
+			continue
+		}
+		rest = append(rest, detail)
+	}
+	if len(rest) > 0 {
+		body["details"] = rest
+	}
+	body["error"] = message
+	body["code"] = code
+	if requestID != "" {
+		body["request_id"] = requestID
+	}
+	RespondJSON(w, status, body)
+}
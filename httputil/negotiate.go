@@ -0,0 +1,31 @@
+package httputil
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Типы содержимого ответов API
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeJSONAPI = "application/vnd.api+json"
+)
+
+// WantsJSONAPI сообщает, запросил ли клиент ответ в стиле JSON:API.
+// Формат включается только явным application/vnd.api+json в Accept:
+// */* и application/json оставляют обычный формат
+func WantsJSONAPI(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || mediaType != MediaTypeJSONAPI {
+			continue
+		}
+		// q=0 означает, что клиент такой формат не принимает
+		if q := params["q"]; q == "0" || q == "0.0" || q == "0.00" || q == "0.000" {
+			continue
+		}
+		return true
+	}
+	return false
+}
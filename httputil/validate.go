@@ -0,0 +1,133 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate проверяет структуры по тегам `validate:"..."`. Имена полей в ошибках
+// берутся из json-тегов, чтобы совпадать с телом запроса
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	// valid - значение типа с методом IsValid, например роль пользователя
+	v.RegisterValidation("valid", func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(interface{ IsValid() bool })
+		return ok && value.IsValid()
+	})
+	return v
+}
+
+// FieldError - ошибка проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError - тело запроса разобрано, но не прошло проверку
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Details возвращает ошибки полей для RespondError
+func (e *ValidationError) Details() []interface{} {
+	details := make([]interface{}, len(e.Fields))
+	for i, field := range e.Fields {
+		details[i] = field
+	}
+	return details
+}
+
+// Validate проверяет структуру по тегам validate. Возвращает *ValidationError
+func Validate(dst interface{}) error {
+	err := validate.Struct(dst)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	fields := make([]FieldError, len(fieldErrs))
+	for i, fieldErr := range fieldErrs {
+		fields[i] = FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: fieldMessage(fieldErr),
+		}
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// DecodeAndValidate разбирает JSON из тела запроса в dst и проверяет его.
+// Ошибка разбора возвращается как есть, ошибка проверки - как *ValidationError
+func DecodeAndValidate(r *http.Request, dst interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// fieldPath возвращает путь к полю без имени корневой структуры: "groups[0].code"
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fieldErr.Field()
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	field := fieldPath(fieldErr)
+	switch fieldErr.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	case "min":
+		if fieldErr.Kind() == reflect.String && fieldErr.Param() == "1" {
+			return fmt.Sprintf("%s must not be empty", field)
+		}
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters", field, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", field, fieldErr.Param())
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters", field, fieldErr.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, fieldErr.Param())
+	case "valid":
+		return fmt.Sprintf("%s has an invalid value", field)
+	case "gte", "gt", "lte", "lt":
+		return fmt.Sprintf("%s must be %s %s", field, fieldErr.Tag(), fieldErr.Param())
+	default:
+		return fmt.Sprintf("%s failed %s validation", field, fieldErr.Tag())
+	}
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"log"
+	"student-backend/config"
+)
+
+// Mailer отправляет письма пользователям
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// ConsoleMailer не отправляет письма, а пишет их в лог. Используется при разработке
+type ConsoleMailer struct{}
+
+func NewConsoleMailer() *ConsoleMailer {
+	return &ConsoleMailer{}
+}
+
+func (m *ConsoleMailer) Send(to, subject, body string) error {
+	log.Printf("📧 Mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// New выбирает реализацию по конфигурации: SMTP при заданном SMTP_HOST, иначе вывод в лог
+func New(cfg *config.Config) Mailer {
+	if cfg.SMTPHost == "" {
+		log.Println("📧 SMTP_HOST is not set, emails will be logged to console")
+		return NewConsoleMailer()
+	}
+	return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPFrom)
+}
@@ -1,27 +1,106 @@
 package main
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
 	"student-backend/auth"
 	"student-backend/config"
 	"student-backend/database"
+	"student-backend/docs"
+	"student-backend/events"
+	"student-backend/features"
 	"student-backend/handlers"
+	"student-backend/httputil"
+	"student-backend/mailer"
 	"student-backend/middleware"
+	"student-backend/models"
+	"student-backend/realtime"
+	"student-backend/telemetry"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"golang.org/x/crypto/acme/autocert"
+	"gorm.io/gorm"
 )
 
 func main() {
+	// -seed заполняет начальные данные и завершает работу, не запуская сервер
+	seedOnly := flag.Bool("seed", false, "seed initial data (admin and SEED_FILE or built-in fixture) and exit")
+	// -migrate выполняет команду миграций и завершает работу
+	migrateCommand := flag.String("migrate", "", "run a migration command and exit: up, status or reset")
+	confirmReset := flag.String("confirm", "", "database name confirming -migrate reset; asked interactively when empty")
+	flag.Parse()
+
 	log.Println(" Starting Student Backend Server with Authentication...")
 
 	// Загрузка конфигурации
 	cfg := config.Load()
 	log.Printf(" Configuration loaded: Server Port %s", cfg.ServerPort)
 
-	// Инициализация подключения к базе данных
+	httputil.SetEnvelope(cfg.ErrorEnvelope)
+	models.SetPasswordCost(cfg.BcryptCost)
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(" Invalid configuration: ", err)
+	}
+
+	if *migrateCommand != "" {
+		if err := runMigrateCommand(cfg, *migrateCommand, *confirmReset); err != nil {
+			log.Fatal(" Migration command failed: ", err)
+		}
+		return
+	}
+
+	// Удаление данных при старте возможно только явным DB_RESET=true и не в production
+	if cfg.DBReset {
+		if err := checkResetAllowed(cfg); err != nil {
+			log.Fatal(" DB_RESET refused: ", err)
+		}
+		log.Printf("⚠️ DB_RESET=true: ALL DATA in database %s on %s will be dropped and re-seeded", cfg.DBName, cfg.DBHost)
+	}
+
+	// Ошибки в настройках TLS обнаруживаются до подключения к базе
+	if err := validateTLSConfig(cfg); err != nil {
+		log.Fatal(" Invalid TLS configuration: ", err)
+	}
+
+	// Файл начальных данных проверяется до подключения к базе и до DB_RESET
+	if _, err := database.LoadFixture(cfg.SeedFile); err != nil {
+		log.Fatal(" Invalid seed fixture: ", err)
+	}
+
+	// Трассировка: без OTLP endpoint спаны не отправляются
+	shutdownTracing, err := telemetry.Init(context.Background(), cfg)
+	if err != nil {
+		log.Fatal(" Error initializing tracing:", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Сервер принимает соединения уже во время подключения к базе, миграций
+	// и заполнения данных: до открытия StartupGate проверка готовности и API
+	// отвечают 503, а проверки живости - 200
+	serverAddr := ":" + cfg.ServerPort
+	startupGate := middleware.NewStartupGate()
+	server := newServer(cfg, serverAddr, startupGate)
+	serveErr := make(chan error, 1)
+	if !*seedOnly {
+		go func() {
+			serveErr <- serve(cfg, server)
+		}()
+	}
+
+	// Подключение к базе данных и миграции
 	db, err := database.InitDB(cfg)
 	if err != nil {
 		log.Fatal(" Error initializing database:", err)
@@ -34,111 +113,563 @@ func main() {
 	}
 	defer sqlDB.Close()
 
+	// Заполнение начальных данных идемпотентно и выполняется при каждом запуске
+	if err := database.Seed(db, cfg); err != nil {
+		log.Fatal(" Error seeding database:", err)
+	}
+	if *seedOnly {
+		log.Println(" Seeding completed, exiting")
+		return
+	}
+
+	app, err := newApplication(cfg, db)
+	if err != nil {
+		log.Fatal(" Error initializing server: ", err)
+	}
+	go toggleMaintenanceOnSIGHUP(app.maintenance)
+
+	scheme := "http"
+	if cfg.TLSEnabled() {
+		scheme = "https"
+	}
+	log.Printf(" Server successfully started on %s", serverAddr)
+	log.Printf(" Available at: %s://localhost%s", scheme, serverAddr)
+	log.Printf(" JWT Expiry: %d hours", cfg.JWTExpiry)
+	for role, hours := range cfg.JWTRoleExpiry {
+		log.Printf(" JWT Expiry for %s: %d hours", role, hours)
+	}
+	log.Printf(" DB query timeout: %v", cfg.DBQueryTimeout)
+
+	log.Printf(" Server timeouts: read header %v, read %v, write %v, idle %v, max header bytes %d",
+		server.ReadHeaderTimeout, server.ReadTimeout, server.WriteTimeout, server.IdleTimeout, server.MaxHeaderBytes)
+
+	// Остановка по SIGINT/SIGTERM: сначала /readyz начинает отвечать 503,
+	// затем сервер дожидается завершения текущих запросов
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		shutdownOnSignal(cfg, server, app.health)
+	}()
+
+	app.open(startupGate)
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(" Server error: ", err)
+	}
+	<-stopped
+	app.Close()
+	log.Println(" Server stopped")
+}
+
+// application - роутер и компоненты сервера, которыми управляет main
+type application struct {
+	// handler - роутер за общим ограничителем частоты запросов
+	handler     http.Handler
+	router      *mux.Router
+	health      *handlers.HealthHandler
+	maintenance *middleware.Maintenance
+	auditTrail  *middleware.AuditTrail
+	bus         *events.Bus
+}
+
+// newApplication собирает middleware, обработчики и маршруты поверх подключенной
+// и заполненной базы и запускает их фоновые задачи
+func newApplication(cfg *config.Config, db *gorm.DB) (*application, error) {
 	// Инициализация JWT сервиса
-	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiry)
+	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiry, cfg.JWTRoleExpiry)
 
 	// Инициализация middleware
-	authMiddleware := middleware.NewAuthMiddleware(jwtService)
+	refreshWindowPercent := 0
+	if cfg.TokenRefreshEnabled {
+		refreshWindowPercent = cfg.TokenRefreshWindowPercent
+	}
+	authMiddleware := middleware.NewAuthMiddleware(jwtService, db, refreshWindowPercent)
+
+	// Адрес клиента за прокси определяется одинаково для всех ограничителей,
+	// журнала доступа и списка разрешенных сетей
+	clientIP, err := middleware.NewClientIP(cfg.TrustProxy, cfg.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	authRateLimiter := middleware.NewAuthRateLimiter(cfg.AuthRateLimitPerMinute, clientIP)
 
 	// Инициализация обработчиков
-	authHandler := handlers.NewAuthHandler(db, jwtService)
-	studentHandler := handlers.NewStudentHandler(db)
-	teacherHandler := handlers.NewTeacherHandler(db)
-	groupHandler := handlers.NewGroupHandler(db)
+	// Режим обслуживания переключается администратором или сигналом SIGHUP
+	maintenance := middleware.NewMaintenance(cfg.MaintenanceMode, cfg.MaintenanceRetryAfter, cfg.MaintenanceBypassSecret)
+
+	// Флаги функциональности: сохраненные значения перекрывают значения по умолчанию
+	flags := features.New(db)
+	flags.Bind(features.MaintenanceMode, maintenance.Enabled, maintenance.SetEnabled)
+	if err := flags.Load(); err != nil {
+		return nil, fmt.Errorf("load feature flags: %w", err)
+	}
+
+	mail := mailer.New(cfg)
+	authHandler := handlers.NewAuthHandler(db, jwtService, cfg, mail, flags)
+	// Рассылка изменений студентов и преподавателей подключенным к /ws клиентам
+	hub := realtime.NewHub()
+	go hub.Run()
+
+	// Обработчики публикуют доменные события, а аудит, рассылка в /ws
+	// и письма подписаны на них здесь
+	bus := events.NewBus()
+	handlers.SubscribeAudit(bus, db)
+	handlers.SubscribeLiveUpdates(bus, hub)
+	if cfg.NotifyOnCreate {
+		handlers.SubscribeCreateNotifications(bus, mail)
+	}
+
+	studentHandler := handlers.NewStudentHandler(db, cfg, bus)
+	teacherHandler := handlers.NewTeacherHandler(db, cfg, bus)
+	groupHandler := handlers.NewGroupHandler(db, cfg)
+	auditHandler := handlers.NewAuditHandler(db, cfg)
+	userHandler := handlers.NewUserHandler(db, cfg)
+	apiKeyHandler := handlers.NewAPIKeyHandler(db, cfg)
+	maintenanceHandler := handlers.NewMaintenanceHandler(db, cfg, maintenance, flags)
+	if err := maintenanceHandler.LoadNotice(); err != nil {
+		return nil, fmt.Errorf("load maintenance notice: %w", err)
+	}
+	featureFlagHandler := handlers.NewFeatureFlagHandler(db, cfg, flags)
+	healthHandler := handlers.NewHealthHandler(db)
+	wsHandler := handlers.NewWSHandler(hub)
+
+	ipAllowlist, err := middleware.NewIPAllowlist(cfg.AdminAllowedCIDRs, clientIP)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ADMIN_ALLOWED_CIDRS: %w", err)
+	}
+
+	// Повтор запроса создания с тем же Idempotency-Key возвращает сохраненный ответ
+	idempotency := middleware.NewIdempotency(db, cfg.IdempotencyKeyTTL)
+	go idempotency.Sweep(time.Hour)
+
+	// Журнал изменяющих запросов пишется в фоне
+	auditTrail := middleware.NewAuditTrail(db)
+	go auditTrail.Run()
+	eventsHandler := handlers.NewEventsHandler(auditTrail)
 
 	// Создание роутера
 	r := mux.NewRouter()
 
 	// Добавление middleware CORS для всех маршрутов
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Tracing)
+	r.Use(middleware.SecurityHeaders(cfg.SecurityHeaders, cfg.TrustProxy))
 	r.Use(middleware.CORS)
-	r.Use(loggingMiddleware)
+	r.Use(middleware.AccessLog(cfg.AccessLogFormat, cfg.AccessLogExcludePaths, clientIP))
+	r.Use(maintenance.Middleware)
+	r.Use(middleware.RequireJSON())
+	r.Use(middleware.LimitBody(cfg.MaxBodyBytes, nil))
+	r.Use(middleware.Timeout(cfg.RequestTimeout, map[string]time.Duration{
+		"/api/teachers/export": cfg.ExportRequestTimeout,
+		// Соединение WebSocket живет дольше любого запроса
+		"/ws": 0,
+		// Поток событий открыт до отключения клиента
+		"/api/events": 0,
+	}))
 
 	// Маршруты
-	setupRoutes(r, authHandler, studentHandler, teacherHandler, groupHandler, authMiddleware)
+	setupRoutes(r, authHandler, studentHandler, teacherHandler, groupHandler, auditHandler, userHandler, apiKeyHandler, maintenanceHandler, featureFlagHandler, healthHandler, wsHandler, eventsHandler, idempotency, auditTrail, ipAllowlist, authMiddleware, authRateLimiter)
+
+	// Общий лимит запросов применяется до маршрутизации
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitRPS, cfg.RateLimitBurst, clientIP)
+
+	return &application{
+		handler:     rateLimiter.Limit(r),
+		router:      r,
+		health:      healthHandler,
+		maintenance: maintenance,
+		auditTrail:  auditTrail,
+		bus:         bus,
+	}, nil
+}
 
-	serverAddr := ":" + cfg.ServerPort
-	log.Printf(" Server successfully started on %s", serverAddr)
-	log.Printf(" Available at: http://localhost%s", serverAddr)
-	log.Printf(" JWT Expiry: %d hours", cfg.JWTExpiry)
+// Close дожидается записи журнала изменяющих запросов и обработки доменных событий
+func (a *application) Close() {
+	a.auditTrail.Close()
+	a.bus.Close()
+}
 
-	log.Fatal(http.ListenAndServe(serverAddr, r))
+// open завершает запуск: запросы из StartupGate идут в роутер, /readyz отвечает 200
+func (a *application) open(gate *middleware.StartupGate) {
+	gate.Open(a.handler)
+	a.health.SetReady(true)
 }
 
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+// checkResetAllowed запрещает удаление данных в production
+func checkResetAllowed(cfg *config.Config) error {
+	if cfg.Production {
+		return errors.New("resetting the database is not allowed in production")
+	}
+	return nil
+}
 
-		// Создаем обертку для response writer для захвата статуса
-		rw := &responseWriter{w, http.StatusOK}
+// confirmDatabaseReset требует ввести имя базы: из -confirm или с клавиатуры
+func confirmDatabaseReset(cfg *config.Config, confirmation string) error {
+	if confirmation == "" {
+		fmt.Printf("This will drop ALL tables in database %s on %s.\nType the database name to confirm: ", cfg.DBName, cfg.DBHost)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read confirmation: %w", err)
+		}
+		confirmation = strings.TrimSpace(line)
+	}
+	if confirmation != cfg.DBName {
+		return errors.New("confirmation does not match the database name, nothing was dropped")
+	}
+	return nil
+}
+
+// runMigrateCommand выполняет команду -migrate: up применяет миграции, status
+// печатает состояние шагов, reset удаляет все таблицы, создает схему заново
+// и заполняет начальные данные.
+// reset запрещен в production и требует подтверждения именем базы
+func runMigrateCommand(cfg *config.Config, command, confirmation string) error {
+	switch command {
+	case "up", "status":
+	case "reset":
+		if err := checkResetAllowed(cfg); err != nil {
+			return err
+		}
+		// После сброса база заполняется заново, поэтому файл проверяется заранее
+		if _, err := database.LoadFixture(cfg.SeedFile); err != nil {
+			return err
+		}
+		if err := confirmDatabaseReset(cfg, confirmation); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown command %q, expected up, status or reset", command)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	switch command {
+	case "status":
+		statuses, err := database.Status(db)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-30s  %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	case "reset":
+		log.Printf("⚠️ Resetting database %s on %s", cfg.DBName, cfg.DBHost)
+		if err := database.Reset(db); err != nil {
+			return err
+		}
+		if err := database.Migrate(db, cfg); err != nil {
+			return err
+		}
+		return database.Seed(db, cfg)
+	}
+	return database.Migrate(db, cfg)
+}
+
+// verifyPublicRoutes сверяет роутер со списком middleware.PublicRoutes: каждый
+// маршрут из списка зарегистрирован, а на публичном подроутере API нет ничего
+// сверх списка. Так защищенный обработчик не окажется случайно без аутентификации
+func verifyPublicRoutes(r, publicAPI *mux.Router) error {
+	registered := make(map[string]bool)
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if template, err := route.GetPathTemplate(); err == nil {
+			registered[template] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range middleware.PublicRoutes {
+		if !registered[path] {
+			return fmt.Errorf("public route %s is not registered", path)
+		}
+	}
+
+	return publicAPI.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		if !middleware.IsPublicRoute(template) {
+			return fmt.Errorf("route %s is registered without authentication but is not in PublicRoutes", template)
+		}
+		return nil
+	})
+}
+
+// serve запускает сервер по HTTP или по HTTPS: с сертификатом из файлов
+// либо с сертификатом Let's Encrypt для TLS_AUTOCERT_DOMAINS
+func serve(cfg *config.Config, server *http.Server) error {
+	if !cfg.TLSEnabled() {
+		return server.ListenAndServe()
+	}
+
+	if cfg.TLSAutocert() {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.TLSAutocertDomains...),
+			Cache:      autocert.DirCache(cfg.TLSAutocertCacheDir),
+			Email:      cfg.TLSAutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+
+		// Порт 80 нужен для проверки HTTP-01, остальные запросы перенаправляются на HTTPS
+		redirectPort := cfg.HTTPRedirectPort
+		if redirectPort == "" {
+			redirectPort = "80"
+		}
+		go serveHTTPSRedirect(cfg, redirectPort, manager.HTTPHandler(httpsRedirectHandler(cfg)))
+
+		log.Printf(" TLS enabled: autocert for %s, cache %s",
+			strings.Join(cfg.TLSAutocertDomains, ", "), cfg.TLSAutocertCacheDir)
+		return server.ListenAndServeTLS("", "")
+	}
+
+	if cfg.HTTPRedirectPort != "" {
+		go serveHTTPSRedirect(cfg, cfg.HTTPRedirectPort, httpsRedirectHandler(cfg))
+	}
+	log.Printf(" TLS enabled: cert %s", cfg.TLSCertFile)
+	return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+}
+
+// shutdownOnSignal ждет SIGINT или SIGTERM и плавно останавливает сервер. Пауза
+// ShutdownDelay дает балансировщику заметить неготовность и перестать слать запросы
+func shutdownOnSignal(cfg *config.Config, server *http.Server, health *handlers.HealthHandler) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+
+	log.Printf(" %v received, shutting down", sig)
+	health.SetReady(false)
+	time.Sleep(cfg.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("❌ Error during graceful shutdown: %v", err)
+	}
+}
 
-		next.ServeHTTP(rw, r)
+// validateTLSConfig проверяет, что сертификат и ключ заданы вместе и загружаются,
+// а режим autocert не смешивается с сертификатом из файлов
+func validateTLSConfig(cfg *config.Config) error {
+	if cfg.TLSAutocert() {
+		if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+			return fmt.Errorf("TLS_AUTOCERT_DOMAINS cannot be combined with TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		if err := os.MkdirAll(cfg.TLSAutocertCacheDir, 0o700); err != nil {
+			return fmt.Errorf("cannot create autocert cache directory: %w", err)
+		}
+		return nil
+	}
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		if cfg.HTTPRedirectPort != "" {
+			return fmt.Errorf("HTTP_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE or TLS_AUTOCERT_DOMAINS")
+		}
+		return nil
+	}
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return fmt.Errorf("both TLS_CERT_FILE and TLS_KEY_FILE must be set")
+	}
+	for _, path := range []string{cfg.TLSCertFile, cfg.TLSKeyFile} {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("cannot access %s: %w", path, err)
+		}
+	}
+	if _, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+		return fmt.Errorf("cannot load certificate and key: %w", err)
+	}
+	return nil
+}
 
-		duration := time.Since(start)
-		log.Printf("📨 %s %s - %d (%v)", r.Method, r.URL.Path, rw.statusCode, duration)
+// httpsRedirectHandler перенаправляет запросы по HTTP на тот же путь по HTTPS
+func httpsRedirectHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if cfg.ServerPort != "443" {
+			host = net.JoinHostPort(host, cfg.ServerPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
 	})
 }
 
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
+// serveHTTPSRedirect слушает HTTP на port и отдает запросы handler
+func serveHTTPSRedirect(cfg *config.Config, port string, handler http.Handler) {
+	addr := ":" + port
+	log.Printf(" Redirecting HTTP on %s to HTTPS", addr)
+	server := newServer(cfg, addr, handler)
+	if err := server.ListenAndServe(); err != nil {
+		log.Printf("❌ HTTPS redirect listener stopped: %v", err)
+	}
 }
 
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
+// newServer создает HTTP-сервер с таймаутами из конфигурации
+func newServer(cfg *config.Config, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+		MaxHeaderBytes:    cfg.ServerMaxHeaderBytes,
+	}
+}
+
+// toggleMaintenanceOnSIGHUP переключает режим обслуживания по сигналу SIGHUP
+func toggleMaintenanceOnSIGHUP(maintenance *middleware.Maintenance) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		log.Printf(" SIGHUP received, maintenance mode: %v", maintenance.Toggle())
+	}
 }
 
 func setupRoutes(r *mux.Router, authHandler *handlers.AuthHandler,
 	studentHandler *handlers.StudentHandler,
 	teacherHandler *handlers.TeacherHandler,
 	groupHandler *handlers.GroupHandler,
-	authMiddleware *middleware.AuthMiddleware) {
-
-	// Создаем отдельный роутер для API с middleware аутентификации
-	api := r.PathPrefix("/api").Subrouter()
-
-	// Публичные маршруты API (без аутентификации)
-	api.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
-	api.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
-
-	// Защищенные маршруты API
+	auditHandler *handlers.AuditHandler,
+	userHandler *handlers.UserHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	maintenanceHandler *handlers.MaintenanceHandler,
+	featureFlagHandler *handlers.FeatureFlagHandler,
+	healthHandler *handlers.HealthHandler,
+	wsHandler *handlers.WSHandler,
+	eventsHandler *handlers.EventsHandler,
+	idempotency *middleware.Idempotency,
+	auditTrail *middleware.AuditTrail,
+	ipAllowlist *middleware.IPAllowlist,
+	authMiddleware *middleware.AuthMiddleware,
+	authRateLimiter *middleware.AuthRateLimiter) {
+
+	// Публичные маршруты API (без аутентификации) с отдельным лимитом попыток.
+	// Подроутер проверяется по middleware.PublicRoutes в verifyPublicRoutes:
+	// маршрут, которого нет в списке, здесь регистрировать нельзя
+	publicAPI := r.PathPrefix("/api").Subrouter()
+	publicAPI.Handle("/auth/login", authRateLimiter.Limit(http.HandlerFunc(authHandler.Login))).Methods("POST")
+	publicAPI.Handle("/auth/register", authRateLimiter.Limit(http.HandlerFunc(authHandler.Register))).Methods("POST")
+	publicAPI.HandleFunc("/auth/verify", authHandler.VerifyEmail).Methods("GET")
+	publicAPI.Handle("/auth/forgot-password", authRateLimiter.Limit(http.HandlerFunc(authHandler.ForgotPassword))).Methods("POST")
+	publicAPI.Handle("/auth/reset-password", authRateLimiter.Limit(http.HandlerFunc(authHandler.ResetPassword))).Methods("POST")
+
+	// Защищенные маршруты API: обработчики могут считать claims заданными.
+	// Запрос, не совпавший ни с одним публичным маршрутом, проверяется здесь;
+	// AuthMiddleware не пропускает никаких путей без токена.
+	// Права ролей на ресурсы проверяются в обработчиках по models.Can
 	protectedAPI := r.PathPrefix("/api").Subrouter()
-	protectedAPI.Use(authMiddleware.AuthMiddleware)
+	protectedAPI.Use(authMiddleware.AuthMiddleware, middleware.RequireAuth(), auditTrail.Middleware)
+
+	// Управление преподавателями, учетными записями и ключами доступно
+	// только из сетей ADMIN_ALLOWED_CIDRS (если они заданы)
+	restricted := func(handler http.HandlerFunc) http.Handler {
+		return ipAllowlist.Middleware(handler)
+	}
 
 	// Аутентификация
 	protectedAPI.HandleFunc("/auth/me", authHandler.GetCurrentUser).Methods("GET")
+	protectedAPI.HandleFunc("/auth/me", authHandler.UpdateCurrentUser).Methods("PATCH")
+	protectedAPI.HandleFunc("/auth/2fa/setup", authHandler.SetupTwoFactor).Methods("POST")
+	protectedAPI.HandleFunc("/auth/2fa/enable", authHandler.EnableTwoFactor).Methods("POST")
 
 	// Студенты
 	protectedAPI.HandleFunc("/students", studentHandler.GetStudents).Methods("GET")
-	protectedAPI.HandleFunc("/students", studentHandler.CreateStudent).Methods("POST")
-	protectedAPI.HandleFunc("/students/{id}", studentHandler.UpdateStudent).Methods("PUT", "PATCH")
+	protectedAPI.Handle("/students", idempotency.Wrap(http.HandlerFunc(studentHandler.CreateStudent))).Methods("POST")
+	protectedAPI.HandleFunc("/students/bulk", studentHandler.BulkCreateStudents).Methods("POST")
+	protectedAPI.HandleFunc("/students/{id}", studentHandler.UpdateStudent).Methods("PUT")
+	protectedAPI.HandleFunc("/students/{id}", studentHandler.PatchStudent).Methods("PATCH")
 	protectedAPI.HandleFunc("/students/{id}", studentHandler.DeleteStudent).Methods("DELETE")
+	protectedAPI.HandleFunc("/students/{id}/group-history", studentHandler.GetStudentGroupHistory).Methods("GET")
 
-	// Преподаватели - ТОЛЬКО для админа
+	// Преподаватели
 	protectedAPI.HandleFunc("/teachers", teacherHandler.GetTeachers).Methods("GET")
-	protectedAPI.HandleFunc("/teachers", teacherHandler.CreateTeacher).Methods("POST")
-	protectedAPI.HandleFunc("/teachers/{id}", teacherHandler.UpdateTeacher).Methods("PUT", "PATCH")
-	protectedAPI.HandleFunc("/teachers/{id}", teacherHandler.DeleteTeacher).Methods("DELETE")
-
+	protectedAPI.Handle("/teachers/export", restricted(teacherHandler.ExportTeachers)).Methods("GET")
+	protectedAPI.Handle("/teachers", ipAllowlist.Middleware(idempotency.Wrap(http.HandlerFunc(teacherHandler.CreateTeacher)))).Methods("POST")
+	protectedAPI.Handle("/teachers", restricted(teacherHandler.BatchDeleteTeachers)).Methods("DELETE")
+	protectedAPI.Handle("/teachers/{id}", restricted(teacherHandler.UpdateTeacher)).Methods("PUT")
+	protectedAPI.Handle("/teachers/{id}", restricted(teacherHandler.PatchTeacher)).Methods("PATCH")
+	protectedAPI.Handle("/teachers/{id}", restricted(teacherHandler.DeleteTeacher)).Methods("DELETE")
+	protectedAPI.Handle("/teachers/{id}/restore", restricted(teacherHandler.RestoreTeacher)).Methods("POST")
+
+	// Группы
 	protectedAPI.HandleFunc("/groups", groupHandler.GetGroups).Methods("GET")
-	protectedAPI.HandleFunc("/groups", groupHandler.CreateGroup).Methods("POST")
-	protectedAPI.HandleFunc("/groups/{id}", groupHandler.UpdateGroup).Methods("PUT", "PATCH")
+	protectedAPI.HandleFunc("/groups/all", groupHandler.GetAllGroups).Methods("GET")
+	protectedAPI.HandleFunc("/groups/stats", groupHandler.GetGroupStats).Methods("GET")
+	protectedAPI.Handle("/groups", idempotency.Wrap(http.HandlerFunc(groupHandler.CreateGroup))).Methods("POST")
+	protectedAPI.HandleFunc("/groups/{id}", groupHandler.GetGroup).Methods("GET")
+	protectedAPI.HandleFunc("/groups/{id}", groupHandler.UpdateGroup).Methods("PUT")
+	protectedAPI.HandleFunc("/groups/{id}", groupHandler.PatchGroup).Methods("PATCH")
 	protectedAPI.HandleFunc("/groups/{id}", groupHandler.DeleteGroup).Methods("DELETE")
+	protectedAPI.HandleFunc("/groups/{id}/students", groupHandler.GetGroupStudents).Methods("GET")
+	protectedAPI.HandleFunc("/groups/{id}/students", groupHandler.MoveStudents).Methods("POST")
+	protectedAPI.HandleFunc("/groups/{id}/transfer", groupHandler.TransferStudents).Methods("POST")
+	protectedAPI.HandleFunc("/groups/{id}/archive", groupHandler.ArchiveGroup).Methods("POST")
+	protectedAPI.HandleFunc("/groups/{id}/unarchive", groupHandler.UnarchiveGroup).Methods("POST")
+
+	// Журнал аудита
+	protectedAPI.HandleFunc("/audit", auditHandler.GetAuditLogs).Methods("GET")
+	protectedAPI.HandleFunc("/audit/entries", auditHandler.GetAuditEntries).Methods("GET")
+	protectedAPI.HandleFunc("/events", eventsHandler.Stream).Methods("GET")
+
+	// Учетные записи
+	protectedAPI.Handle("/users/{id}/link", restricted(userHandler.LinkUser)).Methods("PATCH")
+
+	// Ключи API для интеграций
+	protectedAPI.Handle("/api-keys", restricted(apiKeyHandler.GetAPIKeys)).Methods("GET")
+	protectedAPI.Handle("/api-keys", restricted(apiKeyHandler.CreateAPIKey)).Methods("POST")
+	protectedAPI.Handle("/api-keys/{id}", restricted(apiKeyHandler.RevokeAPIKey)).Methods("DELETE")
+
+	// Администрирование: весь /api/admin доступен только из разрешенных сетей
+	adminAPI := protectedAPI.PathPrefix("/admin").Subrouter()
+	adminAPI.Use(ipAllowlist.Middleware)
+	adminAPI.HandleFunc("/maintenance", maintenanceHandler.GetMaintenance).Methods("GET")
+	adminAPI.HandleFunc("/maintenance", maintenanceHandler.SetMaintenance).Methods("POST")
+	adminAPI.HandleFunc("/flags", featureFlagHandler.GetFlags).Methods("GET")
+	adminAPI.HandleFunc("/flags", featureFlagHandler.UpdateFlags).Methods("PATCH")
 
 	// Публичные маршруты (без API префикса)
 	r.HandleFunc("/", rootHandler).Methods("GET")
-	r.HandleFunc("/health", healthHandler).Methods("GET")
+	r.HandleFunc("/health", healthHandler.Health).Methods("GET")
+	r.HandleFunc("/health/live", healthHandler.Health).Methods("GET")
+	r.HandleFunc("/healthz", healthHandler.Live).Methods("GET")
+	r.HandleFunc("/readyz", healthHandler.Ready).Methods("GET")
+	r.HandleFunc("/health/ready", healthHandler.Ready).Methods("GET")
+	// Метрики Prometheus без токена, но только из сетей ADMIN_ALLOWED_CIDRS
+	r.Handle("/metrics", restricted(healthHandler.Metrics)).Methods("GET")
+
+	// Поток изменений по WebSocket: токен передается в ?token=
+	r.Handle("/ws", middleware.QueryToken(authMiddleware.AuthMiddleware(
+		middleware.RequireAuth()(http.HandlerFunc(wsHandler.Serve))))).Methods("GET")
+
+	// Документация API
+	r.HandleFunc("/openapi.json", docs.SpecHandler).Methods("GET")
+	r.HandleFunc("/docs", docs.UIHandler).Methods("GET")
 
 	// OPTIONS handlers для всех маршрутов
 	r.Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, If-None-Match, X-Request-ID, X-API-Key, Idempotency-Key, traceparent, tracestate")
 		w.WriteHeader(http.StatusOK)
 	})
 
-	protectedAPI.HandleFunc("/groups/all", groupHandler.GetAllGroups).Methods("GET")
-
+	if err := verifyPublicRoutes(r, publicAPI); err != nil {
+		log.Fatal(" Invalid public routes: ", err)
+	}
 }
 
 func rootHandler(w http.ResponseWriter, r *http.Request) {
@@ -209,36 +740,58 @@ func rootHandler(w http.ResponseWriter, r *http.Request) {
             <ul>
                 <li><code>POST /api/auth/login</code> - Login</li>
                 <li><code>POST /api/auth/register</code> - Register</li>
+                <li><code>GET /api/auth/verify?token=...</code> - Confirm email</li>
+                <li><code>POST /api/auth/forgot-password</code> - Request password reset link</li>
+                <li><code>POST /api/auth/reset-password</code> - Reset password by token</li>
             </ul>
             <p><strong>Protected Endpoints:</strong></p>
             <ul>
+                <li><code>POST /api/auth/2fa/setup</code> - Start TOTP two-factor setup</li>
+                <li><code>POST /api/auth/2fa/enable</code> - Enable two-factor with the first code</li>
                 <li><code>GET /api/students</code> - Get students</li>
                 <li><code>POST /api/students</code> - Create student (Admin only)</li>
-                <li><code>PUT/PATCH /api/students/{id}</code> - Update student</li>
-                <li><code>DELETE /api/students/{id}</code> - Delete student (Admin only)</li>
+                <li><code>POST /api/students/bulk</code> - Create students from a JSON array (Admin only)</li>
+                <li><code>PUT /api/students/{id}</code> - Replace student</li>
+                <li><code>PATCH /api/students/{id}</code> - Partially update student</li>
+                <li><code>DELETE /api/students/{id}</code> - Delete student (Admin only; the linked account is disabled, <code>?delete_user=true</code> deletes it permanently)</li>
+                <li><code>GET /api/students/{id}/group-history</code> - Student group change history</li>
                 <li><code>GET /api/teachers</code> - Get teachers (Admin only)</li>
+                <li><code>GET /api/teachers/export?format=csv</code> - Export teachers to CSV (Admin only)</li>
                 <li><code>POST /api/teachers</code> - Create teacher (Admin only)</li>
-                <li><code>PUT/PATCH /api/teachers/{id}</code> - Update teacher (Admin only)</li>
-                <li><code>DELETE /api/teachers/{id}</code> - Delete teacher (Admin only)</li>
+                <li><code>DELETE /api/teachers</code> - Batch delete teachers by ids (Admin only, <code>?force=true</code> removes group assignments)</li>
+                <li><code>PUT /api/teachers/{id}</code> - Replace teacher (Admin only)</li>
+                <li><code>PATCH /api/teachers/{id}</code> - Partially update teacher (Admin only)</li>
+                <li><code>DELETE /api/teachers/{id}</code> - Delete teacher (Admin only; the linked account is disabled, <code>?delete_user=true</code> deletes it permanently)</li>
+                <li><code>POST /api/teachers/{id}/restore</code> - Restore deleted teacher (Admin only)</li>
+                <li><code>GET /api/groups</code> - Get groups (Admin; teachers see own groups by default, <code>?mine=false</code> for all; students see own group)</li>
+                <li><code>GET /api/groups/all</code> - Get all groups without pagination</li>
+                <li><code>GET /api/groups/stats</code> - Student count per group (Admin only)</li>
+                <li><code>GET /api/groups/{id}</code> - Get group with students</li>
+                <li><code>GET /api/groups/{id}/students</code> - Get group students (paginated)</li>
+                <li><code>POST /api/groups</code> - Create group (Admin only)</li>
+                <li><code>PUT /api/groups/{id}</code> - Replace group (Admin only)</li>
+                <li><code>PATCH /api/groups/{id}</code> - Partially update group (Admin only)</li>
+                <li><code>DELETE /api/groups/{id}</code> - Delete group (Admin only)</li>
+                <li><code>POST /api/groups/{id}/students</code> - Move listed students or a whole group into group {id} (Admin only)</li>
+                <li><code>POST /api/groups/{id}/transfer</code> - Move all students of group {id} to another group (Admin only)</li>
+                <li><code>POST /api/groups/{id}/archive</code> - Archive group (Admin only, <code>?confirm=true</code> if it has students)</li>
+                <li><code>POST /api/groups/{id}/unarchive</code> - Unarchive group (Admin only)</li>
+                <li><code>GET /api/audit</code> - Audit log (Admin only)</li>
+                <li><code>GET /api/audit/entries</code> - Mutation audit trail (Admin only)</li>
+                <li><code>GET /api/events</code> - Audit trail as Server-Sent Events (Admin only)</li>
+                <li><code>PATCH /api/users/{id}/link</code> - Relink account to a student or teacher (Admin only)</li>
+                <li><code>GET /api/api-keys</code> - List API keys (Admin only)</li>
+                <li><code>POST /api/api-keys</code> - Create API key, returned once (Admin only)</li>
+                <li><code>DELETE /api/api-keys/{id}</code> - Revoke API key (Admin only)</li>
+                <li><code>GET|POST /api/admin/maintenance</code> - Maintenance mode state and toggle (Admin only)</li>
+                <li><code>GET|PATCH /api/admin/flags</code> - Feature flags, e.g. registration_open (Admin only)</li>
+                <li><code>GET /ws?token=...</code> - WebSocket stream of student changes; teacher changes for admins only (Admin, Teacher)</li>
             </ul>
         </div>
-        <p>Default admin: <code>admin@example.com</code> / <code>admin123</code></p>
+        <p>API docs: <a href="/docs">/docs</a> (OpenAPI: <a href="/openapi.json">/openapi.json</a>)</p>
+        <p>Default admin (dev): <code>admin@example.com</code> / <code>admin123</code>, configurable via <code>SEED_ADMIN_EMAIL</code> / <code>SEED_ADMIN_PASSWORD</code></p>
     </div>
 </body>
 </html>`
 	w.Write([]byte(html))
 }
-
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	response := map[string]interface{}{
-		"status":    "ok",
-		"service":   "student-backend",
-		"orm":       "GORM",
-		"auth":      "JWT",
-		"timestamp": time.Now().Format(time.RFC3339),
-	}
-
-	json.NewEncoder(w).Encode(response)
-}
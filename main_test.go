@@ -0,0 +1,871 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"student-backend/auth"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/middleware"
+	"student-backend/models"
+	"student-backend/testutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testFixture - данные, на которые ссылаются маршруты в тестах
+type testFixture struct {
+	group   models.Group
+	student models.Student
+	teacher models.Teacher
+	users   map[models.Role]*models.User
+}
+
+// newTestApplication собирает приложение поверх чистой базы SQLite с группой,
+// студентом и преподавателем, связанными с учетными записями своих ролей
+func newTestApplication(t *testing.T, cfg *config.Config) (*application, *gorm.DB, *testFixture) {
+	t.Helper()
+	db := testutil.NewDB(t)
+
+	fixture := &testFixture{users: make(map[models.Role]*models.User)}
+	fixture.group = models.Group{Name: "Group A", Code: "A-1", Year: 2024, Semester: 1}
+	mustCreate(t, db, &fixture.group)
+	fixture.student = models.Student{Name: "Anna", Surname: "Smirnova", Email: "student@example.com", GroupID: &fixture.group.ID}
+	mustCreate(t, db, &fixture.student)
+	fixture.teacher = models.Teacher{Name: "Ivan", Surname: "Petrov", Email: "teacher@example.com", Groups: []models.Group{fixture.group}}
+	mustCreate(t, db, &fixture.teacher)
+
+	for _, user := range []*models.User{
+		{Email: "admin@example.com", Role: models.RoleAdmin},
+		{Email: "teacher@example.com", Role: models.RoleTeacher, TeacherID: &fixture.teacher.ID},
+		{Email: "student@example.com", Role: models.RoleStudent, StudentID: &fixture.student.ID},
+	} {
+		user.Password = "password123"
+		user.EmailVerified = true
+		mustCreate(t, db, user)
+		fixture.users[user.Role] = user
+	}
+	db.Model(&fixture.student).Update("user_id", fixture.users[models.RoleStudent].ID)
+	db.Model(&fixture.teacher).Update("user_id", fixture.users[models.RoleTeacher].ID)
+
+	app, err := newApplication(cfg, db)
+	if err != nil {
+		t.Fatalf("newApplication: %v", err)
+	}
+	t.Cleanup(app.Close)
+	return app, db, fixture
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+// tokenFor выпускает JWT для учетной записи роли role
+func tokenFor(t *testing.T, cfg *config.Config, user *models.User) string {
+	t.Helper()
+	token, err := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpiry, cfg.JWTRoleExpiry).GenerateToken(user)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+	return token
+}
+
+// Обозначения ролей в матрице доступа
+const (
+	public    = "public" // без токена
+	anyRole   = "ATS"
+	adminOnly = "A"
+)
+
+// roleMatrix - роли, которым доступен каждый маршрут: A - админ, T - преподаватель,
+// S - студент. Для студента используются его собственная запись и группа
+var roleMatrix = map[string]string{
+	"GET /":                          public,
+	"GET /health":                    public,
+	"GET /health/live":               public,
+	"GET /healthz":                   public,
+	"GET /readyz":                    public,
+	"GET /health/ready":              public,
+	"GET /metrics":                   public,
+	"GET /openapi.json":              public,
+	"GET /docs":                      public,
+	"POST /api/auth/login":           public,
+	"POST /api/auth/register":        public,
+	"GET /api/auth/verify":           public,
+	"POST /api/auth/forgot-password": public,
+	"POST /api/auth/reset-password":  public,
+
+	"GET /api/auth/me":          anyRole,
+	"PATCH /api/auth/me":        anyRole,
+	"POST /api/auth/2fa/setup":  anyRole,
+	"POST /api/auth/2fa/enable": anyRole,
+
+	"GET /api/students":                    anyRole,
+	"POST /api/students":                   adminOnly,
+	"POST /api/students/bulk":              adminOnly,
+	"PUT /api/students/{id}":               "AS",
+	"PATCH /api/students/{id}":             "AS",
+	"DELETE /api/students/{id}":            adminOnly,
+	"GET /api/students/{id}/group-history": anyRole,
+
+	"GET /api/teachers":               adminOnly,
+	"GET /api/teachers/export":        adminOnly,
+	"POST /api/teachers":              adminOnly,
+	"DELETE /api/teachers":            adminOnly,
+	"PUT /api/teachers/{id}":          adminOnly,
+	"PATCH /api/teachers/{id}":        adminOnly,
+	"DELETE /api/teachers/{id}":       adminOnly,
+	"POST /api/teachers/{id}/restore": adminOnly,
+
+	"GET /api/groups":                 anyRole,
+	"GET /api/groups/all":             anyRole,
+	"GET /api/groups/stats":           adminOnly,
+	"POST /api/groups":                adminOnly,
+	"GET /api/groups/{id}":            anyRole,
+	"PUT /api/groups/{id}":            adminOnly,
+	"PATCH /api/groups/{id}":          adminOnly,
+	"DELETE /api/groups/{id}":         adminOnly,
+	"GET /api/groups/{id}/students":   anyRole,
+	"POST /api/groups/{id}/students":  adminOnly,
+	"POST /api/groups/{id}/transfer":  adminOnly,
+	"POST /api/groups/{id}/archive":   adminOnly,
+	"POST /api/groups/{id}/unarchive": adminOnly,
+
+	"GET /api/audit":         adminOnly,
+	"GET /api/audit/entries": adminOnly,
+	"GET /api/events":        adminOnly,
+	"GET /ws":                "AT",
+
+	"PATCH /api/users/{id}/link": adminOnly,
+
+	"GET /api/api-keys":         adminOnly,
+	"POST /api/api-keys":        adminOnly,
+	"DELETE /api/api-keys/{id}": adminOnly,
+
+	"GET /api/admin/maintenance":  adminOnly,
+	"POST /api/admin/maintenance": adminOnly,
+	"GET /api/admin/flags":        adminOnly,
+	"PATCH /api/admin/flags":      adminOnly,
+}
+
+// registeredRoutes возвращает все пары "МЕТОД шаблон" роутера, кроме OPTIONS
+func registeredRoutes(t *testing.T, r *mux.Router) []string {
+	t.Helper()
+	var routes []string
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				routes = append(routes, method+" "+template)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+	return routes
+}
+
+// routeTarget подставляет в шаблон ID записей фикстуры
+func routeTarget(template string, fixture *testFixture) string {
+	id := "1"
+	switch {
+	case strings.HasPrefix(template, "/api/students/"):
+		id = strconv.Itoa(int(fixture.student.ID))
+	case strings.HasPrefix(template, "/api/teachers/"):
+		id = strconv.Itoa(int(fixture.teacher.ID))
+	case strings.HasPrefix(template, "/api/groups/"):
+		id = strconv.Itoa(int(fixture.group.ID))
+	case strings.HasPrefix(template, "/api/users/"):
+		id = strconv.Itoa(int(fixture.users[models.RoleStudent].ID))
+	}
+	return strings.Replace(template, "{id}", id, 1)
+}
+
+func TestRoleMatrixCoversAllRoutes(t *testing.T) {
+	app, _, _ := newTestApplication(t, testutil.Config())
+
+	registered := make(map[string]bool)
+	for _, route := range registeredRoutes(t, app.router) {
+		registered[route] = true
+		if _, ok := roleMatrix[route]; !ok {
+			t.Errorf("route %s is missing from the role matrix", route)
+		}
+	}
+	for route := range roleMatrix {
+		if !registered[route] {
+			t.Errorf("role matrix lists %s, which is not registered", route)
+		}
+	}
+}
+
+func TestRoleMatrix(t *testing.T) {
+	roles := map[string]models.Role{"A": models.RoleAdmin, "T": models.RoleTeacher, "S": models.RoleStudent}
+
+	for _, caller := range []string{"anonymous", "A", "T", "S"} {
+		t.Run(caller, func(t *testing.T) {
+			cfg := testutil.Config()
+			// Каждой роли - своя база: удаления администратора не влияют на другие роли
+			app, _, fixture := newTestApplication(t, cfg)
+			token := ""
+			if role, ok := roles[caller]; ok {
+				token = tokenFor(t, cfg, fixture.users[role])
+			}
+
+			for _, route := range registeredRoutes(t, app.router) {
+				allowed, ok := roleMatrix[route]
+				if !ok {
+					continue
+				}
+				method, template, _ := strings.Cut(route, " ")
+
+				var body *strings.Reader
+				if method == http.MethodGet || method == http.MethodDelete && template != "/api/teachers" {
+					body = strings.NewReader("")
+				} else {
+					body = strings.NewReader("{}")
+				}
+
+				// Потоки событий открыты до отключения клиента
+				ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+				r := httptest.NewRequest(method, routeTarget(template, fixture), body).WithContext(ctx)
+				if body.Len() > 0 {
+					r.Header.Set("Content-Type", "application/json")
+				}
+				if token != "" {
+					r.Header.Set("Authorization", "Bearer "+token)
+				}
+				w := httptest.NewRecorder()
+				app.handler.ServeHTTP(w, r)
+				cancel()
+
+				want := "allowed"
+				switch {
+				case allowed == public:
+				case caller == "anonymous":
+					want = "401"
+				case !strings.Contains(allowed, caller):
+					want = "403"
+				}
+
+				got := "allowed"
+				if w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden {
+					got = strconv.Itoa(w.Code)
+				}
+				if got != want {
+					t.Errorf("%s: got %d, want %s; body: %s", route, w.Code, want, strings.TrimSpace(w.Body.String()))
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticationOverHTTP(t *testing.T) {
+	cfg := testutil.Config()
+	app, _, fixture := newTestApplication(t, cfg)
+	server := httptest.NewServer(app.handler)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"protected list", http.MethodGet, "/api/students", "", http.StatusUnauthorized},
+		{"protected item", http.MethodGet, "/api/groups/" + strconv.Itoa(int(fixture.group.ID)), "", http.StatusUnauthorized},
+		{"protected under /api/auth", http.MethodGet, "/api/auth/me", "", http.StatusUnauthorized},
+		{"2fa setup next to login", http.MethodPost, "/api/auth/2fa/setup", "{}", http.StatusUnauthorized},
+		// Пути, не совпавшие ни с одним маршрутом, не доходят ни до одного обработчика:
+		// из-за маршрута OPTIONS для всех путей mux отвечает 405
+		{"public path with another method", http.MethodGet, "/api/auth/login", "", http.StatusMethodNotAllowed},
+		{"public path with a trailing slash", http.MethodPost, "/api/auth/login/", `{"email":"admin@example.com","password":"password123"}`, http.StatusMethodNotAllowed},
+		{"unknown api path", http.MethodGet, "/api/unknown", "", http.StatusMethodNotAllowed},
+		{"health", http.MethodGet, "/health", "", http.StatusOK},
+		{"openapi", http.MethodGet, "/openapi.json", "", http.StatusOK},
+		{"login", http.MethodPost, "/api/auth/login", `{"email":"admin@example.com","password":"password123"}`, http.StatusOK},
+		{"forgot password", http.MethodPost, "/api/auth/forgot-password", `{"email":"nobody@example.com"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			resp, err := server.Client().Do(req)
+			if err != nil {
+				t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("%s %s without a token: status = %d, want %d; body: %s", tt.method, tt.path, resp.StatusCode, tt.want, body)
+			}
+		})
+	}
+}
+
+func TestVerifyPublicRoutes(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	newRouter := func(extra string) (*mux.Router, *mux.Router) {
+		r := mux.NewRouter()
+		for _, path := range middleware.PublicRoutes {
+			if !strings.HasPrefix(path, "/api/") {
+				r.HandleFunc(path, noop)
+			}
+		}
+		publicAPI := r.PathPrefix("/api").Subrouter()
+		for _, path := range middleware.PublicRoutes {
+			if strings.HasPrefix(path, "/api/") && path != extra {
+				publicAPI.HandleFunc(strings.TrimPrefix(path, "/api"), noop)
+			}
+		}
+		return r, publicAPI
+	}
+
+	r, publicAPI := newRouter("")
+	if err := verifyPublicRoutes(r, publicAPI); err != nil {
+		t.Fatalf("complete router: %v", err)
+	}
+
+	r, publicAPI = newRouter("/api/auth/verify")
+	if err := verifyPublicRoutes(r, publicAPI); err == nil || !strings.Contains(err.Error(), "/api/auth/verify") {
+		t.Fatalf("missing public route: err = %v", err)
+	}
+
+	r, publicAPI = newRouter("")
+	publicAPI.HandleFunc("/students", noop)
+	if err := verifyPublicRoutes(r, publicAPI); err == nil || !strings.Contains(err.Error(), "/api/students") {
+		t.Fatalf("unlisted route on the public subrouter: err = %v", err)
+	}
+}
+
+func TestRootPageListsTwoFactorAsProtected(t *testing.T) {
+	w := httptest.NewRecorder()
+	rootHandler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	page := w.Body.String()
+	_, protected, found := strings.Cut(page, "Protected Endpoints")
+	if !found {
+		t.Fatal("root page has no protected endpoints section")
+	}
+	for _, route := range []string{"POST /api/auth/2fa/setup", "POST /api/auth/2fa/enable"} {
+		if !strings.Contains(protected, route) {
+			t.Errorf("%s is not listed under protected endpoints", route)
+		}
+	}
+}
+
+func TestSecurityHeadersOnResponses(t *testing.T) {
+	cfg := testutil.Config()
+	app, _, fixture := newTestApplication(t, cfg)
+	token := tokenFor(t, cfg, fixture.users[models.RoleAdmin])
+
+	tests := []struct {
+		path string
+		auth bool
+		csp  string
+	}{
+		{"/api/students", true, "default-src 'none'"},
+		{"/api/students", false, "default-src 'none'"},
+		{"/", false, "default-src 'self'"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.auth {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		app.handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+			t.Errorf("%s (status %d): X-Content-Type-Options = %q", tt.path, w.Code, got)
+		}
+		if got := w.Header().Get("X-Frame-Options"); got != "DENY" {
+			t.Errorf("%s (status %d): X-Frame-Options = %q", tt.path, w.Code, got)
+		}
+		if got := w.Header().Get("Content-Security-Policy"); !strings.HasPrefix(got, tt.csp) {
+			t.Errorf("%s (status %d): Content-Security-Policy = %q, want prefix %q", tt.path, w.Code, got, tt.csp)
+		}
+	}
+}
+
+func TestMaintenanceToggle(t *testing.T) {
+	cfg := testutil.Config()
+	app, _, fixture := newTestApplication(t, cfg)
+	token := tokenFor(t, cfg, fixture.users[models.RoleAdmin])
+
+	send := func(method, target, body string) int {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+token)
+		if body != "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		app.handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if got := send(http.MethodGet, "/api/students", ""); got != http.StatusOK {
+		t.Fatalf("before maintenance: status = %d, want 200", got)
+	}
+
+	if got := send(http.MethodPost, "/api/admin/maintenance", `{"enabled": true, "message": "Upgrading"}`); got != http.StatusOK {
+		t.Fatalf("enable maintenance: status = %d, want 200", got)
+	}
+	if got := send(http.MethodGet, "/api/students", ""); got != http.StatusServiceUnavailable {
+		t.Fatalf("during maintenance: status = %d, want 503", got)
+	}
+	if got := send(http.MethodGet, "/health", ""); got != http.StatusOK {
+		t.Fatalf("health during maintenance: status = %d, want 200", got)
+	}
+
+	if got := send(http.MethodPost, "/api/admin/maintenance", `{"enabled": false}`); got != http.StatusOK {
+		t.Fatalf("disable maintenance: status = %d, want 200", got)
+	}
+	if got := send(http.MethodGet, "/api/students", ""); got != http.StatusOK {
+		t.Fatalf("after maintenance: status = %d, want 200", got)
+	}
+}
+
+func TestNewServerTimeouts(t *testing.T) {
+	cfg := testutil.Config()
+	cfg.ServerReadHeaderTimeout = 100 * time.Millisecond
+	cfg.ServerReadTimeout = 2 * time.Second
+	cfg.ServerWriteTimeout = 3 * time.Second
+	cfg.ServerIdleTimeout = 4 * time.Second
+	cfg.ServerMaxHeaderBytes = 8 << 10
+
+	server := newServer(cfg, "127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	if server.ReadHeaderTimeout != cfg.ServerReadHeaderTimeout || server.ReadTimeout != cfg.ServerReadTimeout ||
+		server.WriteTimeout != cfg.ServerWriteTimeout || server.IdleTimeout != cfg.ServerIdleTimeout ||
+		server.MaxHeaderBytes != cfg.ServerMaxHeaderBytes {
+		t.Fatalf("server limits do not match config: %+v", server)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	// Клиент, не дописавший заголовки, отключается по ReadHeaderTimeout
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatalf("write partial request: %v", err)
+	}
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("connection was not closed by the server: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("slow client held the connection for %v", elapsed)
+	}
+}
+
+// writeSelfSignedCert создает самоподписанный сертификат для 127.0.0.1 и возвращает
+// пути к сертификату и ключу и пул с этим сертификатом в роли CA
+func writeSelfSignedCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "student-backend test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return certFile, keyFile, pool
+}
+
+// freeAddr возвращает свободный локальный адрес для сервера
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestServeTLS(t *testing.T) {
+	certFile, keyFile, pool := writeSelfSignedCert(t)
+	cfg := testutil.Config()
+	cfg.TLSCertFile = certFile
+	cfg.TLSKeyFile = keyFile
+	if err := validateTLSConfig(cfg); err != nil {
+		t.Fatalf("validateTLSConfig: %v", err)
+	}
+
+	app, _, _ := newTestApplication(t, cfg)
+	addr := freeAddr(t)
+	server := newServer(cfg, addr, app.handler)
+	go serve(cfg, server)
+	t.Cleanup(func() { server.Close() })
+
+	client := &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if resp, err = client.Get("https://" + addr + "/health"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("GET /health over TLS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Fatalf("status = %d, TLS = %v; want 200 over TLS", resp.StatusCode, resp.TLS != nil)
+	}
+	if resp.Header.Get("Strict-Transport-Security") == "" {
+		t.Error("no Strict-Transport-Security header over TLS")
+	}
+
+	// Клиент без нашего CA сертификату не доверяет
+	if _, err := (&http.Client{Timeout: time.Second}).Get("https://" + addr + "/health"); err == nil {
+		t.Fatal("client without the CA accepted the self-signed certificate")
+	}
+}
+
+func TestValidateTLSConfig(t *testing.T) {
+	certFile, keyFile, _ := writeSelfSignedCert(t)
+	otherCert, _, _ := writeSelfSignedCert(t)
+
+	tests := []struct {
+		name     string
+		cert     string
+		key      string
+		redirect string
+		wantErr  bool
+	}{
+		{"plain http", "", "", "", false},
+		{"cert and key", certFile, keyFile, "", false},
+		{"cert only", certFile, "", "", true},
+		{"missing file", certFile, keyFile + ".missing", "", true},
+		{"mismatched pair", otherCert, keyFile, "", true},
+		{"redirect without tls", "", "", "8080", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testutil.Config()
+			cfg.TLSCertFile, cfg.TLSKeyFile, cfg.HTTPRedirectPort = tt.cert, tt.key, tt.redirect
+			cfg.TLSAutocertDomains = nil
+			if err := validateTLSConfig(cfg); (err != nil) != tt.wantErr {
+				t.Fatalf("validateTLSConfig error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// postJSON отправляет запрос с JSON-телом от имени владельца токена
+func postJSON(t *testing.T, server *httptest.Server, token, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST %s: status = %d, want 201", path, resp.StatusCode)
+	}
+	return resp
+}
+
+// liveEvent - сообщение потока /ws
+type liveEvent struct {
+	Type string `json:"type"`
+	ID   uint   `json:"id"`
+}
+
+func dialLiveUpdates(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?token=" + token
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial /ws: %v (status %d)", err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readLiveEvent ждет следующее событие потока не дольше timeout
+func readLiveEvent(conn *websocket.Conn, timeout time.Duration) (liveEvent, error) {
+	var event liveEvent
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	err := conn.ReadJSON(&event)
+	return event, err
+}
+
+func TestLiveUpdatesDeliverStudentCreated(t *testing.T) {
+	cfg := testutil.Config()
+	app, _, fixture := newTestApplication(t, cfg)
+	server := httptest.NewServer(app.handler)
+	t.Cleanup(server.Close)
+	adminToken := tokenFor(t, cfg, fixture.users[models.RoleAdmin])
+
+	teacherToken := tokenFor(t, cfg, fixture.users[models.RoleTeacher])
+
+	// Клиент регистрируется в хабе после рукопожатия: при пропущенном событии
+	// подключаемся заново (после таймаута чтения соединение непригодно)
+	var event liveEvent
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		conn := dialLiveUpdates(t, server, teacherToken)
+		// Событие о преподавателе только для админов: учитель его не получит
+		postJSON(t, server, adminToken, "/api/teachers",
+			fmt.Sprintf(`{"name":"Olga","surname":"Sokolova","email":"olga%d@example.com"}`, attempt))
+		postJSON(t, server, adminToken, "/api/students", `{"name":"Boris","surname":"Ivanov"}`)
+		if event, err = readLiveEvent(conn, time.Second); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("no live update received: %v", err)
+	}
+	if event.Type != "student.created" || event.ID == 0 {
+		t.Fatalf("event = %+v, want student.created", event)
+	}
+}
+
+func TestEventStreamDeliversAuditEntry(t *testing.T) {
+	cfg := testutil.Config()
+	app, _, fixture := newTestApplication(t, cfg)
+	server := httptest.NewServer(app.handler)
+	t.Cleanup(server.Close)
+	adminToken := tokenFor(t, cfg, fixture.users[models.RoleAdmin])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/events: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("status = %d, Content-Type = %q; want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Подписка создается до отправки заголовков, поэтому событие не потеряется
+	postJSON(t, server, adminToken, "/api/students", `{"name":"Boris","surname":"Ivanov"}`)
+
+	received := make(chan models.AuditEntry, 1)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		event := ""
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: ") && event == "audit":
+				var entry models.AuditEntry
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &entry) == nil {
+					received <- entry
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case entry := <-received:
+		if entry.Method != http.MethodPost || !strings.HasPrefix(entry.Route, "/api/students") ||
+			entry.StatusCode != http.StatusCreated || entry.UserID != fixture.users[models.RoleAdmin].ID {
+			t.Fatalf("audit event = %+v, want the admin's POST /api/students", entry)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no audit event received")
+	}
+}
+
+// openUnmigratedDB открывает пустую базу SQLite без таблиц
+func openUnmigratedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "startup.db") + "?_busy_timeout=5000&_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sqlite handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestStartupGateDuringSlowMigration(t *testing.T) {
+	cfg := testutil.Config()
+	db := openUnmigratedDB(t)
+
+	// Миграция останавливается на записи первого шага в schema_migrations
+	migrating := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	err := db.Callback().Create().Before("gorm:create").Register("test:slow_migration", func(tx *gorm.DB) {
+		if tx.Statement.Table == "schema_migrations" {
+			once.Do(func() { close(migrating) })
+			<-release
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	gate := middleware.NewStartupGate()
+	server := httptest.NewServer(gate)
+	defer server.Close()
+
+	started := make(chan *application, 1)
+	go func() {
+		if err := database.Migrate(db, cfg); err != nil {
+			t.Errorf("migrate: %v", err)
+			close(started)
+			return
+		}
+		app, err := newApplication(cfg, db)
+		if err != nil {
+			t.Errorf("newApplication: %v", err)
+			close(started)
+			return
+		}
+		app.open(gate)
+		started <- app
+	}()
+
+	status := func(path string) int {
+		t.Helper()
+		resp, err := server.Client().Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	<-migrating
+	for path, want := range map[string]int{
+		"/health/ready": http.StatusServiceUnavailable,
+		"/readyz":       http.StatusServiceUnavailable,
+		"/api/students": http.StatusServiceUnavailable,
+		"/healthz":      http.StatusOK,
+		"/health/live":  http.StatusOK,
+	} {
+		if got := status(path); got != want {
+			t.Errorf("GET %s while migrating: status = %d, want %d", path, got, want)
+		}
+	}
+
+	close(release)
+	app, ok := <-started
+	if !ok {
+		t.FailNow()
+	}
+	defer app.Close()
+
+	if got := status("/health/ready"); got != http.StatusOK {
+		t.Fatalf("GET /health/ready after startup: status = %d, want 200", got)
+	}
+	if got := status("/api/students"); got != http.StatusUnauthorized {
+		t.Fatalf("GET /api/students after startup: status = %d, want 401 from the router", got)
+	}
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Форматы журнала доступа
+const (
+	AccessLogText = "text"
+	AccessLogJSON = "json"
+)
+
+// jsonAccessLogger пишет записи в формате JSON без префикса времени стандартного логгера,
+// чтобы каждая строка была самостоятельным JSON-объектом
+var jsonAccessLogger = log.New(os.Stdout, "", 0)
+
+// accessLogUserKey - ключ контекста с ячейкой для email пользователя. Аутентификация
+// выполняется глубже по цепочке и заполняет ячейку через SetUserClaims
+const accessLogUserKey contextKey = "accessLogUser"
+
+// accessLogUser - email аутентифицированного пользователя для записи журнала доступа
+type accessLogUser struct {
+	email string
+}
+
+// setAccessLogUser запоминает email пользователя для журнала доступа текущего запроса
+func setAccessLogUser(ctx context.Context, email string) {
+	if user, ok := ctx.Value(accessLogUserKey).(*accessLogUser); ok {
+		user.email = email
+	}
+}
+
+// accessLogEntry - запись журнала доступа
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	RequestID  string  `json:"request_id,omitempty"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	Bytes      int64   `json:"bytes"`
+	DurationMs float64 `json:"duration_ms"`
+	RemoteIP   string  `json:"remote_ip"`
+	UserEmail  string  `json:"user_email,omitempty"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+}
+
+// AccessLog пишет строку журнала на каждый запрос в текстовом или JSON формате.
+// Пути из excludePaths (проверки живости, метрики) не журналируются.
+// Адрес клиента определяет clientIP, общий с ограничителями частоты
+func AccessLog(format string, excludePaths []string, clientIP *ClientIP) func(http.Handler) http.Handler {
+	exclude := make(map[string]bool, len(excludePaths))
+	for _, path := range excludePaths {
+		exclude[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exclude[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			user := &accessLogUser{}
+			r = r.WithContext(context.WithValue(r.Context(), accessLogUserKey, user))
+
+			// Создаем обертку для response writer для захвата статуса и размера ответа
+			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(rw, r)
+
+			duration := time.Since(start)
+			entry := accessLogEntry{
+				Time:       start.UTC().Format(time.RFC3339Nano),
+				RequestID:  GetRequestID(r.Context()),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     rw.statusCode,
+				Bytes:      rw.bytes,
+				DurationMs: float64(duration.Microseconds()) / 1000,
+				RemoteIP:   clientIP.String(r),
+				UserEmail:  user.email,
+				UserAgent:  r.UserAgent(),
+			}
+
+			if format == AccessLogJSON {
+				line, err := json.Marshal(entry)
+				if err != nil {
+					log.Printf("❌ Error encoding access log entry: %v", err)
+					return
+				}
+				jsonAccessLogger.Println(string(line))
+				return
+			}
+
+			userEmail := entry.UserEmail
+			if userEmail == "" {
+				userEmail = "-"
+			}
+			Logf(r.Context(), "📨 %s %s - %d %dB (%v) ip=%s user=%s ua=%q",
+				r.Method, r.URL.Path, rw.statusCode, rw.bytes, duration, entry.RemoteIP, userEmail, entry.UserAgent)
+		})
+	}
+}
+
+// responseWriter запоминает код ответа и число записанных байт
+type responseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	bytes      int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+// Flush передает сброс буфера дальше, чтобы потоковые ответы не задерживались оберткой
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap открывает исходный writer для http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack нужен переключению соединения на WebSocket. После него ответ
+// пишется уже не через обертку, поэтому в журнале остается статус 101
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := hijack(rw.ResponseWriter)
+	if err == nil {
+		rw.statusCode = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// hijack передает захват соединения исходному writer, если он это поддерживает
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer %T does not support hijacking", w)
+	}
+	return hijacker.Hijack()
+}
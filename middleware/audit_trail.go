@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"student-backend/models"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const (
+	// auditTrailBuffer - очередь записей, ожидающих сохранения
+	auditTrailBuffer = 1024
+	// auditSubscriberBuffer - очередь подписчика; при переполнении записи для него пропускаются
+	auditSubscriberBuffer = 64
+)
+
+// AuditTrail записывает в audit_entries каждый успешный изменяющий запрос
+// (POST, PUT, PATCH, DELETE): кто, каким методом и по какому маршруту.
+// Запись идет в отдельной горутине, поэтому не добавляет задержки к ответу
+type AuditTrail struct {
+	db      *gorm.DB
+	entries chan models.AuditEntry
+	done    chan struct{}
+
+	// mu защищает отправку в entries от закрытия очереди в Close
+	mu     sync.RWMutex
+	closed bool
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan models.AuditEntry]struct{}
+}
+
+func NewAuditTrail(db *gorm.DB) *AuditTrail {
+	return &AuditTrail{
+		db:      db,
+		entries: make(chan models.AuditEntry, auditTrailBuffer),
+		done:    make(chan struct{}),
+
+		subscribers: make(map[chan models.AuditEntry]struct{}),
+	}
+}
+
+// Run сохраняет записи из очереди, запускается в отдельной горутине.
+// Завершается после Close, когда очередь разобрана
+func (a *AuditTrail) Run() {
+	defer close(a.done)
+	for entry := range a.entries {
+		if err := a.db.Create(&entry).Error; err != nil {
+			log.Printf("❌ Error writing audit entry (%s %s): %v", entry.Method, entry.Route, err)
+			continue
+		}
+		a.publish(entry)
+	}
+}
+
+// Subscribe подписывает на записи журнала после их сохранения.
+// Возвращенная функция отменяет подписку и закрывает канал
+func (a *AuditTrail) Subscribe() (<-chan models.AuditEntry, func()) {
+	ch := make(chan models.AuditEntry, auditSubscriberBuffer)
+
+	a.subscribersMu.Lock()
+	a.subscribers[ch] = struct{}{}
+	a.subscribersMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			a.subscribersMu.Lock()
+			delete(a.subscribers, ch)
+			a.subscribersMu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish рассылает запись подписчикам, не дожидаясь медленных
+func (a *AuditTrail) publish(entry models.AuditEntry) {
+	a.subscribersMu.Lock()
+	defer a.subscribersMu.Unlock()
+	for ch := range a.subscribers {
+		select {
+		case ch <- entry:
+		default:
+			log.Printf("❌ Audit subscriber is too slow, dropping entry %d", entry.ID)
+		}
+	}
+}
+
+// Close прекращает прием записей и дожидается сохранения оставшихся
+func (a *AuditTrail) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.entries)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
+
+// Middleware должен стоять после аутентификации: автор изменения берется из claims
+func (a *AuditTrail) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if recorder.status < 200 || recorder.status >= 300 {
+			return
+		}
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		entry := models.AuditEntry{
+			Method:     r.Method,
+			Route:      route,
+			Entity:     routeEntity(route),
+			EntityID:   mux.Vars(r)["id"],
+			StatusCode: recorder.status,
+		}
+		if claims := GetUserClaims(r.Context()); claims != nil {
+			entry.UserID = claims.UserID
+			entry.Email = claims.Email
+		}
+
+		a.enqueue(r, entry)
+	})
+}
+
+// enqueue ставит запись в очередь, не блокируя запрос: при переполненной
+// или закрытой очереди запись отбрасывается
+func (a *AuditTrail) enqueue(r *http.Request, entry models.AuditEntry) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return
+	}
+
+	select {
+	case a.entries <- entry:
+	default:
+		Logf(r.Context(), "❌ Audit queue is full, dropping entry for %s %s", r.Method, r.URL.Path)
+	}
+}
+
+// routeEntity возвращает тип сущности по шаблону маршрута:
+// первый сегмент после /api/, например students для /api/students/{id}
+func routeEntity(route string) string {
+	segments := strings.Split(strings.TrimPrefix(route, "/api/"), "/")
+	return segments[0]
+}
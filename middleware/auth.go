@@ -2,55 +2,86 @@ package middleware
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"net/http"
 	"strings"
 	"student-backend/auth"
+	"student-backend/httputil"
+	"student-backend/models"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// RefreshedTokenHeader - заголовок ответа с новым токеном взамен почти истекшего
+const RefreshedTokenHeader = "X-Refreshed-Token"
+
+// APIKeyHeader - заголовок запроса с ключом API, альтернатива Bearer-токену
+const APIKeyHeader = "X-API-Key"
+
 type AuthMiddleware struct {
 	jwtService *auth.JWTService
+	db         *gorm.DB
+
+	// Доля срока жизни токена в процентах, в пределах которой перед истечением
+	// выдается новый токен. 0 - обновление выключено
+	refreshWindowPercent int
 }
 
-func NewAuthMiddleware(jwtService *auth.JWTService) *AuthMiddleware {
+func NewAuthMiddleware(jwtService *auth.JWTService, db *gorm.DB, refreshWindowPercent int) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService: jwtService,
+		jwtService:           jwtService,
+		db:                   db,
+		refreshWindowPercent: refreshWindowPercent,
 	}
 }
 
-// AuthMiddleware проверяет JWT токен
+// nearExpiry проверяет, что до истечения действительного токена осталось
+// не больше refreshWindowPercent процентов его срока жизни
+func (am *AuthMiddleware) nearExpiry(claims *auth.JWTClaims, now time.Time) bool {
+	if am.refreshWindowPercent <= 0 || claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return false
+	}
+
+	lifetime := claims.ExpiresAt.Sub(claims.IssuedAt.Time)
+	remaining := claims.ExpiresAt.Sub(now)
+	if lifetime <= 0 || remaining <= 0 {
+		return false
+	}
+	return remaining <= lifetime*time.Duration(am.refreshWindowPercent)/100
+}
+
+// AuthMiddleware проверяет JWT токен или ключ API. Подключается только к защищенным
+// маршрутам и не делает исключений по пути: публичные маршруты регистрируются
+// отдельно (см. PublicRoutes)
 func (am *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Исключаем публичные маршруты
-		publicRoutes := []string{"/", "/health", "/api/auth/login", "/api/auth/register"}
-
-		// Проверяем, является ли текущий путь публичным
-		isPublic := false
-		for _, route := range publicRoutes {
-			if r.URL.Path == route {
-				isPublic = true
-				break
+		// Извлекаем токен из заголовка
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" && r.Header.Get(APIKeyHeader) != "" {
+			claims, err := am.authenticateAPIKey(r, r.Header.Get(APIKeyHeader))
+			if err != nil {
+				Logf(r.Context(), "❌ Invalid API key for %s %s: %v", r.Method, r.URL.Path, err)
+				httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid or revoked API key")
+				return
 			}
-		}
 
-		if isPublic {
-			next.ServeHTTP(w, r)
+			Logf(r.Context(), "✅ Authenticated user %s (role: %s) by API key for %s %s",
+				claims.Email, claims.Role, r.Method, r.URL.Path)
+			next.ServeHTTP(w, r.WithContext(SetUserClaims(r.Context(), claims)))
 			return
 		}
-
-		// Извлекаем токен из заголовка
-		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			log.Printf("❌ No authorization header for %s %s", r.Method, r.URL.Path)
-			http.Error(w, `{"error": "Authorization header required"}`, http.StatusUnauthorized)
+			Logf(r.Context(), "❌ No authorization header for %s %s", r.Method, r.URL.Path)
+			httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Authorization header required")
 			return
 		}
 
 		// Проверяем формат заголовка
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			log.Printf("❌ Invalid authorization format for %s %s", r.Method, r.URL.Path)
-			http.Error(w, `{"error": "Invalid authorization format"}`, http.StatusUnauthorized)
+			Logf(r.Context(), "❌ Invalid authorization format for %s %s", r.Method, r.URL.Path)
+			httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid authorization format")
 			return
 		}
 
@@ -59,22 +90,63 @@ func (am *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 		// Валидируем токен
 		claims, err := am.jwtService.ValidateToken(token)
 		if err != nil {
-			log.Printf("❌ Invalid token for %s %s: %v", r.Method, r.URL.Path, err)
-			http.Error(w, `{"error": "Invalid or expired token"}`, http.StatusUnauthorized)
+			Logf(r.Context(), "❌ Invalid token for %s %s: %v", r.Method, r.URL.Path, err)
+			httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Invalid or expired token")
 			return
 		}
 
+		// Токен удаленной учетной записи больше не действителен
+		var user models.User
+		if err := am.db.WithContext(r.Context()).Select("id", "email", "role").First(&user, claims.UserID).Error; err != nil {
+			Logf(r.Context(), "❌ User %s (ID: %d) no longer active for %s %s: %v",
+				claims.Email, claims.UserID, r.Method, r.URL.Path, err)
+			httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Account is disabled or deleted")
+			return
+		}
+
+		// Токен скоро истечет - отдаем клиенту новый, старый остается действительным до своего срока
+		if am.nearExpiry(claims, time.Now()) {
+			if refreshed, err := am.jwtService.GenerateToken(&user); err != nil {
+				Logf(r.Context(), "❌ Error refreshing token for %s: %v", claims.Email, err)
+			} else {
+				w.Header().Set(RefreshedTokenHeader, refreshed)
+			}
+		}
+
 		// Добавляем claims в контекст запроса
 		ctx := r.Context()
 		ctx = SetUserClaims(ctx, claims)
 		r = r.WithContext(ctx)
 
-		log.Printf("✅ Authenticated user %s (role: %s) for %s %s",
+		Logf(r.Context(), "✅ Authenticated user %s (role: %s) for %s %s",
 			claims.Email, claims.Role, r.Method, r.URL.Path)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// authenticateAPIKey находит ключ API по хэшу, отмечает его использование
+// и возвращает claims владельца ключа
+func (am *AuthMiddleware) authenticateAPIKey(r *http.Request, key string) (*auth.JWTClaims, error) {
+	db := am.db.WithContext(r.Context())
+
+	var apiKey models.APIKey
+	if err := db.Where("key = ?", auth.HashToken(key)).First(&apiKey).Error; err != nil {
+		return nil, fmt.Errorf("lookup API key: %w", err)
+	}
+
+	var user models.User
+	if err := db.Select("id", "email", "role").First(&user, apiKey.UserID).Error; err != nil {
+		return nil, fmt.Errorf("API key %d owner %d: %w", apiKey.ID, apiKey.UserID, err)
+	}
+
+	now := models.Timestamp(time.Now())
+	if err := db.Model(&apiKey).UpdateColumn("last_used_at", &now).Error; err != nil {
+		Logf(r.Context(), "❌ Error updating last_used_at for API key %d: %v", apiKey.ID, err)
+	}
+
+	return &auth.JWTClaims{UserID: user.ID, Email: user.Email, Role: user.Role}, nil
+}
+
 // Вспомогательные функции для работы с контекстом
 type contextKey string
 
@@ -84,6 +156,7 @@ const (
 
 // SetUserClaims добавляет claims пользователя в контекст
 func SetUserClaims(ctx context.Context, claims *auth.JWTClaims) context.Context {
+	setAccessLogUser(ctx, claims.Email)
 	return context.WithValue(ctx, userClaimsKey, claims)
 }
 
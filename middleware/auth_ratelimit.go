@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"student-backend/httputil"
+	"sync"
+	"time"
+)
+
+// AuthRateLimiter ограничивает число попыток входа и регистрации в минуту
+// отдельно для каждого IP и для каждого переданного email.
+// Не зависит от блокировки учетной записи и срабатывает раньше нее
+type AuthRateLimiter struct {
+	limit    int
+	window   time.Duration
+	clientIP *ClientIP
+
+	mu       sync.Mutex
+	counters map[string]*rateWindow
+	calls    int
+}
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+// NewAuthRateLimiter создает ограничитель; IP клиента определяет clientIP
+// с учетом доверенных прокси
+func NewAuthRateLimiter(requestsPerMinute int, clientIP *ClientIP) *AuthRateLimiter {
+	return &AuthRateLimiter{
+		limit:    requestsPerMinute,
+		window:   time.Minute,
+		clientIP: clientIP,
+		counters: make(map[string]*rateWindow),
+	}
+}
+
+// Limit оборачивает обработчик проверкой лимитов по IP и по email из тела запроса
+func (l *AuthRateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := l.clientIP.String(r)
+		email := peekEmail(r)
+		keys := []string{"ip:" + ip}
+		if email != "" {
+			keys = append(keys, "email:"+email)
+		}
+
+		for _, key := range keys {
+			if retryAfter, ok := l.allow(key); !ok {
+				subject := "ip:" + ip
+				if key != subject {
+					subject = "email:" + MaskEmail(email)
+				}
+				Logf(r.Context(), "❌ Auth rate limit exceeded for %s on %s", subject, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.5)))
+				httputil.RespondError(w, http.StatusTooManyRequests, httputil.CodeRateLimited, "Too many requests, try again later")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow учитывает запрос по ключу и возвращает время до сброса окна, если лимит превышен
+func (l *AuthRateLimiter) allow(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	counter, exists := l.counters[key]
+	if !exists || now.After(counter.resetAt) {
+		counter = &rateWindow{resetAt: now.Add(l.window)}
+		l.counters[key] = counter
+	}
+
+	if counter.count >= l.limit {
+		return counter.resetAt.Sub(now), false
+	}
+
+	counter.count++
+	return 0, true
+}
+
+// sweep периодически удаляет истекшие окна, чтобы карта не росла бесконечно
+func (l *AuthRateLimiter) sweep(now time.Time) {
+	l.calls++
+	if l.calls%100 != 0 {
+		return
+	}
+	for key, counter := range l.counters {
+		if now.After(counter.resetAt) {
+			delete(l.counters, key)
+		}
+	}
+}
+
+// peekEmail читает email из JSON-тела, не лишая обработчик возможности прочитать тело заново
+func peekEmail(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		// Ошибку чтения (например, превышение лимита размера) увидит и обработчик
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		return ""
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+// errReader возвращает сохраненную ошибку при каждом чтении
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failedLogin - обработчик входа, отклоняющий любой пароль
+var failedLogin = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusUnauthorized)
+})
+
+func loginRequest(remoteAddr, email string, headers map[string]string) *http.Request {
+	body := fmt.Sprintf(`{"email":%q,"password":"wrong"}`, email)
+	r := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(body))
+	r.RemoteAddr = remoteAddr
+	r.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	return r
+}
+
+func TestAuthRateLimiterThrottlesOneIP(t *testing.T) {
+	const limit = 5
+	handler := NewAuthRateLimiter(limit, nil).Limit(failedLogin)
+
+	// Каждый запрос - с новым email, поэтому срабатывает именно лимит по IP
+	for i := 0; i < limit; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest("203.0.113.7:5000", fmt.Sprintf("user%d@example.com", i), nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("203.0.113.7:5001", "another@example.com", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt %d: status = %d, want 429", limit+1, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("429 response has no Retry-After header")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("198.51.100.1:5000", "other@example.com", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("other IP: status = %d, want 401", w.Code)
+	}
+}
+
+func TestAuthRateLimiterThrottlesOneEmail(t *testing.T) {
+	const limit = 3
+	handler := NewAuthRateLimiter(limit, nil).Limit(failedLogin)
+
+	for i := 0; i < limit; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest(fmt.Sprintf("203.0.113.%d:5000", i+1), "Victim@Example.com", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, loginRequest("203.0.113.99:5000", "victim@example.com", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429 for the same email from a new IP", w.Code)
+	}
+}
+
+func TestAuthRateLimiterUsesForwardedClient(t *testing.T) {
+	clientIP, err := NewClientIP(false, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("NewClientIP: %v", err)
+	}
+	handler := NewAuthRateLimiter(1, clientIP).Limit(failedLogin)
+
+	send := func(remoteAddr, forwarded, email string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, loginRequest(remoteAddr, email, map[string]string{"X-Forwarded-For": forwarded}))
+		return w.Code
+	}
+
+	// Разные клиенты за одним прокси считаются раздельно
+	if status := send("10.0.0.1:5000", "203.0.113.1", "a@example.com"); status != http.StatusUnauthorized {
+		t.Fatalf("first client: status = %d, want 401", status)
+	}
+	if status := send("10.0.0.1:5000", "203.0.113.2", "b@example.com"); status != http.StatusUnauthorized {
+		t.Fatalf("second client: status = %d, want 401", status)
+	}
+
+	// Подделанный левый элемент цепочки не сбрасывает лимит
+	if status := send("10.0.0.1:5000", "198.51.100.9, 203.0.113.1", "c@example.com"); status != http.StatusTooManyRequests {
+		t.Errorf("spoofed client: status = %d, want 429", status)
+	}
+}
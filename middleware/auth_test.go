@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"student-backend/auth"
+	"student-backend/models"
+	"student-backend/testutil"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testJWTSecret = "test-secret"
+
+// signToken подписывает токен пользователя, выданный issuedAt и действующий ttl
+func signToken(t *testing.T, user *models.User, issuedAt time.Time, ttl time.Duration) string {
+	t.Helper()
+	claims := auth.JWTClaims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			Subject:   user.Email,
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestAuthMiddlewareRefreshesTokenNearExpiry(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := models.User{Email: "admin@example.com", Password: "Secret123!", Role: models.RoleAdmin}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	jwtService := auth.NewJWTService(testJWTSecret, 24, nil)
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		window      int
+		token       string
+		wantRefresh bool
+	}{
+		{"fresh token", 20, signToken(t, &user, now, 24*time.Hour), false},
+		{"near expiry", 20, signToken(t, &user, now.Add(-23*time.Hour), 24*time.Hour), true},
+		{"refresh disabled", 0, signToken(t, &user, now.Add(-23*time.Hour), 24*time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuthMiddleware(jwtService, db, tt.window).AuthMiddleware(okHandler)
+			r := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+
+			refreshed := w.Header().Get(RefreshedTokenHeader)
+			if !tt.wantRefresh {
+				if refreshed != "" {
+					t.Fatalf("%s = %q, want no header", RefreshedTokenHeader, refreshed)
+				}
+				return
+			}
+			claims, err := jwtService.ValidateToken(refreshed)
+			if err != nil {
+				t.Fatalf("refreshed token is invalid: %v", err)
+			}
+			if claims.UserID != user.ID || claims.ExpiresAt.Before(now.Add(23*time.Hour)) {
+				t.Fatalf("refreshed claims = user %d, exp %v; want user %d with a full TTL",
+					claims.UserID, claims.ExpiresAt, user.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"student-backend/httputil"
+)
+
+// LimitBody ограничивает размер тела запроса через http.MaxBytesReader.
+// Для путей из overrides (например, загрузки файлов) действует свой лимит.
+// Запросы с заведомо большим Content-Length отклоняются сразу с 413,
+// остальные получают ошибку *http.MaxBytesError при чтении тела
+func LimitBody(limit int64, overrides map[string]int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			max := limit
+			if pathLimit, ok := overrides[r.URL.Path]; ok {
+				max = pathLimit
+			}
+			if max <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > max {
+				Logf(r.Context(), "❌ Request body too large for %s %s: %d bytes (limit %d)",
+					r.Method, r.URL.Path, r.ContentLength, max)
+				httputil.RespondError(w, http.StatusRequestEntityTooLarge, httputil.CodePayloadTooLarge, "Request body too large",
+					map[string]interface{}{"limit_bytes": max})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIP определяет адрес клиента с учетом доверенных прокси. Один экземпляр
+// используется ограничителями частоты, журналом доступа и списком разрешенных сетей,
+// поэтому все они видят одного и того же клиента. nil - только адрес соединения
+type ClientIP struct {
+	// trustPeer - за сервером один прокси с заранее неизвестным адресом
+	trustPeer bool
+	proxies   []netip.Prefix
+}
+
+// NewClientIP разбирает сети доверенных прокси в формате CIDR или отдельных адресов.
+// X-Forwarded-For учитывается, только если соединение пришло от доверенного прокси.
+// trustProxy без списка прокси доверяет адресу соединения как единственному прокси
+func NewClientIP(trustProxy bool, trustedProxies []string) (*ClientIP, error) {
+	proxies, err := parsePrefixes(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientIP{trustPeer: trustProxy && len(proxies) == 0, proxies: proxies}, nil
+}
+
+// Addr возвращает адрес клиента. Если соединение пришло от доверенного прокси,
+// X-Forwarded-For просматривается справа налево и берется первый адрес,
+// не принадлежащий доверенным прокси: левые элементы клиент может подделать.
+// false - адрес не удалось разобрать
+func (c *ClientIP) Addr(r *http.Request) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(remoteHost(r))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+
+	if c == nil || !(c.trustPeer || containsAddr(c.proxies, addr)) {
+		return addr, true
+	}
+
+	forwarded := strings.Join(r.Header.Values("X-Forwarded-For"), ",")
+	if strings.TrimSpace(forwarded) == "" {
+		return addr, true
+	}
+
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// Непонятный элемент цепочки: дальше ему доверять нельзя
+			return netip.Addr{}, false
+		}
+		hop = hop.Unmap()
+		if !containsAddr(c.proxies, hop) {
+			return hop, true
+		}
+		addr = hop
+	}
+	return addr, true
+}
+
+// String возвращает адрес клиента строкой для ключей ограничителей и журнала.
+// Если адрес не разобран, используется адрес соединения как есть
+func (c *ClientIP) String(r *http.Request) string {
+	if addr, ok := c.Addr(r); ok {
+		return addr.String()
+	}
+	return remoteHost(r)
+}
+
+// remoteHost возвращает хост из адреса соединения
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
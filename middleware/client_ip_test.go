@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		proxies    []string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"no proxy trusted", false, nil, "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"untrusted peer", false, []string{"10.0.0.0/8"}, "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"trusted peer", false, []string{"10.0.0.0/8"}, "10.0.0.1:5000", "198.51.100.1", "198.51.100.1"},
+		{"spoofed left hop", false, []string{"10.0.0.0/8"}, "10.0.0.1:5000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"proxy chain", false, []string{"10.0.0.0/8"}, "10.0.0.1:5000", "198.51.100.1, 10.0.0.2", "198.51.100.1"},
+		{"trust proxy without list", true, nil, "10.0.0.1:5000", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		{"trusted peer without header", false, []string{"10.0.0.0/8"}, "10.0.0.1:5000", "", "10.0.0.1"},
+		{"ipv6 peer", false, []string{"fd00::/8"}, "[fd00::1]:5000", "2001:db8::5", "2001:db8::5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientIP, err := NewClientIP(tt.trustProxy, tt.proxies)
+			if err != nil {
+				t.Fatalf("NewClientIP: %v", err)
+			}
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if got := clientIP.String(r); got != tt.want {
+				t.Errorf("client IP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"student-backend/httputil"
+)
+
+// RequireJSON требует Content-Type: application/json (параметры вроде charset допустимы)
+// для POST/PUT/PATCH запросов с телом и отвечает 415 в остальных случаях.
+// Пути из exemptPaths (например, загрузка файлов) не проверяются
+func RequireJSON(exemptPaths ...string) func(http.Handler) http.Handler {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !hasJSONBodyMethod(r.Method) || r.ContentLength == 0 || exempt[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				Logf(r.Context(), "❌ Unsupported Content-Type %q for %s %s",
+					r.Header.Get("Content-Type"), r.Method, r.URL.Path)
+				httputil.RespondError(w, http.StatusUnsupportedMediaType, httputil.CodeUnsupportedMedia, "Content-Type must be application/json")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func hasJSONBodyMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
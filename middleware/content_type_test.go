@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveWithContentType отправляет POST с телом body через RequireJSON
+// и сообщает код ответа и был ли вызван обработчик
+func serveWithContentType(path, contentType, body string, exempt ...string) (int, bool) {
+	called := false
+	handler := RequireJSON(exempt...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code, called
+}
+
+func TestRequireJSONRejectsFormContentType(t *testing.T) {
+	status, called := serveWithContentType("/api/students", "application/x-www-form-urlencoded", "name=Anna&surname=Smirnova")
+	if status != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", status)
+	}
+	if called {
+		t.Error("handler was called for a form-encoded body")
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		exempt      []string
+		want        int
+	}{
+		{"json", "/api/students", "application/json", `{}`, nil, http.StatusCreated},
+		{"json with charset", "/api/students", "application/json; charset=utf-8", `{}`, nil, http.StatusCreated},
+		{"mixed case", "/api/students", "Application/JSON", `{}`, nil, http.StatusCreated},
+		{"missing", "/api/students", "", `{}`, nil, http.StatusUnsupportedMediaType},
+		{"plain text", "/api/students", "text/plain", `{}`, nil, http.StatusUnsupportedMediaType},
+		{"json suffix lookalike", "/api/students", "application/jsonp", `{}`, nil, http.StatusUnsupportedMediaType},
+		{"malformed", "/api/students", "application/json; charset", `{}`, nil, http.StatusUnsupportedMediaType},
+		{"empty body", "/api/groups/1/archive", "", "", nil, http.StatusCreated},
+		{"exempt path", "/api/upload", "multipart/form-data; boundary=x", "--x--", []string{"/api/upload"}, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, called := serveWithContentType(tt.path, tt.contentType, tt.body, tt.exempt...)
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d", status, tt.want)
+			}
+			if called != (tt.want == http.StatusCreated) {
+				t.Fatalf("handler called = %v with status %d", called, status)
+			}
+		})
+	}
+}
+
+func TestRequireJSONIgnoresBodylessMethods(t *testing.T) {
+	handler := RequireJSON()(okHandler)
+	for _, method := range []string{http.MethodGet, http.MethodDelete, http.MethodOptions} {
+		r := httptest.NewRequest(method, "/api/students", strings.NewReader("ids=1"))
+		r.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", method, w.Code)
+		}
+	}
+}
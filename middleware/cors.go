@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"log"
 	"net/http"
 )
 
@@ -10,12 +9,12 @@ func CORS(next http.Handler) http.Handler {
 		// Устанавливаем CORS заголовки
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin")
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, If-None-Match, X-Request-ID, X-API-Key, Idempotency-Key, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range, ETag, X-Refreshed-Token, X-Request-ID, X-RateLimit-Remaining, Retry-After, Idempotent-Replay, X-Total-Count, Link")
 
 		// Обрабатываем preflight OPTIONS запросы
 		if r.Method == "OPTIONS" {
-			log.Printf(" Handling OPTIONS preflight request for %s", r.URL.Path)
+			Logf(r.Context(), " Handling OPTIONS preflight request for %s", r.URL.Path)
 			w.WriteHeader(http.StatusOK)
 			return
 		}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"student-backend/httputil"
+	"student-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyKeyHeader - заголовок запроса с ключом идемпотентности
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayHeader отмечает ответ, возвращенный из сохраненного
+const IdempotentReplayHeader = "Idempotent-Replay"
+
+// maxIdempotencyKeyLength совпадает с размером колонки key
+const maxIdempotencyKeyLength = 255
+
+// Idempotency сохраняет ответы на запросы создания с заголовком Idempotency-Key,
+// чтобы повтор запроса клиентом после потери ответа не создавал дубликат.
+// Ключи действуют в пределах пользователя и хранятся ttl
+type Idempotency struct {
+	db  *gorm.DB
+	ttl time.Duration
+}
+
+func NewIdempotency(db *gorm.DB, ttl time.Duration) *Idempotency {
+	return &Idempotency{db: db, ttl: ttl}
+}
+
+// Wrap оборачивает обработчик создания. Должен стоять после аутентификации:
+// ключ привязывается к пользователю из токена
+func (i *Idempotency) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		claims := GetUserClaims(r.Context())
+		if key == "" || claims == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if len(key) > maxIdempotencyKeyLength {
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeBadRequest, "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			Logf(r.Context(), "❌ Error reading request body: %v", err)
+			httputil.RespondError(w, http.StatusBadRequest, httputil.CodeInvalidBody, "Cannot read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		db := i.db.WithContext(r.Context())
+		record := models.IdempotencyKey{
+			UserID:      claims.UserID,
+			Key:         key,
+			RequestHash: requestHash(r, body),
+			ExpiresAt:   time.Now().Add(i.ttl),
+		}
+
+		// Ключ резервируется до выполнения запроса, чтобы параллельный повтор
+		// не прошел в обработчик вторым
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			Logf(r.Context(), "❌ Error saving idempotency key: %v", result.Error)
+			httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+			return
+		}
+		if result.RowsAffected == 0 {
+			i.replay(w, r, db, claims.UserID, key, record.RequestHash)
+			return
+		}
+
+		recorder := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Ошибку сервера клиент может повторить с тем же ключом
+		if recorder.status >= http.StatusInternalServerError {
+			if err := i.db.Delete(&record).Error; err != nil {
+				Logf(r.Context(), "❌ Error releasing idempotency key: %v", err)
+			}
+			return
+		}
+
+		err = i.db.Model(&record).Updates(map[string]interface{}{
+			"status_code":   recorder.status,
+			"response_body": recorder.body.String(),
+		}).Error
+		if err != nil {
+			Logf(r.Context(), "❌ Error saving idempotent response: %v", err)
+		}
+	})
+}
+
+// replay отвечает сохраненным ответом на повтор запроса с тем же ключом
+func (i *Idempotency) replay(w http.ResponseWriter, r *http.Request, db *gorm.DB, userID uint, key, hash string) {
+	var stored models.IdempotencyKey
+	if err := db.Where("user_id = ? AND key = ?", userID, key).First(&stored).Error; err != nil {
+		Logf(r.Context(), "❌ Error loading idempotency key: %v", err)
+		httputil.RespondError(w, http.StatusInternalServerError, httputil.CodeInternal, "Internal server error")
+		return
+	}
+
+	if stored.RequestHash != hash {
+		Logf(r.Context(), "❌ Idempotency key reused with a different request by user %d", userID)
+		httputil.RespondError(w, http.StatusUnprocessableEntity, httputil.CodeIdempotencyKeyReused,
+			"Idempotency-Key was already used with a different request")
+		return
+	}
+
+	if stored.StatusCode == 0 {
+		httputil.RespondError(w, http.StatusConflict, httputil.CodeConflict,
+			"A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	Logf(r.Context(), "Replaying idempotent response for user %d", userID)
+	w.Header().Set(IdempotentReplayHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	io.WriteString(w, stored.ResponseBody)
+}
+
+// Sweep периодически удаляет просроченные ключи. Блокирует вызывающую горутину
+func (i *Idempotency) Sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result := i.db.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+		if result.Error != nil {
+			log.Printf("❌ Error sweeping idempotency keys: %v", result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Removed %d expired idempotency keys", result.RowsAffected)
+		}
+	}
+}
+
+// requestHash связывает ключ с конкретным запросом: метод, путь и тело
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseCapture пишет ответ клиенту и одновременно запоминает его для сохранения
+type responseCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) WriteHeader(code int) {
+	c.status = code
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// Unwrap открывает исходный writer для http.ResponseController
+func (c *responseCapture) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"student-backend/httputil"
+)
+
+// IPAllowlist пропускает к защищаемым маршрутам только клиентов из разрешенных
+// сетей (например, диапазона VPN кампуса). Без разрешенных сетей ничего не проверяет
+type IPAllowlist struct {
+	allowed  []netip.Prefix
+	clientIP *ClientIP
+}
+
+// NewIPAllowlist разбирает разрешенные сети в формате CIDR или отдельных адресов.
+// Адрес клиента определяет clientIP с учетом доверенных прокси
+func NewIPAllowlist(allowedCIDRs []string, clientIP *ClientIP) (*IPAllowlist, error) {
+	allowed, err := parsePrefixes(allowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &IPAllowlist{allowed: allowed, clientIP: clientIP}, nil
+}
+
+// Middleware отвечает 403 с кодом ip_not_allowed клиентам вне разрешенных сетей
+func (a *IPAllowlist) Middleware(next http.Handler) http.Handler {
+	if len(a.allowed) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, ok := a.clientIP.Addr(r)
+		if !ok || !containsAddr(a.allowed, ip) {
+			Logf(r.Context(), "❌ %s %s rejected: client IP %s is not allowed", r.Method, r.URL.Path, ip)
+			w.Header().Set("Content-Type", "application/json")
+			httputil.RespondError(w, http.StatusForbidden, httputil.CodeIPNotAllowed, "Access from this network is not allowed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parsePrefixes разбирает список сетей; отдельный адрес считается сетью из одного адреса
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid IP address %q: %w", value, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPAllowlist(t *testing.T) {
+	allowed := []string{"10.8.0.0/16", "192.0.2.10", "2001:db8:1::/48"}
+	direct, err := NewIPAllowlist(allowed, nil)
+	if err != nil {
+		t.Fatalf("NewIPAllowlist: %v", err)
+	}
+	clientIP, err := NewClientIP(false, []string{"172.16.0.0/12", "fd00::/8"})
+	if err != nil {
+		t.Fatalf("NewClientIP: %v", err)
+	}
+	proxied, err := NewIPAllowlist(allowed, clientIP)
+	if err != nil {
+		t.Fatalf("NewIPAllowlist: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		allowlist  *IPAllowlist
+		remoteAddr string
+		forwarded  string
+		want       int
+	}{
+		{"ipv4 in range", direct, "10.8.3.4:5000", "", http.StatusOK},
+		{"ipv4 out of range", direct, "10.9.0.1:5000", "", http.StatusForbidden},
+		{"single address", direct, "192.0.2.10:5000", "", http.StatusOK},
+		{"neighbour of single address", direct, "192.0.2.11:5000", "", http.StatusForbidden},
+		{"ipv4-mapped ipv6", direct, "[::ffff:10.8.0.1]:5000", "", http.StatusOK},
+		{"ipv6 in range", direct, "[2001:db8:1:ff::1]:5000", "", http.StatusOK},
+		{"ipv6 out of range", direct, "[2001:db8:2::1]:5000", "", http.StatusForbidden},
+		// Без доверенных прокси заголовок клиента не учитывается
+		{"spoofed header without proxy", direct, "203.0.113.5:5000", "10.8.0.1", http.StatusForbidden},
+		{"client behind trusted proxy", proxied, "172.16.0.2:5000", "10.8.0.1", http.StatusOK},
+		{"ipv6 client behind trusted proxy", proxied, "[fd00::2]:5000", "2001:db8:1::5", http.StatusOK},
+		// Левый элемент подставлен клиентом, правый дописан прокси
+		{"spoofed left hop behind proxy", proxied, "172.16.0.2:5000", "10.8.0.1, 203.0.113.5", http.StatusForbidden},
+		{"spoofed header from untrusted peer", proxied, "203.0.113.5:5000", "10.8.0.1", http.StatusForbidden},
+		{"garbage in header", proxied, "172.16.0.2:5000", "not-an-ip", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/admin/flags", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+			tt.allowlist.Middleware(okHandler).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPAllowlistWithoutRangesAllowsAll(t *testing.T) {
+	allowlist, err := NewIPAllowlist(nil, nil)
+	if err != nil {
+		t.Fatalf("NewIPAllowlist: %v", err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/flags", nil)
+	r.RemoteAddr = "203.0.113.5:5000"
+	w := httptest.NewRecorder()
+	allowlist.Middleware(okHandler).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+}
+
+func TestNewIPAllowlistRejectsInvalidRanges(t *testing.T) {
+	for _, value := range []string{"10.0.0.0/33", "not-an-ip", "2001:db8::/129"} {
+		if _, err := NewIPAllowlist([]string{value}, nil); err == nil {
+			t.Errorf("NewIPAllowlist(%q) accepted an invalid range", value)
+		}
+	}
+}
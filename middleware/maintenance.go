@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"math"
+	"net/http"
+	"strconv"
+	"student-backend/httputil"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceBypassHeader - заголовок с секретом, который пропускает запрос в режиме обслуживания
+const MaintenanceBypassHeader = "X-Maintenance-Bypass"
+
+// MaintenanceTogglePath - путь переключения режима, остается доступным администраторам
+const MaintenanceTogglePath = "/api/admin/maintenance"
+
+// maintenanceExemptPaths - проверки живости, которые отвечают и в режиме обслуживания
+var maintenanceExemptPaths = map[string]bool{
+	"/health":       true,
+	"/health/live":  true,
+	"/healthz":      true,
+	"/readyz":       true,
+	"/health/ready": true,
+}
+
+// Maintenance - режим обслуживания: пока он включен, все запросы, кроме проверок
+// живости и переключателя режима, получают 503 с Retry-After
+type Maintenance struct {
+	enabled      atomic.Bool
+	notice       atomic.Pointer[MaintenanceNotice]
+	retryAfter   time.Duration
+	bypassSecret string
+}
+
+// MaintenanceNotice - сообщение для клиентов и ожидаемое время окончания обслуживания
+type MaintenanceNotice struct {
+	Message string     `json:"message,omitempty"`
+	ETA     *time.Time `json:"eta,omitempty"`
+}
+
+func NewMaintenance(enabled bool, retryAfter time.Duration, bypassSecret string) *Maintenance {
+	m := &Maintenance{retryAfter: retryAfter, bypassSecret: bypassSecret}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled сообщает, включен ли режим обслуживания
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetEnabled включает или выключает режим обслуживания
+func (m *Maintenance) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Notice возвращает текущее сообщение и время окончания обслуживания
+func (m *Maintenance) Notice() MaintenanceNotice {
+	if notice := m.notice.Load(); notice != nil {
+		return *notice
+	}
+	return MaintenanceNotice{}
+}
+
+// SetNotice задает сообщение и время окончания, которые получат клиенты в ответе 503
+func (m *Maintenance) SetNotice(notice MaintenanceNotice) {
+	m.notice.Store(&notice)
+}
+
+// Toggle переключает режим и возвращает новое состояние
+func (m *Maintenance) Toggle() bool {
+	for {
+		current := m.enabled.Load()
+		if m.enabled.CompareAndSwap(current, !current) {
+			return !current
+		}
+	}
+}
+
+// Middleware отвечает 503 на запросы во время обслуживания
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled() || r.Method == http.MethodOptions || maintenanceExemptPaths[r.URL.Path] ||
+			r.URL.Path == MaintenanceTogglePath || m.bypassed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		notice := m.Notice()
+		message := notice.Message
+		if message == "" {
+			message = "Service is under maintenance, try again later"
+		}
+
+		// До известного времени окончания клиенту нет смысла повторять запрос
+		retryAfter := m.retryAfter
+		var details []interface{}
+		if notice.ETA != nil {
+			if untilETA := time.Until(*notice.ETA); untilETA > 0 {
+				retryAfter = untilETA
+			}
+			details = append(details, map[string]interface{}{"eta": notice.ETA.UTC()})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		httputil.RespondError(w, http.StatusServiceUnavailable, httputil.CodeUnavailable, message, details...)
+	})
+}
+
+// bypassed проверяет секрет обхода режима обслуживания
+func (m *Maintenance) bypassed(r *http.Request) bool {
+	if m.bypassSecret == "" {
+		return false
+	}
+	provided := r.Header.Get(MaintenanceBypassHeader)
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(m.bypassSecret)) == 1
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	m := NewMaintenance(false, 30*time.Second, "secret")
+	handler := m.Middleware(okHandler)
+
+	serveMaintenance := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serveMaintenance(http.MethodGet, "/api/students", nil); w.Code != http.StatusOK {
+		t.Fatalf("disabled: status = %d, want 200", w.Code)
+	}
+
+	m.SetEnabled(true)
+	w := serveMaintenance(http.MethodGet, "/api/students", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "30" {
+		t.Fatalf("enabled: status = %d, Retry-After = %q; want 503 and 30", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	passing := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+	}{
+		{"health check", http.MethodGet, "/health", nil},
+		{"readiness", http.MethodGet, "/readyz", nil},
+		{"toggle", http.MethodPost, MaintenanceTogglePath, nil},
+		{"preflight", http.MethodOptions, "/api/students", nil},
+		{"bypass secret", http.MethodGet, "/api/students", map[string]string{MaintenanceBypassHeader: "secret"}},
+	}
+	for _, tt := range passing {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serveMaintenance(tt.method, tt.path, tt.headers); w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+		})
+	}
+
+	if w := serveMaintenance(http.MethodGet, "/api/students", map[string]string{MaintenanceBypassHeader: "wrong"}); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("wrong bypass secret: status = %d, want 503", w.Code)
+	}
+
+	// Retry-After не раньше объявленного окончания обслуживания
+	eta := time.Now().Add(2 * time.Hour)
+	m.SetNotice(MaintenanceNotice{Message: "Upgrading", ETA: &eta})
+	w = serveMaintenance(http.MethodGet, "/api/students", nil)
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "7200" && retryAfter != "7199" {
+		t.Fatalf("Retry-After with ETA = %q, want about 7200", retryAfter)
+	}
+
+	m.SetEnabled(false)
+	if w := serveMaintenance(http.MethodGet, "/api/students", nil); w.Code != http.StatusOK {
+		t.Fatalf("disabled again: status = %d, want 200", w.Code)
+	}
+}
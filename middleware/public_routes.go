@@ -1,27 +1,32 @@
 package middleware
 
-import (
-	"strings"
-)
+// PublicRoutes - маршруты, доступные без аутентификации. Единственный список:
+// main проверяет, что все они зарегистрированы и что на публичном подроутере
+// API нет других маршрутов. Совпадение только точное: подпути /api/auth/ вроде
+// /api/auth/2fa/setup требуют токена
+var PublicRoutes = []string{
+	"/",
+	"/health",
+	"/health/live",
+	"/healthz",
+	"/readyz",
+	"/health/ready",
+	"/metrics",
+	"/openapi.json",
+	"/docs",
+	"/api/auth/login",
+	"/api/auth/register",
+	"/api/auth/verify",
+	"/api/auth/forgot-password",
+	"/api/auth/reset-password",
+}
 
 // IsPublicRoute проверяет, является ли маршрут публичным
 func IsPublicRoute(path string) bool {
-	publicRoutes := []string{
-		"/",
-		"/health",
-		"/api/auth/login",
-		"/api/auth/register",
-	}
-
-	for _, route := range publicRoutes {
+	for _, route := range PublicRoutes {
 		if path == route {
 			return true
 		}
-		// Для подпутей
-		if strings.HasPrefix(path, "/api/auth/") {
-			return true
-		}
 	}
-
 	return false
 }
@@ -0,0 +1,18 @@
+package middleware
+
+import "net/http"
+
+// QueryToken переносит токен из параметра token в заголовок Authorization
+// для клиентов, которые не могут задать заголовки (WebSocket в браузере).
+// Параметр удаляется из URL, чтобы токен не попал дальше в обработчики и логи
+func QueryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if token := query.Get("token"); token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+			query.Del("token")
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"student-backend/httputil"
+	"sync"
+	"time"
+)
+
+// RateLimiter ограничивает частоту запросов с одного IP алгоритмом token bucket:
+// ведро вмещает burst запросов и пополняется со скоростью rate запросов в секунду
+type RateLimiter struct {
+	rate     float64
+	burst    int
+	clientIP *ClientIP
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	calls   int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter создает ограничитель. rate <= 0 отключает ограничение.
+// Адрес клиента определяет clientIP, общий с остальными middleware
+func NewRateLimiter(rate float64, burst int, clientIP *ClientIP) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:     rate,
+		burst:    burst,
+		clientIP: clientIP,
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// Limit оборачивает обработчик проверкой лимита по IP клиента
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.rate <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ip := l.clientIP.String(r)
+		remaining, retryAfter, ok := l.allow(ip)
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !ok {
+			log.Printf("❌ Rate limit exceeded for %s on %s %s", ip, r.Method, r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			httputil.RespondError(w, http.StatusTooManyRequests, httputil.CodeRateLimited, "Too many requests, try again later")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow списывает токен из ведра ключа. Возвращает остаток токенов
+// и, если токенов нет, время до появления следующего
+func (l *RateLimiter) allow(key string) (int, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(l.burst), bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return 0, wait, false
+	}
+
+	bucket.tokens--
+	return int(bucket.tokens), 0, true
+}
+
+// sweep периодически удаляет ведра, которые успели заполниться полностью:
+// они не отличаются от новых, поэтому хранить их не нужно
+func (l *RateLimiter) sweep(now time.Time) {
+	l.calls++
+	if l.calls%1000 != 0 {
+		return
+	}
+
+	refill := time.Duration(float64(l.burst) / l.rate * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
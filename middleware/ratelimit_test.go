@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func proxiedRequest(forwarded string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+	r.RemoteAddr = "10.0.0.1:5000"
+	r.Header.Set("X-Forwarded-For", forwarded)
+	return r
+}
+
+func TestRateLimiterIgnoresSpoofedForwardedHops(t *testing.T) {
+	clientIP, err := NewClientIP(false, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("NewClientIP: %v", err)
+	}
+	handler := NewRateLimiter(0.001, 2, clientIP).Limit(okHandler)
+
+	// Клиент подставляет новый левый элемент в каждый запрос, прокси дописывает его адрес
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, proxiedRequest(fmt.Sprintf("192.0.2.%d, 198.51.100.1", i+1)))
+		want := http.StatusOK
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("request %d: status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+
+	// Другой клиент за тем же прокси лимит не делит
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, proxiedRequest("198.51.100.2"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("other client: status = %d, want 200", w.Code)
+	}
+}
+
+func TestAccessLogRecordsClientIP(t *testing.T) {
+	var buf bytes.Buffer
+	previous := jsonAccessLogger
+	jsonAccessLogger = log.New(&buf, "", 0)
+	t.Cleanup(func() { jsonAccessLogger = previous })
+
+	clientIP, err := NewClientIP(false, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("NewClientIP: %v", err)
+	}
+	AccessLog(AccessLogJSON, nil, clientIP)(okHandler).
+		ServeHTTP(httptest.NewRecorder(), proxiedRequest("192.0.2.1, 198.51.100.1"))
+
+	var entry accessLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode access log %q: %v", buf.String(), err)
+	}
+	if entry.RemoteIP != "198.51.100.1" {
+		t.Fatalf("remote_ip = %s, want 198.51.100.1", entry.RemoteIP)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader - заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey contextKey = "requestID"
+
+// maxRequestIDLength ограничивает длину идентификатора, пришедшего от клиента
+const maxRequestIDLength = 128
+
+// RequestID берет идентификатор запроса из X-Request-ID или генерирует UUID,
+// сохраняет его в контексте и возвращает в заголовке ответа
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, requestID)))
+	})
+}
+
+// GetRequestID возвращает идентификатор текущего запроса или пустую строку
+func GetRequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// Logf пишет в лог строку с префиксом идентификатора запроса из контекста.
+// Если запрос трассируется, в префикс добавляется trace ID для связи логов с трассами
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		format = "[trace " + spanContext.TraceID().String() + "] " + format
+	}
+	if requestID := GetRequestID(ctx); requestID != "" {
+		format = "[" + requestID + "] " + format
+	}
+	log.Printf(format, args...)
+}
+
+// MaskEmail скрывает адрес, оставляя первую букву и домен: e***@example.com.
+// Используется везде, где email попадает в лог без необходимости
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// newRequestID генерирует случайный UUID версии 4
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		log.Printf("❌ Error generating request ID: %v", err)
+		return "unknown"
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"student-backend/httputil"
+)
+
+// RequireAuth пропускает запрос дальше только при наличии claims в контексте,
+// иначе отвечает 401. Используется после AuthMiddleware, чтобы обработчики
+// могли считать claims заданными
+func RequireAuth() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if GetUserClaims(r.Context()) == nil {
+				httputil.RespondError(w, http.StatusUnauthorized, httputil.CodeUnauthorized, "Not authenticated")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"student-backend/auth"
+	"student-backend/models"
+	"testing"
+)
+
+func TestRequireAuth(t *testing.T) {
+	handler := RequireAuth()(okHandler)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/students", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("without claims: status = %d, want 401", w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+	r = r.WithContext(SetUserClaims(r.Context(), &auth.JWTClaims{UserID: 1, Role: models.RoleStudent}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("with claims: status = %d, want 200", w.Code)
+	}
+}
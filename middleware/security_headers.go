@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// defaultSecurityHeaders - заголовки безопасности для всех ответов.
+// Для JSON политика CSP запрещает загрузку чего-либо, на работу API это не влияет
+var defaultSecurityHeaders = map[string]string{
+	"X-Content-Type-Options":  "nosniff",
+	"X-Frame-Options":         "DENY",
+	"Referrer-Policy":         "strict-origin-when-cross-origin",
+	"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+}
+
+// pageCSP - политики CSP для HTML-страниц: корневая страница использует встроенные стили,
+// /docs загружает Swagger UI с unpkg и запускает встроенный скрипт
+var pageCSP = map[string]string{
+	"/":     "default-src 'self'; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'",
+	"/docs": "default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; style-src 'self' 'unsafe-inline' https://unpkg.com; img-src 'self' data: https:; frame-ancestors 'none'",
+}
+
+// hstsHeaderValue - значение Strict-Transport-Security по умолчанию
+const hstsHeaderValue = "max-age=31536000; includeSubDomains"
+
+// SecurityHeaders добавляет заголовки безопасности к каждому ответу.
+// overrides заменяет значения отдельных заголовков (пустое значение отключает заголовок).
+// Strict-Transport-Security отправляется только для запросов по HTTPS, в том числе
+// через прокси с X-Forwarded-Proto: https, если trustProxy включен
+func SecurityHeaders(overrides map[string]string, trustProxy bool) func(http.Handler) http.Handler {
+	headers := make(map[string]string, len(defaultSecurityHeaders)+len(overrides))
+	for name, value := range defaultSecurityHeaders {
+		headers[name] = value
+	}
+	cspOverridden := false
+	for name, value := range overrides {
+		name = http.CanonicalHeaderKey(name)
+		if name == "Content-Security-Policy" {
+			cspOverridden = true
+		}
+		headers[name] = value
+	}
+	hsts, hstsOverridden := headers["Strict-Transport-Security"]
+	if !hstsOverridden {
+		hsts = hstsHeaderValue
+	}
+	delete(headers, "Strict-Transport-Security")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range headers {
+				if value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+
+			if csp, ok := pageCSP[r.URL.Path]; ok && !cspOverridden {
+				w.Header().Set("Content-Security-Policy", csp)
+			}
+
+			if hsts != "" && isHTTPS(r, trustProxy) {
+				w.Header().Set("Strict-Transport-Security", hsts)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isHTTPS проверяет, пришел ли запрос по TLS напрямую или через доверенный прокси
+func isHTTPS(r *http.Request, trustProxy bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return trustProxy && strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
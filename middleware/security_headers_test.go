@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		path      string
+		tls       bool
+		proto     string
+		want      map[string]string
+	}{
+		{
+			name: "api defaults", path: "/api/students",
+			want: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			name: "root page csp", path: "/",
+			want: map[string]string{"Content-Security-Policy": pageCSP["/"], "X-Frame-Options": "DENY"},
+		},
+		{
+			name: "hsts over tls", path: "/api/students", tls: true,
+			want: map[string]string{"Strict-Transport-Security": hstsHeaderValue},
+		},
+		{
+			name: "forwarded proto from untrusted client", path: "/api/students", proto: "https",
+			want: map[string]string{"Strict-Transport-Security": ""},
+		},
+		{
+			name: "override and disable", path: "/",
+			overrides: map[string]string{"x-frame-options": "SAMEORIGIN", "Content-Security-Policy": ""},
+			want:      map[string]string{"X-Frame-Options": "SAMEORIGIN", "Content-Security-Policy": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			w := httptest.NewRecorder()
+			SecurityHeaders(tt.overrides, false)(okHandler).ServeHTTP(w, r)
+
+			for name, want := range tt.want {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersHSTSBehindTrustedProxy(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/students", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	SecurityHeaders(nil, true)(okHandler).ServeHTTP(w, r)
+
+	if got := w.Header().Get("Strict-Transport-Security"); got != hstsHeaderValue {
+		t.Fatalf("Strict-Transport-Security = %q, want %q", got, hstsHeaderValue)
+	}
+}
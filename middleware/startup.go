@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"student-backend/httputil"
+	"sync/atomic"
+	"time"
+)
+
+// startupLivePaths - проверки живости, которые отвечают 200 и во время запуска
+var startupLivePaths = map[string]bool{
+	"/health/live": true,
+	"/healthz":     true,
+}
+
+// StartupGate принимает соединения, пока сервер запускается: подключение к базе,
+// миграции и заполнение данных еще идут. До Open проверки готовности и все
+// запросы к API получают 503, а не ошибки отсутствующих таблиц
+type StartupGate struct {
+	handler atomic.Pointer[http.Handler]
+}
+
+func NewStartupGate() *StartupGate {
+	return &StartupGate{}
+}
+
+// Open передает дальнейшие запросы полностью инициализированному обработчику
+func (g *StartupGate) Open(handler http.Handler) {
+	g.handler.Store(&handler)
+}
+
+func (g *StartupGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler := g.handler.Load(); handler != nil {
+		(*handler).ServeHTTP(w, r)
+		return
+	}
+
+	if startupLivePaths[r.URL.Path] {
+		httputil.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":    "ok",
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "5")
+	httputil.RespondError(w, http.StatusServiceUnavailable, httputil.CodeUnavailable, "Service is starting")
+}
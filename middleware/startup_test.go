@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStartupGate(t *testing.T) {
+	gate := NewStartupGate()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gate.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	for _, path := range []string{"/health/ready", "/readyz", "/api/students", "/"} {
+		w := get(path)
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s while starting: status = %d, Retry-After = %q; want 503 with Retry-After",
+				path, w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+	for _, path := range []string{"/healthz", "/health/live"} {
+		if w := get(path); w.Code != http.StatusOK {
+			t.Errorf("%s while starting: status = %d, want 200", path, w.Code)
+		}
+	}
+
+	gate.Open(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	for _, path := range []string{"/health/ready", "/healthz", "/api/students"} {
+		if w := get(path); w.Code != http.StatusTeapot {
+			t.Errorf("%s after Open: status = %d, want the handler's 418", path, w.Code)
+		}
+	}
+}
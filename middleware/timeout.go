@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"student-backend/httputil"
+	"sync"
+	"time"
+)
+
+// errHandlerTimeout возвращается обработчику при записи после истечения таймаута
+var errHandlerTimeout = errors.New("handler timed out")
+
+// Timeout ограничивает время обработки запроса: контекст запроса получает дедлайн,
+// поэтому запросы к базе через WithContext(r.Context()) прерываются по его истечении
+// или при отключении клиента. Для путей из overrides (например, выгрузок) действует
+// свой таймаут, значение 0 отключает его. Ответ буферизуется: если обработчик не
+// успел до дедлайна, клиент получает 504 в стандартном формате ошибки.
+// После Flush ответ уже отправлен, и по таймауту только отменяется контекст
+func Timeout(timeout time.Duration, overrides map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := timeout
+			if pathTimeout, ok := overrides[r.URL.Path]; ok {
+				limit = pathTimeout
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), limit)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.commit()
+			case <-ctx.Done():
+				tw.mu.Lock()
+				if tw.committed {
+					// Часть ответа уже у клиента: дожидаемся обработчика,
+					// который увидит отмененный контекст
+					tw.mu.Unlock()
+					select {
+					case p := <-panicked:
+						panic(p)
+					case <-done:
+					}
+					return
+				}
+				tw.timedOut = true
+				tw.mu.Unlock()
+
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					Logf(r.Context(), "❌ Request %s %s timed out after %v", r.Method, r.URL.Path, limit)
+					w.Header().Set("Content-Type", "application/json")
+					httputil.RespondError(w, http.StatusGatewayTimeout, httputil.CodeTimeout, "Request timed out")
+					return
+				}
+				// Клиент отключился, отвечать некому
+				Logf(r.Context(), "Request %s %s canceled by client", r.Method, r.URL.Path)
+			}
+		})
+	}
+}
+
+// timeoutWriter накапливает ответ обработчика до его завершения или до Flush
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	body   bytes.Buffer
+	status int
+
+	mu          sync.Mutex
+	timedOut    bool
+	committed   bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = code
+	if tw.committed {
+		tw.w.WriteHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, errHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.status = http.StatusOK
+	}
+	if tw.committed {
+		return tw.w.Write(b)
+	}
+	return tw.body.Write(b)
+}
+
+// Flush отправляет накопленный ответ клиенту, дальше запись идет напрямую.
+// Нужен потоковым ответам, например выгрузке CSV
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.commit()
+	if flusher, ok := tw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap открывает исходный writer для http.ResponseController
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+// commit переносит заголовки и накопленное тело в исходный writer.
+// Вызывается под tw.mu
+func (tw *timeoutWriter) commit() {
+	if tw.committed {
+		return
+	}
+	tw.committed = true
+
+	dst := tw.w.Header()
+	for name, values := range tw.header {
+		dst[name] = values
+	}
+	if tw.wroteHeader {
+		tw.w.WriteHeader(tw.status)
+	}
+	if tw.body.Len() > 0 {
+		tw.w.Write(tw.body.Bytes())
+		tw.body.Reset()
+	}
+}
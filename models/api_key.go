@@ -0,0 +1,17 @@
+package models
+
+// APIKey - ключ доступа для интеграций без интерактивного входа (заголовок X-API-Key).
+// В Key хранится SHA-256 ключа, сам ключ показывается только при создании
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Key        string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Prefix     string     `json:"prefix" gorm:"not null;size:16"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null;size:100"`
+	LastUsedAt *Timestamp `json:"last_used_at"`
+	CreatedAt  Timestamp  `json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
@@ -0,0 +1,33 @@
+package models
+
+// Действия, фиксируемые в журнале аудита
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+)
+
+// Сущности, фиксируемые в журнале аудита
+const (
+	AuditEntityStudent = "student"
+	AuditEntityTeacher = "teacher"
+	AuditEntityGroup   = "group"
+	AuditEntityUser    = "user"
+	AuditEntityAPIKey  = "api_key"
+)
+
+// AuditLog - запись журнала изменений данных
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"not null;size:20;index"`
+	Entity    string    `json:"entity" gorm:"not null;size:50;index"`
+	EntityID  uint      `json:"entity_id"`
+	Detail    string    `json:"detail" gorm:"size:500"`
+	CreatedAt Timestamp `json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,19 @@
+package models
+
+// AuditEntry - запись журнала изменяющих запросов к API: кто, каким методом
+// и по какому маршруту изменил данные. Дополняет AuditLog, который пишут сами обработчики
+type AuditEntry struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     uint      `json:"user_id" gorm:"index"`
+	Email      string    `json:"email" gorm:"size:255;index"`
+	Method     string    `json:"method" gorm:"not null;size:10"`
+	Route      string    `json:"route" gorm:"not null;size:255"`
+	Entity     string    `json:"entity" gorm:"size:50;index"`
+	EntityID   string    `json:"entity_id,omitempty" gorm:"size:64;index"`
+	StatusCode int       `json:"status_code"`
+	CreatedAt  Timestamp `json:"created_at" gorm:"index"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// NormalizeEmail приводит email к виду, в котором он хранится:
+// без пробелов по краям и в нижнем регистре. Пустой email остается пустым
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// normalizeEmailOnSave нормализует email записи перед Create, Save и Update.
+// При обновлении через map (Updates, Update) или другую структуру (Updates(Student{...}))
+// новое значение лежит в Dest, а не в поле модели, поэтому нормализуется и оно
+func normalizeEmailOnSave(tx *gorm.DB, email *string) {
+	*email = NormalizeEmail(*email)
+	if value, ok := updatedString(tx, "email"); ok {
+		tx.Statement.SetColumn("email", NormalizeEmail(value))
+	}
+}
+
+// updatedString возвращает новое значение столбца, если оно передано в Dest
+// отдельно от модели: в map или в структуре, отличной от модели.
+// Пустое поле структуры Updates не записывает, поэтому оно не возвращается
+func updatedString(tx *gorm.DB, column string) (string, bool) {
+	if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		value, ok := updates[column].(string)
+		return value, ok
+	}
+
+	schema := tx.Statement.Schema
+	dest := reflect.Indirect(reflect.ValueOf(tx.Statement.Dest))
+	if schema == nil || dest.Kind() != reflect.Struct || dest.Type() != schema.ModelType || dest == tx.Statement.ReflectValue {
+		return "", false
+	}
+	field := schema.LookUpField(column)
+	if field == nil {
+		return "", false
+	}
+	value, zero := field.ValueOf(tx.Statement.Context, dest)
+	text, ok := value.(string)
+	return text, ok && !zero
+}
+
+func (s *Student) BeforeSave(tx *gorm.DB) error {
+	normalizeEmailOnSave(tx, &s.Email)
+	return nil
+}
+
+func (t *Teacher) BeforeSave(tx *gorm.DB) error {
+	normalizeEmailOnSave(tx, &t.Email)
+	return nil
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"User@Example.COM", "user@example.com"},
+		{"  padded@example.com\t", "padded@example.com"},
+		{"lower@example.com", "lower@example.com"},
+		{"", ""},
+		{"   ", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.in); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEmailStoredLowercased(t *testing.T) {
+	db := openTestDB(t)
+
+	user := User{Email: " Mixed.Case@Example.COM ", Password: "password123", Role: RoleStudent}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	student := Student{Name: "Anna", Surname: "Smirnova", Email: "Anna.Smirnova@Example.com"}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+	teacher := Teacher{Name: "Ivan", Surname: "Petrov", Email: "IVAN@EXAMPLE.COM "}
+	if err := db.Create(&teacher).Error; err != nil {
+		t.Fatalf("create teacher: %v", err)
+	}
+
+	for _, tt := range []struct {
+		table string
+		id    uint
+		want  string
+	}{
+		{"users", user.ID, "mixed.case@example.com"},
+		{"students", student.ID, "anna.smirnova@example.com"},
+		{"teachers", teacher.ID, "ivan@example.com"},
+	} {
+		if got := storedColumn(t, db, tt.table, "email", tt.id); got != tt.want {
+			t.Errorf("%s email = %q, want %q", tt.table, got, tt.want)
+		}
+	}
+
+	// Нормализация не мешает хэшированию пароля
+	stored := storedColumn(t, db, "users", "password", user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte("password123")) != nil {
+		t.Fatal("password is not hashed after email normalization")
+	}
+}
+
+func TestEmailLowercasedOnUpdate(t *testing.T) {
+	db := openTestDB(t)
+	student := Student{Name: "Anna", Surname: "Smirnova", Email: "anna@example.com"}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+
+	db.Model(&student).Update("email", "Updated@Example.com")
+	if got := storedColumn(t, db, "students", "email", student.ID); got != "updated@example.com" {
+		t.Fatalf("after Update email = %q", got)
+	}
+
+	db.Model(&student).Updates(map[string]interface{}{"email": " Map@Example.com"})
+	if got := storedColumn(t, db, "students", "email", student.ID); got != "map@example.com" {
+		t.Fatalf("after Updates with a map email = %q", got)
+	}
+
+	db.Model(&student).Updates(Student{Email: "Struct@Example.com"})
+	if got := storedColumn(t, db, "students", "email", student.ID); got != "struct@example.com" {
+		t.Fatalf("after Updates with a struct email = %q", got)
+	}
+
+	var loaded Student
+	db.First(&loaded, student.ID)
+	loaded.Email = "SAVED@example.com"
+	db.Save(&loaded)
+	if got := storedColumn(t, db, "students", "email", student.ID); got != "saved@example.com" {
+		t.Fatalf("after Save email = %q", got)
+	}
+}
+
+func TestEmptyStudentEmailStaysEmpty(t *testing.T) {
+	db := openTestDB(t)
+	for _, email := range []string{"", "   "} {
+		student := Student{Name: "No", Surname: "Email", Email: email}
+		if err := db.Create(&student).Error; err != nil {
+			t.Fatalf("create student with email %q: %v", email, err)
+		}
+		if got := storedColumn(t, db, "students", "email", student.ID); got != "" {
+			t.Fatalf("email %q stored as %q, want empty", email, got)
+		}
+	}
+}
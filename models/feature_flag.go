@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// FeatureFlag - сохраненное значение флага функциональности, переключаемого администратором
+type FeatureFlag struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:64"`
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (FeatureFlag) TableName() string {
+	return "feature_flags"
+}
@@ -1,18 +1,48 @@
 // models/group.go или models/common.go
 package models
 
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
+import "gorm.io/gorm"
 
 type Group struct {
 	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name      string         `json:"name" gorm:"not null;size:100"`
-	Code      string         `json:"code" gorm:"unique;not null;size:20"`
+	Code      string         `json:"code" gorm:"not null;size:20;uniqueIndex:idx_groups_code_year_semester"`
+	Year      int            `json:"year" gorm:"not null;default:0;uniqueIndex:idx_groups_code_year_semester"`
+	Semester  int            `json:"semester" gorm:"not null;default:0;uniqueIndex:idx_groups_code_year_semester"`
+	Archived  bool           `json:"archived" gorm:"not null;default:false;index"`
+	CuratorID *uint          `json:"curator_id" gorm:"index"`
+	Curator   *GroupCurator  `json:"curator,omitempty" gorm:"foreignKey:CuratorID"`
 	Students  []Student      `json:"students,omitempty" gorm:"foreignKey:GroupID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt Timestamp      `json:"created_at"`
+	UpdatedAt Timestamp      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
+
+// Допустимый диапазон учебных лет для групп
+const (
+	MinGroupYear = 2000
+	MaxGroupYear = 2100
+)
+
+// IsValidGroupYear проверяет, что учебный год группы попадает в допустимый диапазон
+func IsValidGroupYear(year int) bool {
+	return year >= MinGroupYear && year <= MaxGroupYear
+}
+
+// IsValidSemester проверяет номер семестра: 1 или 2
+func IsValidSemester(semester int) bool {
+	return semester == 1 || semester == 2
+}
+
+// GroupCurator - краткие сведения о кураторе группы (ответственном преподавателе)
+type GroupCurator struct {
+	ID        uint           `json:"id"`
+	Name      string         `json:"name"`
+	Surname   string         `json:"surname"`
+	DeletedAt gorm.DeletedAt `json:"-"`
+}
+
+func (GroupCurator) TableName() string {
+	return "teachers"
+}
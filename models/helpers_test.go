@@ -0,0 +1,43 @@
+package models
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB создает базу SQLite с таблицами моделей во временном каталоге.
+// testutil здесь недоступен: он импортирует database, а тот - models
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sqlite handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&Group{}, &Student{}, &Teacher{}, &User{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	SetPasswordCost(bcrypt.MinCost)
+	return db
+}
+
+// storedColumn читает значение столбца в обход модели и ее хуков
+func storedColumn(t *testing.T, db *gorm.DB, table, column string, id uint) string {
+	t.Helper()
+	var value string
+	if err := db.Table(table).Select(column).Where("id = ?", id).Scan(&value).Error; err != nil {
+		t.Fatalf("read %s.%s: %v", table, column, err)
+	}
+	return value
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyKey - сохраненный ответ на запрос создания с заголовком Idempotency-Key.
+// Повтор запроса с тем же ключом получает этот ответ вместо повторного создания.
+// StatusCode 0 означает, что первый запрос еще выполняется
+type IdempotencyKey struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `gorm:"not null;size:255;uniqueIndex:idx_idempotency_user_key"`
+	RequestHash  string    `gorm:"not null;size:64"`
+	StatusCode   int       `gorm:"not null;default:0"`
+	ResponseBody string    `gorm:"type:text"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// passwordCost - стоимость bcrypt для новых хэшей паролей
+var passwordCost atomic.Int32
+
+func init() {
+	passwordCost.Store(int32(bcrypt.DefaultCost))
+}
+
+// SetPasswordCost задает стоимость bcrypt из конфигурации. Значение вне
+// допустимого диапазона bcrypt заменяется стоимостью по умолчанию
+func SetPasswordCost(cost int) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	passwordCost.Store(int32(cost))
+}
+
+// HashPassword хэширует пароль с настроенной стоимостью
+func HashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), int(passwordCost.Load()))
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+// hashPasswordOnSave хэширует пароль, переданный открытым текстом. Поле модели
+// хэшируется, если отличается от stored - хэша, загруженного из базы или уже
+// сохраненного, поэтому повторное сохранение пользователя пароль не меняет.
+// Значение в Dest при Update и Updates всегда считается открытым текстом:
+// по виду строки нельзя отличить хэш от пароля, похожего на хэш
+func hashPasswordOnSave(tx *gorm.DB, password *string, stored string) error {
+	if *password != "" && *password != stored {
+		hashed, err := HashPassword(*password)
+		if err != nil {
+			return err
+		}
+		*password = hashed
+	}
+
+	if value, ok := updatedString(tx, "password"); ok && value != "" {
+		hashed, err := HashPassword(value)
+		if err != nil {
+			return err
+		}
+		tx.Statement.SetColumn("password", hashed)
+	}
+	return nil
+}
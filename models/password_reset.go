@@ -0,0 +1,15 @@
+package models
+
+// PasswordResetToken - одноразовый токен сброса пароля.
+// В Token хранится SHA-256 от токена из письма, а не сам токен
+type PasswordResetToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Token     string    `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt Timestamp `json:"expires_at" gorm:"not null;index"`
+	CreatedAt Timestamp `json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
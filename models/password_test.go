@@ -0,0 +1,135 @@
+package models
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// expectPassword проверяет, что в базе лежит bcrypt-хэш пароля password
+func expectPassword(t *testing.T, db *gorm.DB, id uint, password string) string {
+	t.Helper()
+	stored := storedColumn(t, db, "users", "password", id)
+	if stored == password {
+		t.Fatalf("password %q stored as plain text", password)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+		t.Fatalf("stored hash does not match %q: %v", password, err)
+	}
+	return stored
+}
+
+func createUser(t *testing.T, db *gorm.DB, password string) *User {
+	t.Helper()
+	user := &User{Email: "user@example.com", Password: password, Role: RoleStudent}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func TestSetPasswordCost(t *testing.T) {
+	defer SetPasswordCost(bcrypt.MinCost)
+
+	tests := []struct {
+		cost, want int
+	}{
+		{bcrypt.MinCost, bcrypt.MinCost},
+		{6, 6},
+		{bcrypt.MinCost - 1, bcrypt.DefaultCost},
+		{bcrypt.MaxCost + 1, bcrypt.DefaultCost},
+	}
+	for _, tt := range tests {
+		SetPasswordCost(tt.cost)
+		hashed, err := HashPassword("password123")
+		if err != nil {
+			t.Fatalf("HashPassword: %v", err)
+		}
+		if got, _ := bcrypt.Cost([]byte(hashed)); got != tt.want {
+			t.Errorf("SetPasswordCost(%d): hash cost = %d, want %d", tt.cost, got, tt.want)
+		}
+	}
+}
+
+func TestCreateHashesPasswordWithConfiguredCost(t *testing.T) {
+	db := openTestDB(t)
+	SetPasswordCost(5)
+	defer SetPasswordCost(bcrypt.MinCost)
+
+	user := createUser(t, db, "password123")
+	stored := expectPassword(t, db, user.ID, "password123")
+	if cost, _ := bcrypt.Cost([]byte(stored)); cost != 5 {
+		t.Fatalf("hash cost = %d, want 5", cost)
+	}
+}
+
+func TestSaveKeepsStoredHash(t *testing.T) {
+	db := openTestDB(t)
+	user := createUser(t, db, "password123")
+	stored := storedColumn(t, db, "users", "password", user.ID)
+
+	// Повторное сохранение той же структуры после Create
+	user.EmailVerified = true
+	if err := db.Save(user).Error; err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if got := storedColumn(t, db, "users", "password", user.ID); got != stored {
+		t.Fatal("saving the created user hashed the hash again")
+	}
+
+	// Сохранение загруженного пользователя
+	var loaded User
+	db.First(&loaded, user.ID)
+	loaded.TwoFactorEnabled = true
+	if err := db.Save(&loaded).Error; err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if got := storedColumn(t, db, "users", "password", user.ID); got != stored {
+		t.Fatal("saving a loaded user hashed the hash again")
+	}
+
+	loaded.Password = "changed123"
+	if err := db.Save(&loaded).Error; err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	expectPassword(t, db, user.ID, "changed123")
+}
+
+func TestPasswordThatLooksLikeHashIsHashed(t *testing.T) {
+	db := openTestDB(t)
+	// Пароль пользователя совпадает по виду с хэшем bcrypt
+	lookalike, err := bcrypt.GenerateFromPassword([]byte("other"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	password := string(lookalike)
+
+	user := createUser(t, db, password)
+	expectPassword(t, db, user.ID, password)
+
+	db.Model(&User{}).Where("id = ?", user.ID).Update("password", password)
+	expectPassword(t, db, user.ID, password)
+}
+
+func TestUpdatesHashPassword(t *testing.T) {
+	db := openTestDB(t)
+	user := createUser(t, db, "password123")
+
+	db.Model(&User{}).Where("id = ?", user.ID).Update("password", "update123")
+	expectPassword(t, db, user.ID, "update123")
+
+	db.Model(user).Updates(map[string]interface{}{"password": "map12345"})
+	expectPassword(t, db, user.ID, "map12345")
+
+	var loaded User
+	db.First(&loaded, user.ID)
+	db.Model(&loaded).Updates(User{Password: "struct123"})
+	stored := expectPassword(t, db, user.ID, "struct123")
+
+	// Updates без пароля его не трогает
+	db.Model(&loaded).Updates(User{EmailVerified: true})
+	if got := storedColumn(t, db, "users", "password", user.ID); got != stored {
+		t.Fatal("Updates without a password changed the stored hash")
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+// Действия над ресурсами
+const (
+	ActionRead   = "read"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Ресурсы API, доступ к которым разграничивается по ролям
+const (
+	ResourceStudents     = "students"
+	ResourceTeachers     = "teachers"
+	ResourceGroups       = "groups"
+	ResourceGroupStats   = "group_stats"
+	ResourceAudit        = "audit"
+	ResourceUsers        = "users"
+	ResourceAPIKeys      = "api_keys"
+	ResourceMaintenance  = "maintenance"
+	ResourceFeatureFlags = "feature_flags"
+	// ResourceLiveUpdates - поток изменений данных через /ws
+	ResourceLiveUpdates = "live_updates"
+)
+
+// rolePermissions - разрешенные действия по ролям и ресурсам.
+// Админ может все. Ограничения по конкретным записям (студент видит и правит
+// только себя, видит только свою группу) проверяются в обработчиках
+var rolePermissions = map[Role]map[string][]string{
+	RoleTeacher: {
+		ResourceStudents:    {ActionRead},
+		ResourceGroups:      {ActionRead},
+		ResourceLiveUpdates: {ActionRead},
+	},
+	RoleStudent: {
+		ResourceStudents: {ActionRead, ActionUpdate},
+		ResourceGroups:   {ActionRead},
+	},
+}
+
+// Can проверяет, разрешено ли роли действие над ресурсом
+func Can(role Role, action, resource string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, allowed := range rolePermissions[role][resource] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Setting - сохраненная строковая настройка, которая должна пережить перезапуск
+type Setting struct {
+	Key       string    `json:"key" gorm:"primaryKey;size:64"`
+	Value     string    `json:"value" gorm:"type:text;not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Setting) TableName() string {
+	return "settings"
+}
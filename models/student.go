@@ -1,10 +1,6 @@
 package models
 
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
+import "gorm.io/gorm"
 
 type Student struct {
 	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -14,8 +10,9 @@ type Student struct {
 	GroupID   *uint          `json:"group_id,omitempty"`
 	Group     *Group         `json:"group,omitempty" gorm:"foreignKey:GroupID"`
 	UserID    *uint          `json:"user_id,omitempty" gorm:"unique"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt Timestamp      `json:"created_at"`
+	UpdatedAt Timestamp      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
@@ -28,12 +25,22 @@ type PaginatedResponse struct {
 	Items interface{} `json:"items"`
 }
 
+// JSONAPIResponse - страница списка в стиле JSON:API для Accept: application/vnd.api+json
+type JSONAPIResponse struct {
+	Data interface{} `json:"data"`
+	Meta Meta        `json:"meta"`
+}
+
 type Meta struct {
-	TotalItems     int `json:"total_items"`
-	TotalPages     int `json:"total_pages"`
-	CurrentPage    int `json:"current_page"`
-	PerPage        int `json:"per_page"`
+	TotalItems  int `json:"total_items"`
+	TotalPages  int `json:"total_pages"`
+	CurrentPage int `json:"current_page"`
+	PerPage     int `json:"per_page"`
+	// RemainingCount - число записей после текущей страницы, 0 на последней
 	RemainingCount int `json:"remaining_count"`
+	// NextCursor - значение after для следующей страницы в режиме курсора,
+	// отсутствует на последней странице и в режиме смещения
+	NextCursor *uint `json:"next_cursor,omitempty"`
 }
 
 type SortConfig struct {
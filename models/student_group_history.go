@@ -0,0 +1,15 @@
+package models
+
+// StudentGroupHistory - запись о смене группы студента
+type StudentGroupHistory struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StudentID   uint      `json:"student_id" gorm:"not null;index"`
+	FromGroupID *uint     `json:"from_group_id"`
+	ToGroupID   *uint     `json:"to_group_id"`
+	ChangedBy   uint      `json:"changed_by" gorm:"not null"`
+	ChangedAt   Timestamp `json:"changed_at" gorm:"not null;index"`
+}
+
+func (StudentGroupHistory) TableName() string {
+	return "student_group_history"
+}
@@ -1,23 +1,45 @@
 package models
 
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
+import "gorm.io/gorm"
 
 type Teacher struct {
-	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name      string         `json:"name" gorm:"not null;size:100"`
-	Surname   string         `json:"surname" gorm:"not null;size:100"`
-	Email     string         `json:"email" gorm:"unique;size:255"`
-	Phone     string         `json:"phone" gorm:"size:20"`
-	Groups    []Group        `json:"groups,omitempty" gorm:"many2many:teacher_groups;"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string         `json:"name" gorm:"not null;size:100"`
+	Surname      string         `json:"surname" gorm:"not null;size:100"`
+	Email        string         `json:"email" gorm:"unique;size:255"`
+	Phone        string         `json:"phone" gorm:"size:20"`
+	Title        string         `json:"title" gorm:"size:50"`
+	DepartmentID *uint          `json:"department_id,omitempty" gorm:"index"`
+	UserID       *uint          `json:"user_id,omitempty" gorm:"unique"`
+	Groups       []Group        `json:"groups,omitempty" gorm:"many2many:teacher_groups;"`
+	Version      int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt    Timestamp      `json:"created_at"`
+	UpdatedAt    Timestamp      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func (Teacher) TableName() string {
 	return "teachers"
 }
+
+// Допустимые ученые звания и должности преподавателей
+var TeacherTitles = []string{
+	"Assistant",
+	"Lecturer",
+	"Senior Lecturer",
+	"Associate Professor",
+	"Professor",
+}
+
+// IsValidTeacherTitle проверяет звание по списку допустимых. Пустое звание допустимо
+func IsValidTeacherTitle(title string) bool {
+	if title == "" {
+		return true
+	}
+	for _, allowed := range TeacherTitles {
+		if title == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Timestamp - время в моделях, сериализуемое в JSON в формате RFC3339 без долей секунды,
+// как и остальные метки времени API. На входе принимается RFC3339 с долями секунды и без них
+type Timestamp time.Time
+
+// Time возвращает значение как time.Time
+func (t Timestamp) Time() time.Time {
+	return time.Time(t)
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + time.Time(t).Format(time.RFC3339) + `"`), nil
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return fmt.Errorf("timestamp must be a string, got %s", s)
+	}
+
+	// RFC3339Nano при разборе допускает отсутствие долей секунды
+	parsed, err := time.Parse(time.RFC3339Nano, s[1:len(s)-1])
+	if err != nil {
+		return fmt.Errorf("invalid timestamp: %w", err)
+	}
+	*t = Timestamp(parsed)
+	return nil
+}
+
+// Scan читает значение из базы данных
+func (t *Timestamp) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = Timestamp{}
+	case time.Time:
+		*t = Timestamp(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Timestamp", value)
+	}
+	return nil
+}
+
+// Value записывает значение в базу данных
+func (t Timestamp) Value() (driver.Value, error) {
+	return time.Time(t), nil
+}
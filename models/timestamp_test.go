@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// secondPrecision - RFC3339 без долей секунды
+var secondPrecision = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})$`)
+
+func TestTimestampJSONHasSecondPrecision(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 20, 30, 123456789, time.UTC)
+	data, err := json.Marshal(Student{Name: "Anna", CreatedAt: Timestamp(at), UpdatedAt: Timestamp(at)})
+	if err != nil {
+		t.Fatalf("marshal student: %v", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal student: %v", err)
+	}
+	if fields["created_at"] != "2024-05-01T10:20:30Z" {
+		t.Fatalf("created_at = %v, want 2024-05-01T10:20:30Z", fields["created_at"])
+	}
+}
+
+func TestTimestampJSONFromDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	student := Student{Name: "Anna", Surname: "Orlova"}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+	var stored Student
+	if err := db.First(&stored, student.ID).Error; err != nil {
+		t.Fatalf("reload student: %v", err)
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatalf("marshal student: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal student: %v", err)
+	}
+	for _, key := range []string{"created_at", "updated_at"} {
+		value, _ := fields[key].(string)
+		if !secondPrecision.MatchString(value) {
+			t.Errorf("%s = %q, want RFC3339 without fractional seconds", key, value)
+		}
+	}
+}
+
+func TestTimestampUnmarshalAcceptsFractionalSeconds(t *testing.T) {
+	for _, input := range []string{`"2024-05-01T10:20:30Z"`, `"2024-05-01T10:20:30.123456Z"`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err != nil {
+			t.Fatalf("unmarshal %s: %v", input, err)
+		}
+		if got := ts.Time().Truncate(time.Second); !got.Equal(time.Date(2024, 5, 1, 10, 20, 30, 0, time.UTC)) {
+			t.Errorf("unmarshal %s = %v", input, got)
+		}
+	}
+}
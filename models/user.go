@@ -1,49 +1,88 @@
 package models
 
-import (
-	"time"
+import "gorm.io/gorm"
 
-	"gorm.io/gorm"
-)
+// Role - роль пользователя
+type Role string
 
 // Роли пользователей
 const (
-	RoleAdmin   = "admin"
-	RoleTeacher = "teacher"
-	RoleStudent = "student"
+	RoleAdmin   Role = "admin"
+	RoleTeacher Role = "teacher"
+	RoleStudent Role = "student"
 )
 
+// IsValid сообщает, является ли значение одной из известных ролей
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleTeacher, RoleStudent:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	Email     string         `json:"email" gorm:"unique;not null;size:255"`
-	Password  string         `json:"-" gorm:"not null;size:255"`
-	Role      string         `json:"role" gorm:"not null;size:50"`
-	StudentID *uint          `json:"student_id,omitempty" gorm:"unique"`
-	TeacherID *uint          `json:"teacher_id,omitempty" gorm:"unique"`
-	Student   *Student       `json:"student,omitempty" gorm:"foreignKey:StudentID"`
-	Teacher   *Teacher       `json:"teacher,omitempty" gorm:"foreignKey:TeacherID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID       uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Email    string `json:"email" gorm:"unique;not null;size:255"`
+	Password string `json:"-" gorm:"not null;size:255"`
+	Role     Role   `json:"role" gorm:"not null;size:50"`
+	// Подтверждение email: токен из письма сбрасывается после подтверждения
+	EmailVerified     bool           `json:"email_verified" gorm:"not null;default:false"`
+	VerificationToken string         `json:"-" gorm:"size:64;index"`
+	TwoFactorSecret   string         `json:"-" gorm:"size:64"`                                 // секрет TOTP в base32
+	TwoFactorEnabled  bool           `json:"two_factor_enabled" gorm:"not null;default:false"` // включается после проверки первого кода
+	StudentID         *uint          `json:"student_id,omitempty" gorm:"unique"`
+	TeacherID         *uint          `json:"teacher_id,omitempty" gorm:"unique"`
+	Student           *Student       `json:"student,omitempty" gorm:"foreignKey:StudentID"`
+	Teacher           *Teacher       `json:"teacher,omitempty" gorm:"foreignKey:TeacherID"`
+	CreatedAt         Timestamp      `json:"created_at"`
+	UpdatedAt         Timestamp      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// passwordHash - значение Password, загруженное из базы или сохраненное
+	// последним. Password, совпадающий с ним, уже хэширован, любое другое
+	// непустое значение - пароль открытым текстом
+	passwordHash string
 }
 
 func (User) TableName() string {
 	return "users"
 }
 
+// BeforeSave нормализует email и хэширует пароль, переданный открытым текстом,
+// чтобы ни один путь записи не сохранил пароль как есть
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	normalizeEmailOnSave(tx, &u.Email)
+	return hashPasswordOnSave(tx, &u.Password, u.passwordHash)
+}
+
+// AfterSave запоминает сохраненный хэш пароля
+func (u *User) AfterSave(tx *gorm.DB) error {
+	u.passwordHash = u.Password
+	return nil
+}
+
+// AfterFind запоминает загруженный хэш пароля
+func (u *User) AfterFind(tx *gorm.DB) error {
+	u.passwordHash = u.Password
+	return nil
+}
+
 // Запросы для аутентификации
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+	// Code - одноразовый код TOTP, обязателен при включенной 2FA
+	Code string `json:"code,omitempty"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"` // не выдается при регистрации, пока email не подтвержден
 	User  User   `json:"user"`
 }
 
 type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,oneof=admin teacher student"`
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Role     Role   `json:"role" validate:"required,valid"`
 }
@@ -0,0 +1,175 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait - предельное время записи одного сообщения клиенту
+	writeWait = 10 * time.Second
+	// pongWait - сколько ждать pong, прежде чем считать клиента отключенным
+	pongWait = 60 * time.Second
+	// pingPeriod - период ping, меньше pongWait
+	pingPeriod = pongWait * 9 / 10
+	// sendBuffer - очередь сообщений клиента; медленный клиент с полной очередью отключается
+	sendBuffer = 64
+)
+
+// Event - событие, рассылаемое подключенным клиентам
+type Event struct {
+	Type string      `json:"type"`
+	ID   uint        `json:"id"`
+	Data interface{} `json:"data,omitempty"`
+	// AdminOnly - событие получают только администраторы
+	AdminOnly bool `json:"-"`
+}
+
+// client - подключение одного клиента
+type client struct {
+	conn  *websocket.Conn
+	send  chan []byte
+	admin bool
+}
+
+// outgoing - событие, подготовленное к рассылке
+type outgoing struct {
+	payload   []byte
+	adminOnly bool
+}
+
+// Hub держит подключенных клиентов и рассылает им события. Список клиентов
+// меняется только в горутине Run, поэтому блокировки не нужны
+type Hub struct {
+	register   chan *client
+	unregister chan *client
+	broadcast  chan outgoing
+	clients    map[*client]bool
+	upgrader   websocket.Upgrader
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		register:   make(chan *client),
+		unregister: make(chan *client),
+		broadcast:  make(chan outgoing, 256),
+		clients:    make(map[*client]bool),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// Доступ проверяется по токену, а не по cookie, поэтому Origin не ограничиваем
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Run обслуживает регистрацию клиентов и рассылку, запускается в отдельной горутине
+func (h *Hub) Run() {
+	for {
+		select {
+		case c := <-h.register:
+			h.clients[c] = true
+		case c := <-h.unregister:
+			if h.clients[c] {
+				delete(h.clients, c)
+				close(c.send)
+			}
+		case msg := <-h.broadcast:
+			for c := range h.clients {
+				if msg.adminOnly && !c.admin {
+					continue
+				}
+				select {
+				case c.send <- msg.payload:
+				default:
+					// Клиент не успевает читать - отключаем, чтобы не держать память
+					delete(h.clients, c)
+					close(c.send)
+				}
+			}
+		}
+	}
+}
+
+// Broadcast ставит событие в очередь рассылки и не блокирует вызывающего:
+// при переполненной очереди событие отбрасывается
+func (h *Hub) Broadcast(event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("❌ Error encoding %s event: %v", event.Type, err)
+		return
+	}
+
+	select {
+	case h.broadcast <- outgoing{payload: payload, adminOnly: event.AdminOnly}:
+	default:
+		log.Printf("❌ Event queue is full, dropping %s event for %d", event.Type, event.ID)
+	}
+}
+
+// Serve переключает соединение на WebSocket и подписывает клиента на события.
+// admin определяет, получает ли клиент события только для администраторов
+func (h *Hub) Serve(w http.ResponseWriter, r *http.Request, admin bool) error {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+
+	c := &client{conn: conn, send: make(chan []byte, sendBuffer), admin: admin}
+	h.register <- c
+
+	go h.writePump(c)
+	go h.readPump(c)
+	return nil
+}
+
+// readPump читает входящие сообщения только ради pong и закрытия соединения
+func (h *Hub) readPump(c *client) {
+	defer func() {
+		h.unregister <- c
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump отправляет клиенту события и периодический ping
+func (h *Hub) writePump(c *client) {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package settings
+
+import (
+	"student-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Get читает значения настроек по ключам. Отсутствующих ключей в результате нет
+func Get(db *gorm.DB, keys ...string) (map[string]string, error) {
+	var rows []models.Setting
+	if err := db.Where("key IN ?", keys).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(rows))
+	for _, row := range rows {
+		values[row.Key] = row.Value
+	}
+	return values, nil
+}
+
+// Set сохраняет значения настроек, перезаписывая существующие
+func Set(db *gorm.DB, values map[string]string) error {
+	rows := make([]models.Setting, 0, len(values))
+	for key, value := range values {
+		rows = append(rows, models.Setting{Key: key, Value: value})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&rows).Error
+}
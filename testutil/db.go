@@ -0,0 +1,50 @@
+// Package testutil содержит общие помощники тестов. Импортируется только
+// из _test.go, поэтому драйвер SQLite не попадает в сервер
+package testutil
+
+import (
+	"path/filepath"
+	"student-backend/config"
+	"student-backend/database"
+	"student-backend/models"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewDB создает пустую базу SQLite во временном каталоге теста и применяет
+// к ней миграции. Файл, а не :memory:, нужен, чтобы все соединения пула
+// видели одну базу
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_foreign_keys=on"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get test database handle: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db, &config.Config{}); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// Config возвращает конфигурацию по умолчанию без ограничений частоты запросов,
+// мешающих тестам, и с минимальной стоимостью bcrypt
+func Config() *config.Config {
+	cfg := config.Load()
+	cfg.AuthRateLimitPerMinute = 0
+	cfg.RateLimitRPS = 0
+	cfg.BcryptCost = 4
+	models.SetPasswordCost(cfg.BcryptCost)
+	return cfg
+}